@@ -2,7 +2,10 @@
 // This file implements the agent card functionality as defined in the A2A schema
 package a2a
 
-import "encoding/json"
+import (
+    "encoding/json"
+    "strings"
+)
 
 // AgentAuthentication represents the authentication requirements of an agent
 type AgentAuthentication struct {
@@ -34,6 +37,18 @@ type AgentSkill struct {
     OutputModes []string `json:"outputModes,omitempty"`
 }
 
+// Transports an agent can be reached over
+const (
+    TransportJSONRPC   = "JSONRPC"
+    TransportWebSocket = "WEBSOCKET"
+)
+
+// AgentInterface is a URL the agent can also be reached at over the given transport
+type AgentInterface struct {
+    URL       string `json:"url"`
+    Transport string `json:"transport"`
+}
+
 // AgentCard represents an agent's capabilities according to the A2A protocol
 type AgentCard struct {
     Name             string             `json:"name"`
@@ -41,12 +56,15 @@ type AgentCard struct {
     URL              string             `json:"url"`
     Provider         *AgentProvider     `json:"provider,omitempty"`
     Version          string             `json:"version"`
+    ProtocolVersion  ProtocolVersion    `json:"protocolVersion,omitempty"`
     DocumentationURL *string            `json:"documentationUrl,omitempty"`
     Capabilities     AgentCapabilities  `json:"capabilities"`
     Authentication   *AgentAuthentication `json:"authentication,omitempty"`
     DefaultInputModes []string          `json:"defaultInputModes,omitempty"`
     DefaultOutputModes []string         `json:"defaultOutputModes,omitempty"`
     Skills           []AgentSkill       `json:"skills"`
+    PreferredTransport   string           `json:"preferredTransport,omitempty"`
+    AdditionalInterfaces []AgentInterface `json:"additionalInterfaces,omitempty"`
 }
 
 // NewAgentCard creates a new agent card with required fields
@@ -55,6 +73,7 @@ func NewAgentCard(name, url, version string, capabilities AgentCapabilities, ski
         Name:               name,
         URL:                url,
         Version:            version,
+        ProtocolVersion:    ProtocolVersionCurrent,
         Capabilities:       capabilities,
         Skills:             skills,
         DefaultInputModes:  []string{"text"},
@@ -77,23 +96,6 @@ func FromJSON(data []byte) (*AgentCard, error) {
     return &card, nil
 }
 
-// Validate checks if the agent card is valid according to the A2A schema
-func (a *AgentCard) Validate() bool {
-    // Required fields check
-    if a.Name == "" || a.URL == "" || a.Version == "" || len(a.Skills) == 0 {
-        return false
-    }
-    
-    // Skills validation
-    for _, skill := range a.Skills {
-        if skill.ID == "" || skill.Name == "" {
-            return false
-        }
-    }
-    
-    return true
-}
-
 // WithDescription adds a description to the agent card
 func (a *AgentCard) WithDescription(description string) *AgentCard {
     a.Description = &description
@@ -109,6 +111,25 @@ func (a *AgentCard) WithProvider(organization string, url *string) *AgentCard {
     return a
 }
 
+// WithInterface advertises a URL the agent can also be reached at over the given transport
+func (a *AgentCard) WithInterface(url, transport string) *AgentCard {
+    a.AdditionalInterfaces = append(a.AdditionalInterfaces, AgentInterface{URL: url, Transport: transport})
+    return a
+}
+
+// InterfaceURL returns the URL the agent is reached at over the transport, or "" if it is not advertised
+func (a *AgentCard) InterfaceURL(transport string) string {
+    if strings.EqualFold(a.PreferredTransport, transport) {
+        return a.URL
+    }
+    for _, iface := range a.AdditionalInterfaces {
+        if strings.EqualFold(iface.Transport, transport) {
+            return iface.URL
+        }
+    }
+    return ""
+}
+
 /*
 card := NewAgentCard("MyAgent", "https://example.com/agent", "1.0", capabilities, skills).
     WithDescription("This is my agent").
@@ -65,6 +65,24 @@ func (a *Artifact) ToJSON() ([]byte, error) {
     return json.Marshal(a)
 }
 
+// UnmarshalJSON decodes an artifact, resolving each part to its concrete type
+func (a *Artifact) UnmarshalJSON(data []byte) error {
+    type ArtifactAlias Artifact
+    var artifact struct {
+        *ArtifactAlias
+        Parts []json.RawMessage `json:"parts"`
+    }
+    artifact.ArtifactAlias = (*ArtifactAlias)(a)
+
+    err := json.Unmarshal(data, &artifact)
+    if err != nil {
+        return err
+    }
+
+    a.Parts, err = unmarshalParts(artifact.Parts)
+    return err
+}
+
 // ArtifactFromJSON parses JSON into an artifact
 func ArtifactFromJSON(data []byte) (*Artifact, error) {
     var artifact Artifact
@@ -0,0 +1,353 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the reassembly of artifacts streamed in chunks
+package a2a
+
+import (
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+)
+
+// Default limits of an ArtifactAssembler
+const (
+    DefaultMaxArtifactBytes    = 32 << 20
+    DefaultMaxPendingArtifacts = 64
+    DefaultMaxAssemblerTasks   = 1024
+)
+
+// Errors returned for chunks that cannot be assembled
+var (
+    // ErrArtifactChunkMissing means a chunk continues an artifact whose earlier chunks were not received
+    ErrArtifactChunkMissing = errors.New("a2a: artifact chunk missing")
+    // ErrArtifactChunkOutOfOrder means a chunk arrived for an artifact that is already complete, or restarts one in progress
+    ErrArtifactChunkOutOfOrder = errors.New("a2a: artifact chunk out of order")
+    // ErrArtifactTooLarge means the assembled artifacts exceed the configured memory limit
+    ErrArtifactTooLarge = errors.New("a2a: artifact too large")
+)
+
+// ArtifactCompleteFunc is called with each artifact whose last chunk was received
+type ArtifactCompleteFunc func(taskID string, artifact *Artifact)
+
+// artifactKey identifies an artifact of a task
+type artifactKey struct {
+    taskID string
+    index  int
+}
+
+// partBuffer accumulates the content of one part across chunks
+type partBuffer struct {
+    part  Part
+    text  strings.Builder
+    bytes []byte
+}
+
+// assemblyTask tracks the artifacts of one task
+type assemblyTask struct {
+    completed map[int]bool
+    nextIndex int
+    // seq orders tasks by their latest chunk, so the least recently used is evicted first
+    seq uint64
+}
+
+// pendingArtifact is an artifact whose last chunk has not been received yet
+type pendingArtifact struct {
+    artifact Artifact
+    parts    []*partBuffer
+    size     int64
+}
+
+// ArtifactAssembler stitches artifacts streamed as TaskArtifactUpdateEvent chunks back together.
+// A chunk with Append set continues the artifact of the same index: text is concatenated to a
+// trailing text part and file bytes to a trailing file part, other parts are added as they are.
+// An artifact is complete when a chunk sets LastChunk, or when a chunk sets neither Append nor
+// LastChunk and so carries the whole artifact. Complete artifacts are handed out and forgotten,
+// so memory is held only for artifacts in progress and, for a bounded number of tasks,
+// for the indexes already complete; the least recently updated task is dropped first.
+type ArtifactAssembler struct {
+    maxBytes   int64
+    maxPending int
+    maxTasks   int
+    onComplete ArtifactCompleteFunc
+
+    mu      sync.Mutex
+    pending map[artifactKey]*pendingArtifact
+    tasks   map[string]*assemblyTask
+    seq     uint64
+    size    int64
+}
+
+// NewArtifactAssembler creates an assembler with the default limits
+func NewArtifactAssembler() *ArtifactAssembler {
+    return &ArtifactAssembler{
+        maxBytes:   DefaultMaxArtifactBytes,
+        maxPending: DefaultMaxPendingArtifacts,
+        maxTasks:   DefaultMaxAssemblerTasks,
+        pending:    make(map[artifactKey]*pendingArtifact),
+        tasks:      make(map[string]*assemblyTask),
+    }
+}
+
+// WithMaxBytes sets how many text and file bytes the artifacts in progress may hold in total
+func (a *ArtifactAssembler) WithMaxBytes(maxBytes int64) *ArtifactAssembler {
+    a.maxBytes = maxBytes
+    return a
+}
+
+// WithMaxPending sets how many artifacts may be in progress at the same time
+func (a *ArtifactAssembler) WithMaxPending(maxPending int) *ArtifactAssembler {
+    a.maxPending = maxPending
+    return a
+}
+
+// WithMaxTasks sets how many tasks the assembler tracks before dropping the least recently updated one
+func (a *ArtifactAssembler) WithMaxTasks(maxTasks int) *ArtifactAssembler {
+    if maxTasks > 0 {
+        a.maxTasks = maxTasks
+    }
+    return a
+}
+
+// OnComplete sets the function called with each completed artifact
+func (a *ArtifactAssembler) OnComplete(fn ArtifactCompleteFunc) *ArtifactAssembler {
+    a.onComplete = fn
+    return a
+}
+
+// Add applies a chunk and returns the artifact assembled so far and whether it is complete.
+// A chunk that cannot be applied leaves the assembler unchanged, except that an artifact
+// exceeding the memory limit is dropped.
+func (a *ArtifactAssembler) Add(event *TaskArtifactUpdateEvent) (*Artifact, bool, error) {
+    chunk := &event.Artifact
+    key := artifactKey{taskID: event.ID, index: chunk.Index}
+    appending := chunk.Append != nil && *chunk.Append
+    last := chunk.LastChunk != nil && *chunk.LastChunk
+    complete := last || (chunk.Append == nil && chunk.LastChunk == nil)
+
+    a.mu.Lock()
+    pending := a.pending[key]
+    task := a.tasks[event.ID]
+    if task == nil {
+        task = &assemblyTask{completed: make(map[int]bool)}
+    }
+    switch {
+    case task.completed[chunk.Index]:
+        a.mu.Unlock()
+        return nil, false, fmt.Errorf("%w: task %s artifact %d is already complete", ErrArtifactChunkOutOfOrder, event.ID, chunk.Index)
+    case appending && pending == nil:
+        a.mu.Unlock()
+        return nil, false, fmt.Errorf("%w: task %s artifact %d continues without a first chunk", ErrArtifactChunkMissing, event.ID, chunk.Index)
+    case !appending && pending != nil:
+        a.mu.Unlock()
+        return nil, false, fmt.Errorf("%w: task %s artifact %d restarts before its last chunk", ErrArtifactChunkOutOfOrder, event.ID, chunk.Index)
+    case !appending && chunk.Index > task.nextIndex:
+        a.mu.Unlock()
+        return nil, false, fmt.Errorf("%w: task %s artifact %d arrived before artifact %d", ErrArtifactChunkMissing, event.ID, chunk.Index, task.nextIndex)
+    case pending == nil && !complete && a.maxPending > 0 && len(a.pending) >= a.maxPending:
+        a.mu.Unlock()
+        return nil, false, fmt.Errorf("%w: more than %d artifacts in progress", ErrArtifactTooLarge, a.maxPending)
+    }
+
+    if pending == nil {
+        pending = &pendingArtifact{}
+    }
+    added, err := pending.apply(chunk)
+    if err != nil {
+        a.mu.Unlock()
+        return nil, false, err
+    }
+    if a.maxBytes > 0 && a.size+added > a.maxBytes {
+        if _, ok := a.pending[key]; ok {
+            a.size -= pending.size - added
+            delete(a.pending, key)
+        }
+        a.mu.Unlock()
+        return nil, false, fmt.Errorf("%w: task %s artifact %d exceeds %d bytes", ErrArtifactTooLarge, event.ID, chunk.Index, a.maxBytes)
+    }
+
+    if _, ok := a.tasks[event.ID]; !ok {
+        a.evictTasks()
+        a.tasks[event.ID] = task
+    }
+    a.seq++
+    task.seq = a.seq
+    if chunk.Index >= task.nextIndex {
+        task.nextIndex = chunk.Index + 1
+    }
+    artifact := pending.build()
+    if complete {
+        if _, ok := a.pending[key]; ok {
+            a.size -= pending.size - added
+            delete(a.pending, key)
+        }
+        task.completed[chunk.Index] = true
+    } else {
+        a.pending[key] = pending
+        a.size += added
+    }
+    onComplete := a.onComplete
+    a.mu.Unlock()
+
+    if complete && onComplete != nil {
+        onComplete(event.ID, artifact)
+    }
+    return artifact, complete, nil
+}
+
+// Pending returns the artifacts of the task that are still in progress, as assembled so far
+func (a *ArtifactAssembler) Pending(taskID string) []*Artifact {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    task := a.tasks[taskID]
+    if task == nil {
+        return nil
+    }
+    var artifacts []*Artifact
+    for index := 0; index < task.nextIndex; index++ {
+        if pending := a.pending[artifactKey{taskID: taskID, index: index}]; pending != nil {
+            artifacts = append(artifacts, pending.build())
+        }
+    }
+    return artifacts
+}
+
+// Forget drops everything held for the task, such as after its final status update
+func (a *ArtifactAssembler) Forget(taskID string) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.forget(taskID)
+}
+
+// forget drops the task and its artifacts in progress; the caller holds a.mu
+func (a *ArtifactAssembler) forget(taskID string) {
+    for key, pending := range a.pending {
+        if key.taskID == taskID {
+            a.size -= pending.size
+            delete(a.pending, key)
+        }
+    }
+    delete(a.tasks, taskID)
+}
+
+// evictTasks drops the least recently updated tasks to make room for a new one; the caller holds a.mu
+func (a *ArtifactAssembler) evictTasks() {
+    for len(a.tasks) >= a.maxTasks {
+        var oldestID string
+        var oldest *assemblyTask
+        for id, task := range a.tasks {
+            if oldest == nil || task.seq < oldest.seq {
+                oldestID, oldest = id, task
+            }
+        }
+        a.forget(oldestID)
+    }
+}
+
+// apply merges a chunk into the artifact and returns the number of bytes it added
+func (p *pendingArtifact) apply(chunk *Artifact) (int64, error) {
+    // Decode file bytes first so an invalid chunk leaves the artifact unchanged
+    decoded := make([][]byte, len(chunk.Parts))
+    for i, part := range chunk.Parts {
+        if file, ok := part.(FilePart); ok && file.File.Bytes != "" {
+            data, err := base64.StdEncoding.DecodeString(file.File.Bytes)
+            if err != nil {
+                return 0, fmt.Errorf("a2a: invalid file bytes in artifact %d: %w", chunk.Index, err)
+            }
+            decoded[i] = data
+        }
+    }
+
+    var added int64
+    for i, part := range chunk.Parts {
+        if i == 0 && len(p.parts) > 0 {
+            if last := p.parts[len(p.parts)-1]; last.merge(part, decoded[i]) {
+                added += partSize(part, decoded[i])
+                continue
+            }
+        }
+        p.parts = append(p.parts, newPartBuffer(part, decoded[i]))
+        added += partSize(part, decoded[i])
+    }
+
+    if chunk.Name != nil {
+        p.artifact.Name = chunk.Name
+    }
+    if chunk.Description != nil {
+        p.artifact.Description = chunk.Description
+    }
+    for name, value := range chunk.Metadata {
+        if p.artifact.Metadata == nil {
+            p.artifact.Metadata = make(map[string]interface{})
+        }
+        p.artifact.Metadata[name] = value
+    }
+    p.artifact.Index = chunk.Index
+    p.size += added
+    return added, nil
+}
+
+// build returns the artifact assembled so far
+func (p *pendingArtifact) build() *Artifact {
+    artifact := p.artifact
+    artifact.Append = nil
+    artifact.LastChunk = nil
+    artifact.Parts = make([]Part, len(p.parts))
+    for i, buffer := range p.parts {
+        artifact.Parts[i] = buffer.build()
+    }
+    return &artifact
+}
+
+// newPartBuffer starts a buffer with the content of part
+func newPartBuffer(part Part, data []byte) *partBuffer {
+    buffer := &partBuffer{part: part, bytes: data}
+    if text, ok := part.(TextPart); ok {
+        buffer.text.WriteString(text.Text)
+    }
+    return buffer
+}
+
+// merge appends the content of part to the buffer if both hold text, or both hold file bytes
+func (b *partBuffer) merge(part Part, data []byte) bool {
+    switch current := b.part.(type) {
+    case TextPart:
+        text, ok := part.(TextPart)
+        if !ok {
+            return false
+        }
+        b.text.WriteString(text.Text)
+        return true
+    case FilePart:
+        file, ok := part.(FilePart)
+        if !ok || current.File.URI != "" || file.File.URI != "" {
+            return false
+        }
+        b.bytes = append(b.bytes, data...)
+        return true
+    }
+    return false
+}
+
+// build returns the part with its accumulated content
+func (b *partBuffer) build() Part {
+    switch part := b.part.(type) {
+    case TextPart:
+        part.Text = b.text.String()
+        return part
+    case FilePart:
+        if part.File.URI == "" {
+            part.File.Bytes = base64.StdEncoding.EncodeToString(b.bytes)
+        }
+        return part
+    }
+    return b.part
+}
+
+// partSize returns the number of text or file bytes a part holds
+func partSize(part Part, data []byte) int64 {
+    if text, ok := part.(TextPart); ok {
+        return int64(len(text.Text))
+    }
+    return int64(len(data))
+}
@@ -0,0 +1,101 @@
+package a2a_test
+
+import (
+    "encoding/base64"
+    "errors"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func artifactChunk(index int, append, last bool, parts ...a2a.Part) *a2a.TaskArtifactUpdateEvent {
+    artifact := a2a.NewArtifact(parts).WithIndex(index).WithAppend(append).WithLastChunk(last)
+    return &a2a.TaskArtifactUpdateEvent{ID: "task-1", Artifact: *artifact}
+}
+
+func fileChunkPart(data string) a2a.Part {
+    return a2a.NewFilePart(a2a.NewFileContentWithBytes("out.bin", "application/octet-stream", base64.StdEncoding.EncodeToString([]byte(data))))
+}
+
+func TestArtifactAssembler(t *testing.T) {
+    var completed []*a2a.Artifact
+    assembler := a2a.NewArtifactAssembler().OnComplete(func(taskID string, artifact *a2a.Artifact) {
+        completed = append(completed, artifact)
+    })
+
+    steps := []*a2a.TaskArtifactUpdateEvent{
+        artifactChunk(0, false, false, a2a.NewTextPart("Hello, ")),
+        artifactChunk(1, false, false, fileChunkPart("abc")),
+        artifactChunk(0, true, false, a2a.NewTextPart("wor")),
+        artifactChunk(1, true, true, fileChunkPart("def")),
+        artifactChunk(0, true, true, a2a.NewTextPart("ld"), a2a.NewDataPart(map[string]interface{}{"n": 1})),
+    }
+    for i, event := range steps {
+        if _, _, err := assembler.Add(event); err != nil {
+            t.Fatalf("Add %d failed: %v", i, err)
+        }
+    }
+    if len(completed) != 2 {
+        t.Fatalf("Expected 2 completed artifacts, got %d", len(completed))
+    }
+
+    file := completed[0].Parts[0].(a2a.FilePart)
+    if data, _ := base64.StdEncoding.DecodeString(file.File.Bytes); string(data) != "abcdef" || len(completed[0].Parts) != 1 {
+        t.Errorf("File bytes mismatch: %q", data)
+    }
+    if completed[1].Index != 0 || len(completed[1].Parts) != 2 || completed[1].Parts[0].(a2a.TextPart).Text != "Hello, world" {
+        t.Errorf("Text artifact mismatch: %+v", completed[1])
+    }
+    if pending := assembler.Pending("task-1"); len(pending) != 0 {
+        t.Errorf("Expected no pending artifacts, got %d", len(pending))
+    }
+}
+
+func TestArtifactAssemblerRejectsChunks(t *testing.T) {
+    tests := []struct {
+        name   string
+        events []*a2a.TaskArtifactUpdateEvent
+        err    error
+    }{
+        {"append without first chunk", []*a2a.TaskArtifactUpdateEvent{artifactChunk(0, true, false, a2a.NewTextPart("x"))}, a2a.ErrArtifactChunkMissing},
+        {"skipped index", []*a2a.TaskArtifactUpdateEvent{artifactChunk(1, false, true, a2a.NewTextPart("x"))}, a2a.ErrArtifactChunkMissing},
+        {"chunk after last", []*a2a.TaskArtifactUpdateEvent{artifactChunk(0, false, true, a2a.NewTextPart("x")), artifactChunk(0, true, false, a2a.NewTextPart("y"))}, a2a.ErrArtifactChunkOutOfOrder},
+        {"restart", []*a2a.TaskArtifactUpdateEvent{artifactChunk(0, false, false, a2a.NewTextPart("x")), artifactChunk(0, false, false, a2a.NewTextPart("y"))}, a2a.ErrArtifactChunkOutOfOrder},
+        {"too large", []*a2a.TaskArtifactUpdateEvent{artifactChunk(0, false, false, a2a.NewTextPart("12345")), artifactChunk(0, true, false, a2a.NewTextPart("67890"))}, a2a.ErrArtifactTooLarge},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assembler := a2a.NewArtifactAssembler().WithMaxBytes(8)
+            var err error
+            for _, event := range tt.events {
+                if _, _, err = assembler.Add(event); err != nil {
+                    break
+                }
+            }
+            if !errors.Is(err, tt.err) {
+                t.Errorf("Expected %v, got %v", tt.err, err)
+            }
+        })
+    }
+}
+
+func TestArtifactAssemblerBoundsTasks(t *testing.T) {
+    assembler := a2a.NewArtifactAssembler().WithMaxTasks(2)
+    for _, id := range []string{"task-1", "task-2", "task-3"} {
+        chunk := artifactChunk(0, false, false, a2a.NewTextPart("a"))
+        chunk.ID = id
+        if _, _, err := assembler.Add(chunk); err != nil {
+            t.Fatalf("Add failed for %s: %v", id, err)
+        }
+    }
+
+    // The least recently updated task was dropped, so its artifact may be sent again
+    again := artifactChunk(0, false, false, a2a.NewTextPart("a"))
+    if _, _, err := assembler.Add(again); err != nil {
+        t.Errorf("Expected the dropped task to start over, got %v", err)
+    }
+    again.ID = "task-3"
+    if _, _, err := assembler.Add(again); !errors.Is(err, a2a.ErrArtifactChunkOutOfOrder) {
+        t.Errorf("Expected a tracked task to reject a repeated artifact, got %v", err)
+    }
+}
@@ -0,0 +1,192 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the io.Writer streaming incremental output as artifact chunks
+package a2a
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "errors"
+    "sync"
+    "unicode/utf8"
+)
+
+// DefaultArtifactChunkSize is the number of bytes sent per chunk unless another size is configured
+const DefaultArtifactChunkSize = 16 << 10
+
+// ErrArtifactWriterClosed is returned when writing to an artifact writer that has been closed
+var ErrArtifactWriterClosed = errors.New("a2a: artifact writer closed")
+
+// ArtifactWriter streams output as chunks of one artifact of a task.
+// Written bytes are buffered and sent as TaskArtifactUpdateEvents of the configured chunk size;
+// Close sends the remainder as the last chunk. Output is sent as text unless WithFile is used.
+// When the stream's handler has a task store, the chunks are assembled on the stored task as they are sent.
+type ArtifactWriter struct {
+    stream    *StreamWriter
+    taskID    string
+    index     int
+    chunkSize int
+    name      *string
+    file      *FileContent
+    metadata  map[string]interface{}
+
+    mu       sync.Mutex
+    buffer   bytes.Buffer
+    content  bytes.Buffer
+    sent     int
+    closed   bool
+    artifact *Artifact
+}
+
+// NewArtifactWriter creates a writer of the next artifact of the task on the stream.
+// Artifacts already on the stored task, such as those of an earlier turn, keep their indexes.
+func (s *StreamWriter) NewArtifactWriter(ctx context.Context, taskID string) *ArtifactWriter {
+    stored := 0
+    if s.store != nil {
+        if task, _, err := s.store.Get(ctx, taskID); err == nil {
+            stored = nextArtifactIndex(task)
+        }
+    }
+    s.mu.Lock()
+    index := s.artifacts
+    if stored > index {
+        index = stored
+    }
+    s.artifacts = index + 1
+    s.mu.Unlock()
+    return &ArtifactWriter{
+        stream:    s,
+        taskID:    taskID,
+        index:     index,
+        chunkSize: DefaultArtifactChunkSize,
+    }
+}
+
+// WithChunkSize sets the number of bytes sent per chunk
+func (w *ArtifactWriter) WithChunkSize(size int) *ArtifactWriter {
+    if size > 0 {
+        w.chunkSize = size
+    }
+    return w
+}
+
+// WithName sets the name of the artifact
+func (w *ArtifactWriter) WithName(name string) *ArtifactWriter {
+    w.name = &name
+    return w
+}
+
+// WithFile sends the output as the bytes of a file part instead of text
+func (w *ArtifactWriter) WithFile(name, mimeType string) *ArtifactWriter {
+    w.file = &FileContent{Name: name, MimeType: mimeType}
+    return w
+}
+
+// WithMetadata sets the metadata of the artifact
+func (w *ArtifactWriter) WithMetadata(metadata map[string]interface{}) *ArtifactWriter {
+    w.metadata = metadata
+    return w
+}
+
+// Index returns the index of the artifact within the task
+func (w *ArtifactWriter) Index() int {
+    return w.index
+}
+
+// Write implements io.Writer, sending every full chunk of buffered output
+func (w *ArtifactWriter) Write(p []byte) (int, error) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if w.closed {
+        return 0, ErrArtifactWriterClosed
+    }
+    w.buffer.Write(p)
+    for w.buffer.Len() >= w.chunkSize {
+        if err := w.flushChunk(false); err != nil {
+            return len(p), err
+        }
+    }
+    return len(p), nil
+}
+
+// Close sends the buffered output as the last chunk
+func (w *ArtifactWriter) Close() error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if w.closed {
+        return nil
+    }
+    w.closed = true
+    for w.buffer.Len() > w.chunkSize {
+        if err := w.flushChunk(false); err != nil {
+            return err
+        }
+    }
+    if err := w.flushChunk(true); err != nil {
+        return err
+    }
+    w.artifact = NewArtifact([]Part{w.part(w.content.Bytes())}).WithIndex(w.index)
+    w.artifact.Name = w.name
+    w.artifact.Metadata = w.metadata
+    return nil
+}
+
+// Artifact returns the assembled artifact once the writer is closed
+func (w *ArtifactWriter) Artifact() *Artifact {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    return w.artifact
+}
+
+// flushChunk sends up to one chunk of buffered output
+func (w *ArtifactWriter) flushChunk(last bool) error {
+    size := w.buffer.Len()
+    if size > w.chunkSize {
+        size = w.chunkSize
+    }
+    data := w.buffer.Bytes()[:size]
+    if w.file == nil && !last {
+        // Keep runes whole so every text chunk is valid UTF-8
+        for size > 0 && size < w.buffer.Len() && !utf8.RuneStart(w.buffer.Bytes()[size]) {
+            size--
+        }
+        if size == 0 {
+            size = len(data)
+        }
+        data = data[:size]
+    }
+
+    artifact := NewArtifact([]Part{w.part(data)}).
+        WithIndex(w.index).
+        WithAppend(w.sent > 0).
+        WithLastChunk(last)
+    if w.sent == 0 {
+        artifact.Name = w.name
+        artifact.Metadata = w.metadata
+    }
+    w.content.Write(data)
+    w.buffer.Next(size)
+    w.sent++
+    return w.stream.WriteArtifactUpdate(TaskArtifactUpdateEvent{ID: w.taskID, Artifact: *artifact})
+}
+
+// part wraps output in a text part, or a file part when WithFile is used
+func (w *ArtifactWriter) part(data []byte) Part {
+    if w.file == nil {
+        return NewTextPart(string(data))
+    }
+    file := *w.file
+    file.Bytes = base64.StdEncoding.EncodeToString(data)
+    return NewFilePart(file)
+}
+
+// nextArtifactIndex returns the index following the artifacts of the task
+func nextArtifactIndex(task *Task) int {
+    next := len(task.Artifacts)
+    for _, artifact := range task.Artifacts {
+        if artifact.Index >= next {
+            next = artifact.Index + 1
+        }
+    }
+    return next
+}
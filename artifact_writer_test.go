@@ -0,0 +1,153 @@
+package a2a_test
+
+import (
+    "context"
+    "encoding/base64"
+    "fmt"
+    "io"
+    "net/http/httptest"
+    "testing"
+    "unicode/utf8"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestArtifactWriter(t *testing.T) {
+    store := a2a.NewMemoryTaskStore()
+    if _, err := store.Create(context.Background(), a2a.NewTask("task-1", a2a.TaskStateWorking)); err != nil {
+        t.Fatalf("Create failed: %v", err)
+    }
+    handler := a2a.NewProtocolHandler(nil).
+        WithTaskStore(store).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            text := stream.NewArtifactWriter(ctx, params.ID).WithChunkSize(4).WithName("greeting")
+            fmt.Fprint(text, "héllo, ")
+            fmt.Fprint(text, "wörld")
+            if err := text.Close(); err != nil {
+                return err
+            }
+            file := stream.NewArtifactWriter(ctx, params.ID).WithChunkSize(8).WithFile("data.bin", "application/octet-stream")
+            file.Write([]byte("0123456789"))
+            if err := file.Close(); err != nil {
+                return err
+            }
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    stream, err := a2a.NewClient().SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+
+    assembler := a2a.NewArtifactAssembler()
+    var artifacts []*a2a.Artifact
+    chunks := 0
+    for {
+        event, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            t.Fatalf("Recv failed: %#v", err)
+        }
+        if event.Artifact == nil {
+            continue
+        }
+        chunks++
+        if text, ok := event.Artifact.Artifact.Parts[0].(a2a.TextPart); ok && !utf8.ValidString(text.Text) {
+            t.Errorf("Chunk splits a rune: %q", text.Text)
+        }
+        artifact, complete, err := assembler.Add(event.Artifact)
+        if err != nil {
+            t.Fatalf("Add failed: %v", err)
+        }
+        if complete {
+            artifacts = append(artifacts, artifact)
+        }
+    }
+
+    if len(artifacts) != 2 || chunks < 5 {
+        t.Fatalf("Expected 2 artifacts in at least 5 chunks, got %d in %d", len(artifacts), chunks)
+    }
+    if text := artifacts[0].Parts[0].(a2a.TextPart).Text; text != "héllo, wörld" || *artifacts[0].Name != "greeting" {
+        t.Errorf("Text artifact mismatch: %q", text)
+    }
+    file := artifacts[1].Parts[0].(a2a.FilePart)
+    if data, _ := base64.StdEncoding.DecodeString(file.File.Bytes); artifacts[1].Index != 1 || string(data) != "0123456789" {
+        t.Errorf("File artifact mismatch: index %d, %q", artifacts[1].Index, data)
+    }
+
+    if streamed := stream.Task(); streamed.Status.State != a2a.TaskStateCompleted || len(streamed.Artifacts) != 2 {
+        t.Errorf("Streamed task mismatch: %+v", streamed)
+    }
+
+    task, _, err := store.Get(context.Background(), "task-1")
+    if err != nil {
+        t.Fatalf("Get failed: %v", err)
+    }
+    if len(task.Artifacts) != 2 || task.Artifacts[0].Parts[0].(a2a.TextPart).Text != "héllo, wörld" {
+        t.Errorf("Stored artifacts mismatch: %+v", task.Artifacts)
+    }
+}
+
+func TestArtifactWriterNextTurn(t *testing.T) {
+    store := a2a.NewMemoryTaskStore()
+    if _, err := store.Create(context.Background(), a2a.NewTask("task-1", a2a.TaskStateWorking)); err != nil {
+        t.Fatalf("Create failed: %v", err)
+    }
+    handler := a2a.NewProtocolHandler(nil).
+        WithTaskStore(store).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            text := params.Message.Parts[0].(a2a.TextPart).Text
+            writer := stream.NewArtifactWriter(ctx, params.ID)
+            fmt.Fprint(writer, "reply to "+text)
+            if err := writer.Close(); err != nil {
+                return err
+            }
+            state := a2a.TaskStateInputRequired
+            if text == "second" {
+                state = a2a.TaskStateCompleted
+            }
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: state}, Final: true})
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    client := a2a.NewClient()
+    for _, text := range []string{"first", "second"} {
+        stream, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+            ID:      "task-1",
+            Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart(text)}),
+        }, server.URL)
+        if err != nil {
+            t.Fatalf("SendTaskSubscribe failed: %v", err)
+        }
+        for {
+            if _, err := stream.Recv(); err == io.EOF {
+                break
+            } else if err != nil {
+                t.Fatalf("Recv failed: %v", err)
+            }
+        }
+        stream.Close()
+    }
+
+    task, _, err := store.Get(context.Background(), "task-1")
+    if err != nil {
+        t.Fatalf("Get failed: %v", err)
+    }
+    if len(task.Artifacts) != 2 {
+        t.Fatalf("Expected the artifacts of both turns, got %+v", task.Artifacts)
+    }
+    for i, text := range []string{"reply to first", "reply to second"} {
+        if artifact := task.Artifacts[i]; artifact.Index != i || artifact.Parts[0].(a2a.TextPart).Text != text {
+            t.Errorf("Artifact %d mismatch: %+v", i, artifact)
+        }
+    }
+}
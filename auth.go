@@ -0,0 +1,439 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the server middleware enforcing the authentication schemes of the agent card
+package a2a
+
+import (
+    "context"
+    "crypto/subtle"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// Authentication schemes advertised in AgentAuthentication.Schemes
+const (
+    AuthSchemeAPIKey = "ApiKey"
+    AuthSchemeBearer = "Bearer"
+    AuthSchemeBasic  = "Basic"
+    AuthSchemeMTLS   = "mTLS"
+)
+
+// DefaultAPIKeyHeader is the header carrying API keys unless another one is configured
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// Errors returned by authenticators
+var (
+    // ErrNoCredentials means the request carries no credentials for the scheme, so the next scheme is tried
+    ErrNoCredentials = errors.New("a2a: no credentials")
+    // ErrInvalidCredentials means the request carries credentials for the scheme that do not verify
+    ErrInvalidCredentials = errors.New("a2a: invalid credentials")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+    // Scheme is the authentication scheme that authenticated the caller
+    Scheme string
+    // Subject identifies the caller, such as a user name, key owner, token subject or certificate common name
+    Subject string
+    // Claims holds the claims of a JWT bearer token
+    Claims map[string]interface{}
+    // Certificate is the verified client certificate of mutual TLS
+    Certificate *x509.Certificate
+    // Peer holds the credentials of a caller connected over a Unix domain socket
+    Peer *PeerCredentials
+}
+
+// principalContextKey is the context key of the authenticated principal
+type principalContextKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+    return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal authenticated for the request, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+    principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+    return principal, ok && principal != nil
+}
+
+// Authenticator verifies the credentials of one authentication scheme.
+// Authenticate returns ErrNoCredentials when the request carries none for the scheme.
+type Authenticator interface {
+    Scheme() string
+    Authenticate(r *http.Request) (*Principal, error)
+}
+
+// APIKeyValidator resolves an API key to its principal
+type APIKeyValidator func(ctx context.Context, key string) (*Principal, error)
+
+// StaticAPIKeys returns a validator accepting a fixed set of keys, mapped to the subject they identify
+func StaticAPIKeys(keys map[string]string) APIKeyValidator {
+    return func(ctx context.Context, key string) (*Principal, error) {
+        for candidate, subject := range keys {
+            if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+                return &Principal{Subject: subject}, nil
+            }
+        }
+        return nil, ErrInvalidCredentials
+    }
+}
+
+// APIKeyAuthenticator authenticates requests by an API key header
+type APIKeyAuthenticator struct {
+    header   string
+    validate APIKeyValidator
+}
+
+// NewAPIKeyAuthenticator creates an authenticator reading keys from DefaultAPIKeyHeader
+func NewAPIKeyAuthenticator(validate APIKeyValidator) *APIKeyAuthenticator {
+    return &APIKeyAuthenticator{
+        header:   DefaultAPIKeyHeader,
+        validate: validate,
+    }
+}
+
+// WithHeader sets the header carrying the API key
+func (a *APIKeyAuthenticator) WithHeader(header string) *APIKeyAuthenticator {
+    a.header = header
+    return a
+}
+
+// Scheme implements Authenticator
+func (a *APIKeyAuthenticator) Scheme() string {
+    return AuthSchemeAPIKey
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+    key := r.Header.Get(a.header)
+    if key == "" {
+        return nil, ErrNoCredentials
+    }
+    principal, err := a.validate(r.Context(), key)
+    return stampScheme(AuthSchemeAPIKey, principal, err)
+}
+
+// TokenValidator resolves a bearer token to its principal
+type TokenValidator func(ctx context.Context, token string) (*Principal, error)
+
+// BearerAuthenticator authenticates requests by an Authorization: Bearer token
+type BearerAuthenticator struct {
+    validate TokenValidator
+}
+
+// NewBearerAuthenticator creates an authenticator checking bearer tokens with the validator
+func NewBearerAuthenticator(validate TokenValidator) *BearerAuthenticator {
+    return &BearerAuthenticator{validate: validate}
+}
+
+// Scheme implements Authenticator
+func (a *BearerAuthenticator) Scheme() string {
+    return AuthSchemeBearer
+}
+
+// Authenticate implements Authenticator
+func (a *BearerAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+    token, ok := bearerToken(r)
+    if !ok {
+        return nil, ErrNoCredentials
+    }
+    principal, err := a.validate(r.Context(), token)
+    return stampScheme(AuthSchemeBearer, principal, err)
+}
+
+// bearerToken returns the token of an Authorization: Bearer header
+func bearerToken(r *http.Request) (string, bool) {
+    scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+    if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+        return "", false
+    }
+    return strings.TrimSpace(token), true
+}
+
+// JWTAuthenticator authenticates requests by a signed JWT bearer token.
+// Tokens are verified with an HMAC secret (HS256), an RSA public key (RS256) or a P-256 ECDSA public key (ES256).
+// Tokens must carry an exp claim unless WithOptionalExpiry is set.
+type JWTAuthenticator struct {
+    key            interface{}
+    issuer         string
+    audience       string
+    leeway         time.Duration
+    optionalExpiry bool
+}
+
+// NewJWTAuthenticator creates an authenticator verifying tokens with the key.
+// The key is a []byte secret, an *rsa.PublicKey or an *ecdsa.PublicKey.
+func NewJWTAuthenticator(key interface{}) *JWTAuthenticator {
+    return &JWTAuthenticator{
+        key:    key,
+        leeway: time.Minute,
+    }
+}
+
+// WithIssuer requires the iss claim to match
+func (a *JWTAuthenticator) WithIssuer(issuer string) *JWTAuthenticator {
+    a.issuer = issuer
+    return a
+}
+
+// WithAudience requires the aud claim to contain the audience
+func (a *JWTAuthenticator) WithAudience(audience string) *JWTAuthenticator {
+    a.audience = audience
+    return a
+}
+
+// WithOptionalExpiry accepts tokens without an exp claim, which never expire
+func (a *JWTAuthenticator) WithOptionalExpiry() *JWTAuthenticator {
+    a.optionalExpiry = true
+    return a
+}
+
+// Scheme implements Authenticator
+func (a *JWTAuthenticator) Scheme() string {
+    return AuthSchemeJWT
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+    token, ok := bearerToken(r)
+    if !ok {
+        return nil, ErrNoCredentials
+    }
+    principal, err := a.Validate(r.Context(), token)
+    return stampScheme(AuthSchemeJWT, principal, err)
+}
+
+// Validate verifies a token and its registered claims.
+// It is a TokenValidator, so JWTs can also back the Bearer scheme.
+func (a *JWTAuthenticator) Validate(ctx context.Context, token string) (*Principal, error) {
+    claims, err := verifyJWT(token, a.key)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+    }
+    if _, ok := claims["exp"].(float64); !ok && !a.optionalExpiry {
+        return nil, fmt.Errorf("%w: token has no expiry", ErrInvalidCredentials)
+    }
+    if nbf, ok := claims["nbf"].(float64); ok && time.Now().Add(a.leeway).Before(time.Unix(int64(nbf), 0)) {
+        return nil, fmt.Errorf("%w: token not yet valid", ErrInvalidCredentials)
+    }
+    if a.issuer != "" && claims["iss"] != a.issuer {
+        return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidCredentials)
+    }
+    if a.audience != "" && !audienceContains(claims["aud"], a.audience) {
+        return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+    }
+    subject, _ := claims["sub"].(string)
+    return &Principal{Scheme: AuthSchemeJWT, Subject: subject, Claims: claims}, nil
+}
+
+// audienceContains reports whether an aud claim, a string or a list of strings, contains the audience
+func audienceContains(claim interface{}, audience string) bool {
+    switch aud := claim.(type) {
+    case string:
+        return aud == audience
+    case []interface{}:
+        for _, candidate := range aud {
+            if candidate == audience {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// BasicValidator checks a user name and password
+type BasicValidator func(ctx context.Context, username, password string) (*Principal, error)
+
+// BasicAuthenticator authenticates requests by HTTP Basic credentials
+type BasicAuthenticator struct {
+    validate BasicValidator
+}
+
+// NewBasicAuthenticator creates an authenticator checking Basic credentials with the validator
+func NewBasicAuthenticator(validate BasicValidator) *BasicAuthenticator {
+    return &BasicAuthenticator{validate: validate}
+}
+
+// Scheme implements Authenticator
+func (a *BasicAuthenticator) Scheme() string {
+    return AuthSchemeBasic
+}
+
+// Authenticate implements Authenticator
+func (a *BasicAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+    username, password, ok := r.BasicAuth()
+    if !ok {
+        return nil, ErrNoCredentials
+    }
+    principal, err := a.validate(r.Context(), username, password)
+    if err == nil && principal != nil && principal.Subject == "" {
+        principal.Subject = username
+    }
+    return stampScheme(AuthSchemeBasic, principal, err)
+}
+
+// ClientCertAuthenticator authenticates requests by the client certificate of a mutual TLS connection.
+// The server's tls.Config must request client certificates and verify them against trusted CAs.
+type ClientCertAuthenticator struct {
+    validate func(cert *x509.Certificate) (*Principal, error)
+}
+
+// NewClientCertAuthenticator creates an authenticator accepting any verified client certificate
+func NewClientCertAuthenticator() *ClientCertAuthenticator {
+    return &ClientCertAuthenticator{}
+}
+
+// WithValidator sets a function that further checks the verified certificate
+func (a *ClientCertAuthenticator) WithValidator(validate func(cert *x509.Certificate) (*Principal, error)) *ClientCertAuthenticator {
+    a.validate = validate
+    return a
+}
+
+// Scheme implements Authenticator
+func (a *ClientCertAuthenticator) Scheme() string {
+    return AuthSchemeMTLS
+}
+
+// Authenticate implements Authenticator
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+    if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+        return nil, ErrNoCredentials
+    }
+    if len(r.TLS.VerifiedChains) == 0 {
+        return nil, fmt.Errorf("%w: client certificate not verified", ErrInvalidCredentials)
+    }
+    cert := r.TLS.VerifiedChains[0][0]
+    if a.validate == nil {
+        return &Principal{Scheme: AuthSchemeMTLS, Subject: cert.Subject.CommonName, Certificate: cert}, nil
+    }
+    principal, err := a.validate(cert)
+    if err == nil && principal != nil {
+        if principal.Subject == "" {
+            principal.Subject = cert.Subject.CommonName
+        }
+        principal.Certificate = cert
+    }
+    return stampScheme(AuthSchemeMTLS, principal, err)
+}
+
+// stampScheme records the scheme on the principal returned by a validator
+func stampScheme(scheme string, principal *Principal, err error) (*Principal, error) {
+    if err != nil {
+        return nil, err
+    }
+    if principal == nil {
+        return nil, ErrInvalidCredentials
+    }
+    principal.Scheme = scheme
+    return principal, nil
+}
+
+// AuthMiddleware enforces the authentication schemes advertised in an agent card.
+// A request is accepted when any advertised scheme authenticates it; the agent card itself stays public.
+type AuthMiddleware struct {
+    schemes        []string
+    authenticators map[string]Authenticator
+    // allowPeer accepts callers by the peer credentials of their Unix socket; nil requires the card's schemes
+    allowPeer func(*PeerCredentials) bool
+}
+
+// NewAuthMiddleware creates the middleware for the schemes of the card.
+// Every advertised scheme needs a matching authenticator, so the card never promises a scheme the server cannot check.
+func NewAuthMiddleware(card *AgentCard, authenticators ...Authenticator) (*AuthMiddleware, error) {
+    m := &AuthMiddleware{authenticators: make(map[string]Authenticator)}
+    for _, authenticator := range authenticators {
+        m.authenticators[strings.ToLower(authenticator.Scheme())] = authenticator
+    }
+    if card == nil || card.Authentication == nil {
+        return m, nil
+    }
+    for _, scheme := range card.Authentication.Schemes {
+        if _, ok := m.authenticators[strings.ToLower(scheme)]; !ok {
+            return nil, fmt.Errorf("a2a: no authenticator for scheme %q advertised by the agent card", scheme)
+        }
+        m.schemes = append(m.schemes, scheme)
+    }
+    return m, nil
+}
+
+// WithPeerCredentials accepts callers on a Unix domain socket whose peer credentials allow returns true,
+// without the schemes of the card; all other callers still need an advertised scheme. A local proxy
+// relaying remote traffic onto the socket connects with its own credentials, so allow should reject its user.
+func (m *AuthMiddleware) WithPeerCredentials(allow func(*PeerCredentials) bool) *AuthMiddleware {
+    m.allowPeer = allow
+    return m
+}
+
+// WithPeerUIDs accepts callers on a Unix domain socket running as one of the users, as WithPeerCredentials does
+func (m *AuthMiddleware) WithPeerUIDs(uids ...int) *AuthMiddleware {
+    return m.WithPeerCredentials(func(peer *PeerCredentials) bool {
+        for _, uid := range uids {
+            if peer.UID == uid {
+                return true
+            }
+        }
+        return false
+    })
+}
+
+// Wrap returns a handler authenticating requests before passing them to next
+func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if len(m.schemes) == 0 || (r.URL.Path == AgentCardPath && r.Method != http.MethodPost) {
+            next.ServeHTTP(w, r)
+            return
+        }
+        if principal, ok := PrincipalFromContext(r.Context()); ok && principal.Peer != nil && m.allowPeer != nil && m.allowPeer(principal.Peer) {
+            // The kernel identified the caller when it connected to the Unix domain socket
+            next.ServeHTTP(w, r)
+            return
+        }
+        principal, err := m.authenticate(r)
+        if err != nil {
+            m.reject(w, err)
+            return
+        }
+        next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), principal)))
+    })
+}
+
+// authenticate tries the advertised schemes in order.
+// Credentials that are present but invalid fail the request rather than falling through to the next scheme.
+func (m *AuthMiddleware) authenticate(r *http.Request) (*Principal, error) {
+    for _, scheme := range m.schemes {
+        principal, err := m.authenticators[strings.ToLower(scheme)].Authenticate(r)
+        if errors.Is(err, ErrNoCredentials) {
+            continue
+        }
+        return principal, err
+    }
+    return nil, ErrNoCredentials
+}
+
+// reject answers a request that failed authentication with a JSON-RPC error and the matching HTTP status
+func (m *AuthMiddleware) reject(w http.ResponseWriter, err error) {
+    var rpcErr *JSONRPCError
+    if errors.As(err, &rpcErr) && rpcErr.Code == ErrCodePermissionDenied {
+        writeJSON(w, http.StatusForbidden, NewJSONRPCErrorResponse(nil, rpcErr))
+        return
+    }
+    challenges := map[string]bool{}
+    for _, scheme := range m.schemes {
+        challenge := ""
+        switch {
+        case strings.EqualFold(scheme, AuthSchemeBasic):
+            challenge = `Basic realm="a2a"`
+        case strings.EqualFold(scheme, AuthSchemeBearer), strings.EqualFold(scheme, AuthSchemeJWT):
+            challenge = "Bearer"
+        }
+        if challenge != "" && !challenges[challenge] {
+            challenges[challenge] = true
+            w.Header().Add("WWW-Authenticate", challenge)
+        }
+    }
+    writeJSON(w, http.StatusUnauthorized, NewJSONRPCErrorResponse(nil, UnauthenticatedError().WithData(err.Error())))
+}
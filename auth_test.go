@@ -0,0 +1,138 @@
+package a2a_test
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+// signHS256 builds an HS256 JWT with the given claims
+func signHS256(secret []byte, claims string) string {
+    encode := base64.RawURLEncoding.EncodeToString
+    input := encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims))
+    mac := hmac.New(sha256.New, secret)
+    mac.Write([]byte(input))
+    return input + "." + encode(mac.Sum(nil))
+}
+
+func newAuthServer(t *testing.T, authenticators ...a2a.Authenticator) *httptest.Server {
+    t.Helper()
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    card.Authentication = &a2a.AgentAuthentication{Schemes: []string{a2a.AuthSchemeAPIKey, a2a.AuthSchemeJWT, a2a.AuthSchemeBasic}}
+    middleware, err := a2a.NewAuthMiddleware(card, authenticators...)
+    if err != nil {
+        t.Fatalf("NewAuthMiddleware failed: %v", err)
+    }
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            principal, ok := a2a.PrincipalFromContext(ctx)
+            if !ok {
+                return nil, errors.New("no principal")
+            }
+            task := a2a.NewTask(params.ID, a2a.TaskStateCompleted)
+            task.Metadata = map[string]interface{}{"scheme": principal.Scheme, "subject": principal.Subject}
+            return task, nil
+        })
+    return httptest.NewServer(middleware.Wrap(handler))
+}
+
+func TestAuthMiddleware(t *testing.T) {
+    secret := []byte("jwt-secret")
+    server := newAuthServer(t,
+        a2a.NewAPIKeyAuthenticator(a2a.StaticAPIKeys(map[string]string{"key-1": "alice"})),
+        a2a.NewJWTAuthenticator(secret).WithIssuer("issuer"),
+        a2a.NewBasicAuthenticator(func(ctx context.Context, username, password string) (*a2a.Principal, error) {
+            if password != "hunter2" {
+                return nil, a2a.PermissionDeniedError()
+            }
+            return &a2a.Principal{}, nil
+        }),
+    )
+    defer server.Close()
+
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")})}
+    exp := time.Now().Add(time.Hour).Unix()
+    tests := []struct {
+        name    string
+        header  string
+        value   string
+        scheme  string
+        subject string
+        code    int
+    }{
+        {name: "no credentials", code: a2a.ErrCodeUnauthenticated},
+        {name: "api key", header: a2a.DefaultAPIKeyHeader, value: "key-1", scheme: a2a.AuthSchemeAPIKey, subject: "alice"},
+        {name: "bad api key", header: a2a.DefaultAPIKeyHeader, value: "key-2", code: a2a.ErrCodeUnauthenticated},
+        {name: "jwt", header: "Authorization", value: "Bearer " + signHS256(secret, `{"sub":"bob","iss":"issuer","exp":`+strconv.FormatInt(exp, 10)+`}`), scheme: a2a.AuthSchemeJWT, subject: "bob"},
+        {name: "jwt without expiry", header: "Authorization", value: "Bearer " + signHS256(secret, `{"sub":"bob","iss":"issuer"}`), code: a2a.ErrCodeUnauthenticated},
+        {name: "jwt wrong issuer", header: "Authorization", value: "Bearer " + signHS256(secret, `{"sub":"bob","iss":"other"}`), code: a2a.ErrCodeUnauthenticated},
+        {name: "jwt wrong key", header: "Authorization", value: "Bearer " + signHS256([]byte("other"), `{"sub":"bob","iss":"issuer"}`), code: a2a.ErrCodeUnauthenticated},
+        {name: "basic", header: "Authorization", value: "Basic " + base64.StdEncoding.EncodeToString([]byte("carol:hunter2")), scheme: a2a.AuthSchemeBasic, subject: "carol"},
+        {name: "basic denied", header: "Authorization", value: "Basic " + base64.StdEncoding.EncodeToString([]byte("carol:wrong")), code: a2a.ErrCodePermissionDenied},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            client := a2a.NewClient()
+            if tt.header != "" {
+                client.WithHeader(tt.header, tt.value)
+            }
+            task, err := client.SendTask(context.Background(), params, server.URL)
+            if tt.code != 0 {
+                var httpErr *a2a.HTTPError
+                if !errors.As(err, &httpErr) {
+                    t.Fatalf("Expected an HTTP error, got %v", err)
+                }
+                response, parseErr := a2a.ResponseFromJSON(httpErr.Body)
+                if parseErr != nil || response.Error == nil || response.Error.Code != tt.code {
+                    t.Errorf("Error mismatch: expected code %d, got %s", tt.code, httpErr.Body)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("SendTask failed: %v", err)
+            }
+            if task.Metadata["scheme"] != tt.scheme || task.Metadata["subject"] != tt.subject {
+                t.Errorf("Principal mismatch: %v", task.Metadata)
+            }
+        })
+    }
+
+    // The agent card stays reachable without credentials
+    resp, err := http.Get(server.URL + a2a.AgentCardPath)
+    if err != nil {
+        t.Fatalf("Card request failed: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        t.Errorf("Card status mismatch: expected %d, got %d", http.StatusOK, resp.StatusCode)
+    }
+}
+
+func TestJWTAuthenticatorRequiresExpiry(t *testing.T) {
+    secret := []byte("secret")
+    token := signHS256(secret, `{"sub":"bob"}`)
+    if _, err := a2a.NewJWTAuthenticator(secret).Validate(context.Background(), token); !errors.Is(err, a2a.ErrInvalidCredentials) {
+        t.Errorf("Expected ErrInvalidCredentials for a token without exp, got %v", err)
+    }
+    principal, err := a2a.NewJWTAuthenticator(secret).WithOptionalExpiry().Validate(context.Background(), token)
+    if err != nil || principal.Subject != "bob" {
+        t.Errorf("Opt-in rejected token without exp: %v %v", principal, err)
+    }
+}
+
+func TestAuthMiddlewareRequiresAdvertisedSchemes(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, nil)
+    card.Authentication = &a2a.AgentAuthentication{Schemes: []string{a2a.AuthSchemeBearer}}
+    if _, err := a2a.NewAuthMiddleware(card, a2a.NewBasicAuthenticator(nil)); err == nil {
+        t.Errorf("Expected an error for a scheme without authenticator")
+    }
+}
@@ -0,0 +1,241 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements JSON-RPC batch requests and notifications on the server and the client
+package a2a
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sync"
+)
+
+// Default limits of batch requests
+const (
+    DefaultMaxBatchSize     = 100
+    DefaultBatchConcurrency = 8
+)
+
+// WithMaxBatchSize sets the number of requests accepted in one batch
+func (h *ProtocolHandler) WithMaxBatchSize(size int) *ProtocolHandler {
+    h.maxBatchSize = size
+    return h
+}
+
+// WithBatchConcurrency sets how many requests of a batch are processed at the same time
+func (h *ProtocolHandler) WithBatchConcurrency(concurrency int) *ProtocolHandler {
+    h.batchConcurrency = concurrency
+    return h
+}
+
+// isNotification reports whether the request is a notification, which is processed without a response
+func (r *rpcRequest) isNotification() bool {
+    return len(r.ID) == 0 && r.JSONRPC == JSONRPCVersion && r.Method != ""
+}
+
+// isBatch reports whether a request body holds a batch
+func isBatch(body []byte) bool {
+    body = bytes.TrimLeft(body, " \t\r\n")
+    return len(body) > 0 && body[0] == '['
+}
+
+// serveBatch answers a batch request over HTTP
+func (h *ProtocolHandler) serveBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+    requested := ProtocolVersion(r.Header.Get(ProtocolVersionHeader))
+    response := h.processBatch(r.Context(), body, requested)
+    w.Header().Set(ProtocolVersionHeader, string(h.requestVersion("", requested)))
+    if response == nil {
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+    writeJSON(w, http.StatusOK, response)
+}
+
+// processBatch processes the requests of a batch concurrently.
+// It returns the responses in the order of the requests, a single error response when the
+// batch is rejected as a whole, or nil when the batch holds only notifications.
+func (h *ProtocolHandler) processBatch(ctx context.Context, body []byte, requested ProtocolVersion) interface{} {
+    var elements []json.RawMessage
+    if err := json.Unmarshal(body, &elements); err != nil {
+        return NewJSONRPCErrorResponse(nil, JSONParseError())
+    }
+    if len(elements) == 0 {
+        return NewJSONRPCErrorResponse(nil, InvalidRequestError().WithData("empty batch"))
+    }
+    maxSize := h.maxBatchSize
+    if maxSize <= 0 {
+        maxSize = DefaultMaxBatchSize
+    }
+    if len(elements) > maxSize {
+        return NewJSONRPCErrorResponse(nil, InvalidRequestError().WithData(fmt.Sprintf("batch exceeds %d requests", maxSize)))
+    }
+    concurrency := h.batchConcurrency
+    if concurrency <= 0 {
+        concurrency = DefaultBatchConcurrency
+    }
+
+    responses := make([]*JSONRPCResponse, len(elements))
+    slots := make(chan struct{}, concurrency)
+    var wg sync.WaitGroup
+    for i, element := range elements {
+        var request rpcRequest
+        if err := json.Unmarshal(element, &request); err != nil {
+            responses[i] = NewJSONRPCErrorResponse(nil, InvalidRequestError())
+            continue
+        }
+        request.version = h.requestVersion(request.Method, requested)
+        if isStreamingMethod(request.Method) {
+            responses[i] = NewJSONRPCErrorResponse(request.responseID(), InvalidRequestError().WithData("streaming methods cannot be batched"))
+            continue
+        }
+
+        wg.Add(1)
+        slots <- struct{}{}
+        go func(i int, request *rpcRequest) {
+            defer wg.Done()
+            defer func() { <-slots }()
+            response := h.handleRequest(ctx, request)
+            if !request.isNotification() {
+                responses[i] = response
+            }
+        }(i, &request)
+    }
+    wg.Wait()
+
+    results := make([]*JSONRPCResponse, 0, len(responses))
+    for _, response := range responses {
+        if response != nil {
+            results = append(results, response)
+        }
+    }
+    if len(results) == 0 {
+        return nil
+    }
+    return results
+}
+
+// Batch collects requests to one agent that are sent together in a single round trip
+type Batch struct {
+    client *Client
+    url    string
+    calls  []*BatchCall
+}
+
+// BatchCall is the pending result of one request in a batch
+type BatchCall struct {
+    request *JSONRPCRequest
+    done    bool
+    task    *Task
+    err     error
+}
+
+// Batch starts a batch of requests to the agent at url
+func (c *Client) Batch(url string) *Batch {
+    return &Batch{client: c, url: url}
+}
+
+// GetTask adds a tasks/get request to the batch
+func (b *Batch) GetTask(params *TaskQueryParams) *BatchCall {
+    return b.add(MethodGetTask, params)
+}
+
+// CancelTask adds a tasks/cancel request to the batch
+func (b *Batch) CancelTask(params *TaskIdParams) *BatchCall {
+    return b.add(MethodCancelTask, params)
+}
+
+// Len returns the number of requests in the batch
+func (b *Batch) Len() int {
+    return len(b.calls)
+}
+
+// add appends a request to the batch
+func (b *Batch) add(method string, params interface{}) *BatchCall {
+    call := &BatchCall{request: NewJSONRPCRequest(b.client.nextID(), method, params)}
+    b.calls = append(b.calls, call)
+    return call
+}
+
+// Send sends the batch and distributes the responses to the calls by request ID.
+// The returned error concerns the batch as a whole; errors of single requests are reported by their calls.
+func (b *Batch) Send(ctx context.Context) error {
+    if len(b.calls) == 0 {
+        return nil
+    }
+    requests := make([]*JSONRPCRequest, len(b.calls))
+    for i, call := range b.calls {
+        requests[i] = call.request
+    }
+    body, err := json.Marshal(requests)
+    if err != nil {
+        return err
+    }
+    data, err := b.client.post(ctx, b.url, rawJSON(body))
+    if err != nil {
+        return err
+    }
+
+    var responses []struct {
+        ID     json.RawMessage `json:"id"`
+        Result json.RawMessage `json:"result"`
+        Error  *JSONRPCError   `json:"error"`
+    }
+    if err := json.Unmarshal(data, &responses); err != nil {
+        // A batch rejected as a whole is answered with a single response
+        response, parseErr := b.client.protocol.ParseResponse(data)
+        if parseErr == nil && response.Error != nil {
+            return response.Error
+        }
+        return err
+    }
+
+    calls := make(map[string]*BatchCall, len(b.calls))
+    for _, call := range b.calls {
+        calls[idValueKey(call.request.ID)] = call
+    }
+    legacy := b.client.versionFor(b.url).IsLegacy()
+    for _, response := range responses {
+        call := calls[idKey(response.ID)]
+        if call == nil || call.done {
+            continue
+        }
+        call.done = true
+        switch {
+        case response.Error != nil:
+            call.err = response.Error
+        case len(response.Result) == 0 || string(response.Result) == "null":
+            call.err = errNoResult
+        case legacy:
+            call.task, call.err = TaskFromJSON(response.Result)
+        default:
+            var task TaskV2
+            if call.err = json.Unmarshal(response.Result, &task); call.err == nil {
+                call.task = task.ToLegacy()
+            }
+        }
+    }
+    for _, call := range b.calls {
+        if !call.done {
+            call.done = true
+            call.err = fmt.Errorf("a2a: no response for request id %v", call.request.ID)
+        }
+    }
+    return nil
+}
+
+// Task returns the task answered for the request once the batch has been sent
+func (c *BatchCall) Task() (*Task, error) {
+    if !c.done {
+        return nil, fmt.Errorf("a2a: batch not sent")
+    }
+    return c.task, c.err
+}
+
+// rawJSON is an already encoded request body
+type rawJSON []byte
+
+// ToJSON returns the encoded body
+func (r rawJSON) ToJSON() ([]byte, error) {
+    return r, nil
+}
@@ -0,0 +1,149 @@
+package a2a_test
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func newBatchHandler(inFlight, peak *int32) *a2a.ProtocolHandler {
+    return a2a.NewProtocolHandler(nil).
+        WithBatchConcurrency(2).
+        HandleTaskGet(func(ctx context.Context, params *a2a.TaskQueryParams) (*a2a.Task, error) {
+            current := atomic.AddInt32(inFlight, 1)
+            defer atomic.AddInt32(inFlight, -1)
+            for {
+                seen := atomic.LoadInt32(peak)
+                if current <= seen || atomic.CompareAndSwapInt32(peak, seen, current) {
+                    break
+                }
+            }
+            time.Sleep(10 * time.Millisecond)
+            if params.ID == "missing" {
+                return nil, a2a.TaskNotFoundError()
+            }
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        })
+}
+
+func TestProtocolHandlerBatch(t *testing.T) {
+    var inFlight, peak int32
+    handler := newBatchHandler(&inFlight, &peak)
+
+    body := `[
+        {"jsonrpc":"2.0","id":1,"method":"tasks/get","params":{"id":"a"}},
+        {"jsonrpc":"2.0","method":"tasks/get","params":{"id":"notified"}},
+        {"jsonrpc":"2.0","id":2,"method":"tasks/get","params":{"id":"missing"}},
+        42,
+        {"jsonrpc":"2.0","id":3,"method":"tasks/sendSubscribe","params":{}},
+        {"jsonrpc":"2.0","id":4,"method":"tasks/get","params":{"id":"b"}}
+    ]`
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+    var responses []a2a.JSONRPCResponse
+    if err := json.Unmarshal(rec.Body.Bytes(), &responses); err != nil {
+        t.Fatalf("Failed to parse batch response: %v: %s", err, rec.Body)
+    }
+
+    expected := []struct {
+        id   interface{}
+        code int
+    }{{float64(1), 0}, {float64(2), a2a.ErrCodeTaskNotFound}, {nil, a2a.ErrCodeInvalidRequest}, {float64(3), a2a.ErrCodeInvalidRequest}, {float64(4), 0}}
+    if len(responses) != len(expected) {
+        t.Fatalf("Response count mismatch: expected %d, got %d: %s", len(expected), len(responses), rec.Body)
+    }
+    for i, want := range expected {
+        code := 0
+        if responses[i].Error != nil {
+            code = responses[i].Error.Code
+        }
+        if responses[i].ID != want.id || code != want.code {
+            t.Errorf("Response %d mismatch: expected id %v code %d, got %+v", i, want.id, want.code, responses[i])
+        }
+    }
+    if got := atomic.LoadInt32(&peak); got > 2 {
+        t.Errorf("Concurrency limit exceeded: %d requests in flight", got)
+    }
+
+    // Notifications alone are answered without a body
+    for _, body := range []string{
+        `{"jsonrpc":"2.0","method":"tasks/get","params":{"id":"a"}}`,
+        `[{"jsonrpc":"2.0","method":"tasks/get","params":{"id":"a"}}]`,
+    } {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+        if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+            t.Errorf("Notification answered with %d: %s", rec.Code, rec.Body)
+        }
+    }
+
+    response := postJSONRPC(t, handler, `[]`)
+    if response.Error == nil || response.Error.Code != a2a.ErrCodeInvalidRequest {
+        t.Errorf("Expected invalid request for an empty batch, got %+v", response)
+    }
+}
+
+func TestClientBatch(t *testing.T) {
+    var inFlight, peak int32
+    server := httptest.NewServer(newBatchHandler(&inFlight, &peak))
+    defer server.Close()
+
+    batch := a2a.NewClient().Batch(server.URL)
+    calls := []*a2a.BatchCall{
+        batch.GetTask(&a2a.TaskQueryParams{ID: "a"}),
+        batch.GetTask(&a2a.TaskQueryParams{ID: "missing"}),
+        batch.GetTask(&a2a.TaskQueryParams{ID: "b"}),
+    }
+    if _, err := calls[0].Task(); err == nil {
+        t.Errorf("Expected an error before the batch is sent")
+    }
+    if err := batch.Send(context.Background()); err != nil {
+        t.Fatalf("Send failed: %v", err)
+    }
+
+    for i, id := range []string{"a", "", "b"} {
+        task, err := calls[i].Task()
+        if id == "" {
+            var rpcErr *a2a.JSONRPCError
+            if err == nil || !errors.As(err, &rpcErr) || rpcErr.Code != a2a.ErrCodeTaskNotFound {
+                t.Errorf("Expected task not found, got %v", err)
+            }
+            continue
+        }
+        if err != nil || task.ID != id {
+            t.Errorf("Call %d mismatch: %+v %v", i, task, err)
+        }
+    }
+}
+
+func TestClientBatchLargeNumericIDs(t *testing.T) {
+    var inFlight, peak int32
+    server := httptest.NewServer(newBatchHandler(&inFlight, &peak))
+    defer server.Close()
+
+    next := 1000000
+    batch := a2a.NewClient().WithIDGenerator(func() interface{} {
+        next++
+        return next
+    }).Batch(server.URL)
+    calls := []*a2a.BatchCall{
+        batch.GetTask(&a2a.TaskQueryParams{ID: "a"}),
+        batch.GetTask(&a2a.TaskQueryParams{ID: "b"}),
+    }
+    if err := batch.Send(context.Background()); err != nil {
+        t.Fatalf("Send failed: %v", err)
+    }
+    for i, id := range []string{"a", "b"} {
+        if task, err := calls[i].Task(); err != nil || task.ID != id {
+            t.Errorf("Call %d mismatch: %+v %v", i, task, err)
+        }
+    }
+}
@@ -0,0 +1,340 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the HTTP client used to call remote A2A agents
+package a2a
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// HTTPError is returned when an agent answers with a non-200 HTTP status
+type HTTPError struct {
+    StatusCode int
+    Status     string
+    Body       []byte
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+    return fmt.Sprintf("a2a: unexpected HTTP status %s", e.Status)
+}
+
+// Client sends A2A requests to remote agents over HTTP
+type Client struct {
+    httpClient *http.Client
+    protocol   *Protocol
+    headers    http.Header
+    nextID     func() interface{}
+    cards      *AgentCardResolver
+    version    ProtocolVersion
+
+    // reconnectAttempts and reconnectBackoff govern how dropped task streams are resumed
+    reconnectAttempts int
+    reconnectBackoff  time.Duration
+    // wsPingInterval is the keepalive interval of WebSocket connections
+    wsPingInterval time.Duration
+    // wsRedialBackoff is how long an agent is reached over HTTP after its WebSocket connection failed to open
+    wsRedialBackoff time.Duration
+
+    mu          sync.Mutex
+    versions    map[string]ProtocolVersion
+    agents      map[string]*AgentCard
+    cardMisses  map[string]time.Time
+    credentials []CredentialProvider
+    transports  map[string]Transport
+    dials       map[string]*webSocketDial
+    unixClients map[string]*http.Client
+}
+
+// NewClient creates a new A2A client using http.DefaultClient
+func NewClient() *Client {
+    return &Client{
+        httpClient: http.DefaultClient,
+        protocol:   NewProtocol(),
+        headers:    http.Header{},
+        nextID:     newRequestIDGenerator(),
+        cards:      NewAgentCardResolver(),
+        version:    ProtocolVersionLegacy,
+        versions:   make(map[string]ProtocolVersion),
+        agents:     make(map[string]*AgentCard),
+
+        reconnectAttempts: DefaultStreamReconnectAttempts,
+        reconnectBackoff:  DefaultStreamReconnectBackoff,
+        wsPingInterval:    DefaultWebSocketPingInterval,
+        wsRedialBackoff:   DefaultWebSocketRedialBackoff,
+    }
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+// The certificates of mTLS credentials already added are installed in a copy of it.
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+    c.httpClient = httpClient
+    c.cards.WithHTTPClient(httpClient)
+    for _, provider := range c.credentials {
+        if mtls, ok := provider.(*MTLSCredentials); ok {
+            c.installClientCertificate(mtls)
+        }
+    }
+    return c
+}
+
+// WithHeader adds a header sent with every request
+func (c *Client) WithHeader(key, value string) *Client {
+    c.headers.Add(key, value)
+    return c
+}
+
+// WithIDGenerator sets the function used to generate JSON-RPC request IDs
+func (c *Client) WithIDGenerator(nextID func() interface{}) *Client {
+    c.nextID = nextID
+    return c
+}
+
+// newRequestIDGenerator returns a generator of unique string request IDs
+func newRequestIDGenerator() func() interface{} {
+    prefix := make([]byte, 6)
+    rand.Read(prefix)
+    var counter uint64
+    return func() interface{} {
+        return fmt.Sprintf("%s-%d", hex.EncodeToString(prefix), atomic.AddUint64(&counter, 1))
+    }
+}
+
+// SendTask sends a tasks/send request to the agent at url
+func (c *Client) SendTask(ctx context.Context, params *TaskSendParams, url string) (*Task, error) {
+    if loopback := c.zeroCopyTransport(ctx, url); loopback != nil {
+        return loopback.callTask(ctx, MethodSendTask, params)
+    }
+    if !c.versionFor(url).IsLegacy() {
+        return c.sendMessage(ctx, params, url)
+    }
+    request := c.protocol.CreateSendTaskRequest(c.nextID(), *params)
+    data, err := c.post(ctx, url, request)
+    if err != nil {
+        return nil, err
+    }
+    response, err := c.protocol.ParseSendTaskResponse(data)
+    if response == nil {
+        return nil, err
+    }
+    if err := checkResponse(response.Error, err, response.ID, request.ID); err != nil {
+        return nil, err
+    }
+    if response.Result == nil {
+        return nil, errNoResult
+    }
+    return response.Result, nil
+}
+
+// GetTask sends a tasks/get request to the agent at url
+func (c *Client) GetTask(ctx context.Context, params *TaskQueryParams, url string) (*Task, error) {
+    if loopback := c.zeroCopyTransport(ctx, url); loopback != nil {
+        return loopback.callTask(ctx, MethodGetTask, params)
+    }
+    if !c.versionFor(url).IsLegacy() {
+        return c.getTaskV2(ctx, params, url)
+    }
+    request := c.protocol.CreateGetTaskRequest(c.nextID(), *params)
+    data, err := c.post(ctx, url, request)
+    if err != nil {
+        return nil, err
+    }
+    response, err := c.protocol.ParseGetTaskResponse(data)
+    if response == nil {
+        return nil, err
+    }
+    if err := checkResponse(response.Error, err, response.ID, request.ID); err != nil {
+        return nil, err
+    }
+    if response.Result == nil {
+        return nil, errNoResult
+    }
+    return response.Result, nil
+}
+
+// CancelTask sends a tasks/cancel request to the agent at url
+func (c *Client) CancelTask(ctx context.Context, params *TaskIdParams, url string) (*Task, error) {
+    if loopback := c.zeroCopyTransport(ctx, url); loopback != nil {
+        return loopback.callTask(ctx, MethodCancelTask, params)
+    }
+    if !c.versionFor(url).IsLegacy() {
+        return c.cancelTaskV2(ctx, params, url)
+    }
+    request := c.protocol.CreateCancelTaskRequest(c.nextID(), *params)
+    data, err := c.post(ctx, url, request)
+    if err != nil {
+        return nil, err
+    }
+    response, err := c.protocol.ParseCancelTaskResponse(data)
+    if response == nil {
+        return nil, err
+    }
+    if err := checkResponse(response.Error, err, response.ID, request.ID); err != nil {
+        return nil, err
+    }
+    if response.Result == nil {
+        return nil, errNoResult
+    }
+    return response.Result, nil
+}
+
+// SetTaskPushNotification sends a tasks/pushNotification/set request to the agent at url
+func (c *Client) SetTaskPushNotification(ctx context.Context, params *TaskPushNotificationConfig, url string) (*TaskPushNotificationConfig, error) {
+    if !c.versionFor(url).IsLegacy() {
+        return c.setTaskPushNotificationV2(ctx, params, url)
+    }
+    request := c.protocol.CreateSetTaskPushNotificationRequest(c.nextID(), *params)
+    data, err := c.post(ctx, url, request)
+    if err != nil {
+        return nil, err
+    }
+    response, err := c.protocol.ParseSetTaskPushNotificationResponse(data)
+    if response == nil {
+        return nil, err
+    }
+    if err := checkResponse(response.Error, err, response.ID, request.ID); err != nil {
+        return nil, err
+    }
+    if response.Result == nil {
+        return nil, errNoResult
+    }
+    return response.Result, nil
+}
+
+// GetTaskPushNotification sends a tasks/pushNotification/get request to the agent at url
+func (c *Client) GetTaskPushNotification(ctx context.Context, params *TaskIdParams, url string) (*TaskPushNotificationConfig, error) {
+    if !c.versionFor(url).IsLegacy() {
+        return c.getTaskPushNotificationV2(ctx, params, url)
+    }
+    request := c.protocol.CreateGetTaskPushNotificationRequest(c.nextID(), *params)
+    data, err := c.post(ctx, url, request)
+    if err != nil {
+        return nil, err
+    }
+    response, err := c.protocol.ParseGetTaskPushNotificationResponse(data)
+    if response == nil {
+        return nil, err
+    }
+    if err := checkResponse(response.Error, err, response.ID, request.ID); err != nil {
+        return nil, err
+    }
+    if response.Result == nil {
+        return nil, errNoResult
+    }
+    return response.Result, nil
+}
+
+// newHTTPRequest builds the HTTP request carrying a JSON-RPC payload
+func (c *Client) newHTTPRequest(ctx context.Context, url string, body []byte) (*http.Request, error) {
+    target := url
+    if _, httpURL, ok := unixSocketURL(url); ok {
+        target = httpURL
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    for key, values := range c.headers {
+        req.Header[key] = append([]string(nil), values...)
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(ProtocolVersionHeader, string(c.versionFor(url)))
+    return req, nil
+}
+
+// do sends a JSON-RPC payload with the credentials for the agent at url and any extra headers.
+// A request rejected with 401 is sent once more after the provider's cached token is dropped.
+func (c *Client) do(ctx context.Context, url string, body []byte, accept string, header http.Header) (*http.Response, error) {
+    provider, err := c.credentialsFor(ctx, url)
+    if err != nil {
+        return nil, err
+    }
+    for attempt := 0; ; attempt++ {
+        req, err := c.newHTTPRequest(ctx, url, body)
+        if err != nil {
+            return nil, err
+        }
+        req.Header.Set("Accept", accept)
+        for key, values := range header {
+            req.Header[key] = append([]string(nil), values...)
+        }
+        if provider != nil {
+            if err := provider.Apply(ctx, req); err != nil {
+                return nil, fmt.Errorf("a2a: applying %s credentials: %w", provider.Scheme(), err)
+            }
+        }
+
+        resp, err := c.httpClientFor(url).Do(req)
+        if err != nil {
+            return nil, err
+        }
+        invalidator, ok := provider.(tokenInvalidator)
+        if resp.StatusCode != http.StatusUnauthorized || !ok || attempt > 0 {
+            return resp, nil
+        }
+        resp.Body.Close()
+        invalidator.Invalidate()
+    }
+}
+
+// post sends a JSON-RPC request and returns the raw response body
+func (c *Client) post(ctx context.Context, url string, request interface{ ToJSON() ([]byte, error) }) ([]byte, error) {
+    body, err := request.ToJSON()
+    if err != nil {
+        return nil, err
+    }
+    if transport := c.transportFor(ctx, url); transport != nil {
+        return transport.Call(ctx, body)
+    }
+    resp, err := c.do(ctx, url, body, "application/json", nil)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    data, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+    }
+    return data, nil
+}
+
+// errNoResult is returned when a successful response carries no result
+var errNoResult = errors.New("a2a: response has no result")
+
+// checkResponse returns the error carried by a parsed response, preferring the typed JSON-RPC error
+func checkResponse(rpcErr *JSONRPCError, parseErr error, responseID, requestID interface{}) error {
+    if rpcErr != nil {
+        return rpcErr
+    }
+    if parseErr != nil {
+        return parseErr
+    }
+    if idValueKey(responseID) != idValueKey(requestID) {
+        return fmt.Errorf("a2a: response id %v does not match request id %v", responseID, requestID)
+    }
+    return nil
+}
+
+// idValueKey returns the key of a decoded or generated request ID, as idKey does for its JSON.
+// Numbers decoded as float64 get the same key as the integers they were sent as.
+func idValueKey(id interface{}) string {
+    data, err := json.Marshal(id)
+    if err != nil {
+        return fmt.Sprint(id)
+    }
+    return idKey(data)
+}
@@ -0,0 +1,84 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestClientSendAndGetTask(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            task := a2a.NewTask(params.ID, a2a.TaskStateCompleted)
+            return task.AddToHistory(params.Message), nil
+        }).
+        HandleTaskGet(func(ctx context.Context, params *a2a.TaskQueryParams) (*a2a.Task, error) {
+            return nil, a2a.TaskNotFoundError()
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    client := a2a.NewClient().WithHTTPClient(server.Client())
+    task, err := client.SendTask(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hello")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.ID != "task-1" || task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("Unexpected task: %s %s", task.ID, task.Status.State)
+    }
+    if textPart, ok := task.History[0].Parts[0].(a2a.TextPart); !ok || textPart.Text != "hello" {
+        t.Errorf("History mismatch: %#v", task.History)
+    }
+
+    _, err = client.GetTask(context.Background(), &a2a.TaskQueryParams{ID: "missing"}, server.URL)
+    var rpcErr *a2a.JSONRPCError
+    if !errors.As(err, &rpcErr) || rpcErr.Code != a2a.ErrCodeTaskNotFound {
+        t.Errorf("Expected task not found error, got %v", err)
+    }
+}
+
+func TestClientHTTPErrorAndCancellation(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        http.Error(w, "unavailable", http.StatusServiceUnavailable)
+    }))
+    defer server.Close()
+
+    client := a2a.NewClient()
+    _, err := client.CancelTask(context.Background(), &a2a.TaskIdParams{ID: "task-1"}, server.URL)
+    var httpErr *a2a.HTTPError
+    if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+        t.Errorf("Expected HTTP error, got %v", err)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    _, err = client.CancelTask(ctx, &a2a.TaskIdParams{ID: "task-1"}, server.URL)
+    if !errors.Is(err, context.Canceled) {
+        t.Errorf("Expected context canceled, got %v", err)
+    }
+}
+
+func TestClientLargeNumericIDs(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskGet(func(ctx context.Context, params *a2a.TaskQueryParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    next := 1000000
+    client := a2a.NewClient().WithIDGenerator(func() interface{} {
+        next++
+        return next
+    })
+    if _, err := client.GetTask(context.Background(), &a2a.TaskQueryParams{ID: "task-1"}, server.URL); err != nil {
+        t.Fatalf("GetTask failed: %v", err)
+    }
+}
@@ -0,0 +1,229 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements deep copies of tasks, messages and the values they hold
+package a2a
+
+import "encoding/json"
+
+// Clone returns a deep copy of the task
+func (t *Task) Clone() *Task {
+    if t == nil {
+        return nil
+    }
+    clone := *t
+    clone.SessionID = copyString(t.SessionID)
+    clone.Status = t.Status.clone()
+    if t.Artifacts != nil {
+        clone.Artifacts = make([]Artifact, len(t.Artifacts))
+        for i := range t.Artifacts {
+            clone.Artifacts[i] = *t.Artifacts[i].Clone()
+        }
+    }
+    if t.History != nil {
+        clone.History = make([]Message, len(t.History))
+        for i := range t.History {
+            clone.History[i] = *t.History[i].Clone()
+        }
+    }
+    clone.Metadata = copyMetadata(t.Metadata)
+    return &clone
+}
+
+// Clone returns a deep copy of the message
+func (m *Message) Clone() *Message {
+    if m == nil {
+        return nil
+    }
+    clone := *m
+    clone.Parts = copyParts(m.Parts)
+    clone.Metadata = copyMetadata(m.Metadata)
+    return &clone
+}
+
+// Clone returns a deep copy of the artifact
+func (a *Artifact) Clone() *Artifact {
+    if a == nil {
+        return nil
+    }
+    clone := *a
+    clone.Name = copyString(a.Name)
+    clone.Description = copyString(a.Description)
+    clone.Parts = copyParts(a.Parts)
+    if a.Append != nil {
+        appended := *a.Append
+        clone.Append = &appended
+    }
+    if a.LastChunk != nil {
+        lastChunk := *a.LastChunk
+        clone.LastChunk = &lastChunk
+    }
+    clone.Metadata = copyMetadata(a.Metadata)
+    return &clone
+}
+
+// clone returns a deep copy of the status
+func (s TaskStatus) clone() TaskStatus {
+    s.Message = s.Message.Clone()
+    return s
+}
+
+// clone returns a deep copy of the params
+func (p *TaskSendParams) clone() *TaskSendParams {
+    clone := *p
+    clone.Message = *p.Message.Clone()
+    if p.PushNotification != nil {
+        config := *p.PushNotification
+        config.Token = copyString(config.Token)
+        if config.Authentication != nil {
+            authentication := *config.Authentication
+            authentication.Schemes = append([]string(nil), authentication.Schemes...)
+            authentication.Credentials = copyString(authentication.Credentials)
+            config.Authentication = &authentication
+        }
+        clone.PushNotification = &config
+    }
+    if p.HistoryLength != nil {
+        historyLength := *p.HistoryLength
+        clone.HistoryLength = &historyLength
+    }
+    clone.Metadata = copyMetadata(p.Metadata)
+    return &clone
+}
+
+// copyParams deep-copies params passed as a Go value into dst, reporting whether their types match
+func copyParams(dst, src interface{}) bool {
+    switch dst := dst.(type) {
+    case *TaskSendParams:
+        params, ok := src.(*TaskSendParams)
+        if ok && params != nil {
+            *dst = *params.clone()
+        }
+        return ok && params != nil
+    case *TaskQueryParams:
+        params, ok := src.(*TaskQueryParams)
+        if ok && params != nil {
+            *dst = *params
+            if params.HistoryLength != nil {
+                historyLength := *params.HistoryLength
+                dst.HistoryLength = &historyLength
+            }
+            dst.Metadata = copyMetadata(params.Metadata)
+        }
+        return ok && params != nil
+    case *TaskIdParams:
+        params, ok := src.(*TaskIdParams)
+        if ok && params != nil {
+            *dst = *params
+            dst.Metadata = copyMetadata(params.Metadata)
+        }
+        return ok && params != nil
+    }
+    return false
+}
+
+// copyTaskEvent returns a deep copy of the event carried by a streaming response
+func copyTaskEvent(result interface{}) *TaskEvent {
+    switch event := result.(type) {
+    case TaskStatusUpdateEvent:
+        event.Status = event.Status.clone()
+        event.Metadata = copyMetadata(event.Metadata)
+        return &TaskEvent{Status: &event}
+    case TaskArtifactUpdateEvent:
+        event.Artifact = *event.Artifact.Clone()
+        event.Metadata = copyMetadata(event.Metadata)
+        return &TaskEvent{Artifact: &event}
+    }
+    return nil
+}
+
+// copyParts returns a deep copy of the parts
+func copyParts(parts []Part) []Part {
+    if parts == nil {
+        return nil
+    }
+    clone := make([]Part, len(parts))
+    for i, part := range parts {
+        clone[i] = copyPart(part)
+    }
+    return clone
+}
+
+// copyPart returns a deep copy of the part.
+// Parts of other types are copied through their JSON form, as they would be on the wire;
+// a part that cannot be encoded is returned as is.
+func copyPart(part Part) Part {
+    switch p := part.(type) {
+    case nil:
+        return nil
+    case TextPart:
+        p.Metadata = copyMetadata(p.Metadata)
+        return p
+    case FilePart:
+        p.Metadata = copyMetadata(p.Metadata)
+        return p
+    case DataPart:
+        p.Data = copyMetadata(p.Data)
+        p.Metadata = copyMetadata(p.Metadata)
+        return p
+    case UnknownPart:
+        p.Raw = append(json.RawMessage(nil), p.Raw...)
+        return p
+    }
+    data, err := json.Marshal(part)
+    if err != nil {
+        return part
+    }
+    clone, err := unmarshalPart(data)
+    if err != nil {
+        return part
+    }
+    return clone
+}
+
+// copyMetadata returns a deep copy of a metadata map
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+    if metadata == nil {
+        return nil
+    }
+    clone := make(map[string]interface{}, len(metadata))
+    for key, value := range metadata {
+        clone[key] = copyValue(value)
+    }
+    return clone
+}
+
+// copyValue returns a deep copy of a JSON value.
+// Values of other types are copied through their JSON form, as they would be on the wire.
+func copyValue(value interface{}) interface{} {
+    switch v := value.(type) {
+    case nil, string, bool, float64, float32, int, int32, int64, uint, uint32, uint64, json.Number:
+        return v
+    case map[string]interface{}:
+        return copyMetadata(v)
+    case []interface{}:
+        clone := make([]interface{}, len(v))
+        for i, element := range v {
+            clone[i] = copyValue(element)
+        }
+        return clone
+    case json.RawMessage:
+        return append(json.RawMessage(nil), v...)
+    }
+    data, err := json.Marshal(value)
+    if err != nil {
+        return value
+    }
+    var clone interface{}
+    if err := json.Unmarshal(data, &clone); err != nil {
+        return value
+    }
+    return clone
+}
+
+// copyString returns a copy of an optional string
+func copyString(s *string) *string {
+    if s == nil {
+        return nil
+    }
+    clone := *s
+    return &clone
+}
@@ -0,0 +1,449 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the client-side credential providers for the authentication schemes of agent cards
+package a2a
+
+import (
+    "context"
+    "crypto/tls"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+)
+
+// tokenRefreshMargin is how long before expiry a cached token is replaced
+const tokenRefreshMargin = 30 * time.Second
+
+// missingCardRetryInterval is how long an agent publishing no card is not asked for one again
+const missingCardRetryInterval = time.Minute
+
+// CredentialProvider attaches the credentials of one authentication scheme to outgoing requests
+type CredentialProvider interface {
+    // Scheme returns the scheme the provider supplies credentials for, as advertised in AgentAuthentication.Schemes
+    Scheme() string
+    // Apply adds the credentials to the request
+    Apply(ctx context.Context, req *http.Request) error
+}
+
+// tokenInvalidator is implemented by providers caching tokens that can be refreshed after a rejection
+type tokenInvalidator interface {
+    Invalidate()
+}
+
+// tokenCache holds a token until shortly before it expires
+type tokenCache struct {
+    mu     sync.Mutex
+    token  string
+    expiry time.Time
+}
+
+// get returns the cached token, calling fetch when there is none or it is about to expire
+func (c *tokenCache) get(fetch func() (string, time.Time, error)) (string, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.token != "" && (c.expiry.IsZero() || time.Now().Add(tokenRefreshMargin).Before(c.expiry)) {
+        return c.token, nil
+    }
+    token, expiry, err := fetch()
+    if err != nil {
+        return "", err
+    }
+    c.token, c.expiry = token, expiry
+    return token, nil
+}
+
+// clear drops the cached token
+func (c *tokenCache) clear() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.token = ""
+}
+
+// StaticTokenCredentials supplies a fixed token.
+// ApiKey tokens are sent in the API key header, all others in the Authorization header.
+type StaticTokenCredentials struct {
+    scheme string
+    token  string
+    header string
+}
+
+// NewStaticTokenCredentials creates a provider sending token for the scheme
+func NewStaticTokenCredentials(scheme, token string) *StaticTokenCredentials {
+    return &StaticTokenCredentials{
+        scheme: scheme,
+        token:  token,
+        header: DefaultAPIKeyHeader,
+    }
+}
+
+// NewBasicCredentials creates a provider sending a user name and password with the Basic scheme
+func NewBasicCredentials(username, password string) *StaticTokenCredentials {
+    return NewStaticTokenCredentials(AuthSchemeBasic, base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+}
+
+// WithHeader sets the header carrying API keys
+func (p *StaticTokenCredentials) WithHeader(header string) *StaticTokenCredentials {
+    p.header = header
+    return p
+}
+
+// Scheme implements CredentialProvider
+func (p *StaticTokenCredentials) Scheme() string {
+    return p.scheme
+}
+
+// Apply implements CredentialProvider
+func (p *StaticTokenCredentials) Apply(ctx context.Context, req *http.Request) error {
+    switch {
+    case strings.EqualFold(p.scheme, AuthSchemeAPIKey):
+        req.Header.Set(p.header, p.token)
+    case strings.EqualFold(p.scheme, AuthSchemeJWT):
+        req.Header.Set("Authorization", "Bearer "+p.token)
+    default:
+        req.Header.Set("Authorization", p.scheme+" "+p.token)
+    }
+    return nil
+}
+
+// ClientCredentials obtains bearer tokens from an OAuth2 token endpoint with the client credentials grant.
+// Tokens are cached and fetched again shortly before they expire.
+type ClientCredentials struct {
+    tokenURL     string
+    clientID     string
+    clientSecret string
+    scopes       []string
+    scheme       string
+    httpClient   *http.Client
+    cache        tokenCache
+}
+
+// NewClientCredentials creates a provider requesting tokens from tokenURL
+func NewClientCredentials(tokenURL, clientID, clientSecret string) *ClientCredentials {
+    return &ClientCredentials{
+        tokenURL:     tokenURL,
+        clientID:     clientID,
+        clientSecret: clientSecret,
+        scheme:       AuthSchemeBearer,
+        httpClient:   http.DefaultClient,
+    }
+}
+
+// WithScopes sets the scopes requested with each token
+func (p *ClientCredentials) WithScopes(scopes ...string) *ClientCredentials {
+    p.scopes = scopes
+    return p
+}
+
+// WithScheme sets the advertised scheme the tokens are used for, Bearer by default
+func (p *ClientCredentials) WithScheme(scheme string) *ClientCredentials {
+    p.scheme = scheme
+    return p
+}
+
+// WithHTTPClient sets the HTTP client used to call the token endpoint
+func (p *ClientCredentials) WithHTTPClient(httpClient *http.Client) *ClientCredentials {
+    p.httpClient = httpClient
+    return p
+}
+
+// Scheme implements CredentialProvider
+func (p *ClientCredentials) Scheme() string {
+    return p.scheme
+}
+
+// Apply implements CredentialProvider
+func (p *ClientCredentials) Apply(ctx context.Context, req *http.Request) error {
+    token, err := p.cache.get(func() (string, time.Time, error) {
+        return p.fetch(ctx)
+    })
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Authorization", "Bearer "+token)
+    return nil
+}
+
+// Invalidate drops the cached token so the next request fetches a new one
+func (p *ClientCredentials) Invalidate() {
+    p.cache.clear()
+}
+
+// fetch requests a new token from the token endpoint
+func (p *ClientCredentials) fetch(ctx context.Context) (string, time.Time, error) {
+    form := url.Values{"grant_type": {"client_credentials"}}
+    if len(p.scopes) > 0 {
+        form.Set("scope", strings.Join(p.scopes, " "))
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", time.Time{}, err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+    resp, err := p.httpClient.Do(req)
+    if err != nil {
+        return "", time.Time{}, err
+    }
+    defer resp.Body.Close()
+    data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    if err != nil {
+        return "", time.Time{}, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return "", time.Time{}, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+    }
+
+    var token tokenResponse
+    if err := json.Unmarshal(data, &token); err != nil {
+        return "", time.Time{}, fmt.Errorf("a2a: invalid token response: %w", err)
+    }
+    if token.AccessToken == "" {
+        return "", time.Time{}, fmt.Errorf("a2a: token response has no access_token")
+    }
+    if token.TokenType != "" && !strings.EqualFold(token.TokenType, "Bearer") {
+        return "", time.Time{}, fmt.Errorf("a2a: unsupported token type %q", token.TokenType)
+    }
+    var expiry time.Time
+    if token.ExpiresIn > 0 {
+        expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+    }
+    return token.AccessToken, expiry, nil
+}
+
+// tokenResponse is the successful response of an OAuth2 token endpoint
+type tokenResponse struct {
+    AccessToken string `json:"access_token"`
+    TokenType   string `json:"token_type"`
+    ExpiresIn   int64  `json:"expires_in,omitempty"`
+    Scope       string `json:"scope,omitempty"`
+}
+
+// JWTCredentials signs its own short-lived JWT bearer tokens.
+// A token is reused until shortly before it expires.
+type JWTCredentials struct {
+    key      interface{}
+    issuer   string
+    subject  string
+    audience string
+    ttl      time.Duration
+    claims   map[string]interface{}
+    cache    tokenCache
+}
+
+// NewJWTCredentials creates a provider signing tokens with the key.
+// The key is a []byte secret, an *rsa.PrivateKey or an *ecdsa.PrivateKey.
+func NewJWTCredentials(key interface{}, subject string) *JWTCredentials {
+    return &JWTCredentials{
+        key:     key,
+        subject: subject,
+        ttl:     5 * time.Minute,
+    }
+}
+
+// WithIssuer sets the iss claim
+func (p *JWTCredentials) WithIssuer(issuer string) *JWTCredentials {
+    p.issuer = issuer
+    return p
+}
+
+// WithAudience sets the aud claim
+func (p *JWTCredentials) WithAudience(audience string) *JWTCredentials {
+    p.audience = audience
+    return p
+}
+
+// WithTTL sets how long each token is valid
+func (p *JWTCredentials) WithTTL(ttl time.Duration) *JWTCredentials {
+    p.ttl = ttl
+    return p
+}
+
+// WithClaim adds a claim to every token
+func (p *JWTCredentials) WithClaim(name string, value interface{}) *JWTCredentials {
+    if p.claims == nil {
+        p.claims = make(map[string]interface{})
+    }
+    p.claims[name] = value
+    return p
+}
+
+// Scheme implements CredentialProvider
+func (p *JWTCredentials) Scheme() string {
+    return AuthSchemeJWT
+}
+
+// Apply implements CredentialProvider
+func (p *JWTCredentials) Apply(ctx context.Context, req *http.Request) error {
+    token, err := p.cache.get(p.sign)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Authorization", "Bearer "+token)
+    return nil
+}
+
+// Invalidate drops the cached token so the next request signs a new one
+func (p *JWTCredentials) Invalidate() {
+    p.cache.clear()
+}
+
+// sign creates a new token
+func (p *JWTCredentials) sign() (string, time.Time, error) {
+    now := time.Now()
+    expiry := now.Add(p.ttl)
+    claims := make(map[string]interface{}, len(p.claims)+6)
+    for name, value := range p.claims {
+        claims[name] = value
+    }
+    claims["sub"] = p.subject
+    claims["iat"] = now.Unix()
+    claims["exp"] = expiry.Unix()
+    claims["jti"] = randomID()
+    if p.issuer != "" {
+        claims["iss"] = p.issuer
+    }
+    if p.audience != "" {
+        claims["aud"] = p.audience
+    }
+    token, err := signJWT(claims, p.key)
+    return token, expiry, err
+}
+
+// MTLSCredentials presents a client certificate during the TLS handshake.
+// The certificate is installed in the transport of the client it is added to, so Apply adds nothing to requests.
+type MTLSCredentials struct {
+    certificate tls.Certificate
+}
+
+// NewMTLSCredentials creates a provider presenting the certificate
+func NewMTLSCredentials(certificate tls.Certificate) *MTLSCredentials {
+    return &MTLSCredentials{certificate: certificate}
+}
+
+// Scheme implements CredentialProvider
+func (p *MTLSCredentials) Scheme() string {
+    return AuthSchemeMTLS
+}
+
+// Apply implements CredentialProvider
+func (p *MTLSCredentials) Apply(ctx context.Context, req *http.Request) error {
+    return nil
+}
+
+// Transport returns a copy of base presenting the certificate, or of http.DefaultTransport when base is nil
+func (p *MTLSCredentials) Transport(base *http.Transport) *http.Transport {
+    if base == nil {
+        base = http.DefaultTransport.(*http.Transport)
+    }
+    transport := base.Clone()
+    if transport.TLSClientConfig == nil {
+        transport.TLSClientConfig = &tls.Config{}
+    }
+    transport.TLSClientConfig.Certificates = append(transport.TLSClientConfig.Certificates, p.certificate)
+    return transport
+}
+
+// WithCredentials adds credential providers. For each agent, the provider of the first scheme
+// in its card's AgentAuthentication.Schemes that has a provider is used. Cards are resolved
+// on the first request to an agent unless NegotiateVersion already resolved them.
+// mTLS providers install their certificate in the transport of the client's HTTP client.
+// Providers that cache tokens get a fresh token when an agent rejects a request with 401.
+func (c *Client) WithCredentials(providers ...CredentialProvider) *Client {
+    for _, provider := range providers {
+        if mtls, ok := provider.(*MTLSCredentials); ok {
+            c.installClientCertificate(mtls)
+        }
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.credentials = append(c.credentials, providers...)
+    return c
+}
+
+// installClientCertificate replaces the HTTP client by one whose transport presents the certificate
+func (c *Client) installClientCertificate(mtls *MTLSCredentials) {
+    base, _ := c.httpClient.Transport.(*http.Transport)
+    httpClient := *c.httpClient
+    httpClient.Transport = mtls.Transport(base)
+    c.httpClient = &httpClient
+    c.cards.WithHTTPClient(&httpClient)
+}
+
+// credentialsFor returns the provider for the agent at agentURL, or nil when no advertised scheme has one.
+// The card is looked up under the URL and then under the origin of the URL, where the RPC endpoint
+// of an agent often lives below the base URL publishing the card. A card that cannot be fetched fails
+// the request and is fetched again by the next one; an agent publishing none is asked again after
+// missingCardRetryInterval.
+func (c *Client) credentialsFor(ctx context.Context, agentURL string) (CredentialProvider, error) {
+    c.mu.Lock()
+    if len(c.credentials) == 0 {
+        c.mu.Unlock()
+        return nil, nil
+    }
+    key := strings.TrimSuffix(agentURL, "/")
+    card, known := c.agents[key]
+    missing := time.Now().Before(c.cardMisses[key])
+    c.mu.Unlock()
+
+    if !known && !missing {
+        resolved, err := c.resolveCardOf(ctx, agentURL)
+        if err != nil {
+            return nil, fmt.Errorf("a2a: resolving the agent card for credentials: %w", err)
+        }
+        card = resolved
+        c.mu.Lock()
+        if card != nil {
+            c.agents[key] = card
+            delete(c.cardMisses, key)
+        } else {
+            // Remembered for a while, so the agent is not asked on every request
+            if c.cardMisses == nil {
+                c.cardMisses = make(map[string]time.Time)
+            }
+            c.cardMisses[key] = time.Now().Add(missingCardRetryInterval)
+        }
+        c.mu.Unlock()
+    }
+    if card == nil || card.Authentication == nil {
+        return nil, nil
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for _, scheme := range card.Authentication.Schemes {
+        for _, provider := range c.credentials {
+            if strings.EqualFold(provider.Scheme(), scheme) {
+                return provider, nil
+            }
+        }
+    }
+    return nil, nil
+}
+
+// resolveCardOf returns the card of the agent at agentURL, published under the URL itself or under its origin.
+// It returns nil without error when neither publishes one.
+func (c *Client) resolveCardOf(ctx context.Context, agentURL string) (*AgentCard, error) {
+    candidates := []string{agentURL}
+    if parsed, err := url.Parse(agentURL); err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") {
+        if origin := parsed.Scheme + "://" + parsed.Host; origin != strings.TrimSuffix(agentURL, "/") {
+            candidates = append(candidates, origin)
+        }
+    }
+    for _, candidate := range candidates {
+        card, err := c.cards.Resolve(ctx, candidate)
+        var httpErr *HTTPError
+        if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+            continue
+        }
+        return card, err
+    }
+    return nil, nil
+}
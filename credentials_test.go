@@ -0,0 +1,169 @@
+package a2a_test
+
+import (
+    "context"
+    "crypto/tls"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestClientCredentialsFromTokenEndpoint(t *testing.T) {
+    secret := []byte("token-secret")
+    var issued int32
+    endpoint := a2a.NewTokenEndpoint(secret).WithClient("agent-b", "s3cret").WithIssuer("local").WithTTL(20 * time.Second)
+    tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&issued, 1)
+        endpoint.ServeHTTP(w, r)
+    }))
+    defer tokenServer.Close()
+
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    card.Authentication = &a2a.AgentAuthentication{Schemes: []string{a2a.AuthSchemeBearer}}
+    middleware, err := a2a.NewAuthMiddleware(card, a2a.NewBearerAuthenticator(a2a.NewJWTAuthenticator(secret).WithIssuer("local").Validate))
+    if err != nil {
+        t.Fatalf("NewAuthMiddleware failed: %v", err)
+    }
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            principal, _ := a2a.PrincipalFromContext(ctx)
+            task := a2a.NewTask(params.ID, a2a.TaskStateCompleted)
+            task.Metadata = map[string]interface{}{"subject": principal.Subject}
+            return task, nil
+        })
+    server := httptest.NewServer(middleware.Wrap(handler))
+    defer server.Close()
+
+    client := a2a.NewClient().WithProtocolVersion(a2a.ProtocolVersionLegacy).WithCredentials(
+        a2a.NewStaticTokenCredentials(a2a.AuthSchemeAPIKey, "unused"),
+        a2a.NewClientCredentials(tokenServer.URL, "agent-b", "s3cret"),
+    )
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")})}
+    for i := 0; i < 2; i++ {
+        task, err := client.SendTask(context.Background(), params, server.URL)
+        if err != nil {
+            t.Fatalf("SendTask failed: %v", err)
+        }
+        if task.Metadata["subject"] != "agent-b" {
+            t.Errorf("Subject mismatch: %v", task.Metadata)
+        }
+    }
+    // Tokens valid for less than the refresh margin are fetched again for every request
+    if got := atomic.LoadInt32(&issued); got != 2 {
+        t.Errorf("Token requests mismatch: expected 2, got %d", got)
+    }
+
+    bad := a2a.NewClient().WithCredentials(a2a.NewClientCredentials(tokenServer.URL, "agent-b", "wrong"))
+    if _, err := bad.SendTask(context.Background(), params, server.URL); err == nil {
+        t.Errorf("Expected an error for rejected client credentials")
+    }
+}
+
+func TestClientPicksAdvertisedScheme(t *testing.T) {
+    secret := []byte("jwt-secret")
+    server := newAuthServer(t,
+        a2a.NewAPIKeyAuthenticator(a2a.StaticAPIKeys(map[string]string{"key-1": "alice"})),
+        a2a.NewJWTAuthenticator(secret).WithIssuer("issuer"),
+        a2a.NewBasicAuthenticator(nil),
+    )
+    defer server.Close()
+
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")})}
+    tests := []struct {
+        name     string
+        provider a2a.CredentialProvider
+        scheme   string
+        subject  string
+    }{
+        {name: "api key", provider: a2a.NewStaticTokenCredentials(a2a.AuthSchemeAPIKey, "key-1"), scheme: a2a.AuthSchemeAPIKey, subject: "alice"},
+        {name: "jwt signer", provider: a2a.NewJWTCredentials(secret, "bob").WithIssuer("issuer"), scheme: a2a.AuthSchemeJWT, subject: "bob"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            client := a2a.NewClient().WithCredentials(a2a.NewMTLSCredentials(tls.Certificate{}), tt.provider)
+            task, err := client.SendTask(context.Background(), params, server.URL)
+            if err != nil {
+                t.Fatalf("SendTask failed: %v", err)
+            }
+            if task.Metadata["scheme"] != tt.scheme || task.Metadata["subject"] != tt.subject {
+                t.Errorf("Principal mismatch: %v", task.Metadata)
+            }
+        })
+    }
+}
+
+// forward proxies the request to the URL
+func forward(w http.ResponseWriter, r *http.Request, url string) {
+    proxy, _ := http.NewRequestWithContext(r.Context(), r.Method, url, r.Body)
+    proxy.Header = r.Header
+    resp, err := http.DefaultClient.Do(proxy)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadGateway)
+        return
+    }
+    defer resp.Body.Close()
+    w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+    w.WriteHeader(resp.StatusCode)
+    io.Copy(w, resp.Body)
+}
+
+func TestClientRetriesCardAfterFailure(t *testing.T) {
+    server := newAuthServer(t, a2a.NewAPIKeyAuthenticator(a2a.StaticAPIKeys(map[string]string{"key-1": "alice"})),
+        a2a.NewJWTAuthenticator([]byte("unused")), a2a.NewBasicAuthenticator(nil))
+    defer server.Close()
+    var failures int32 = 1
+    flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == a2a.AgentCardPath && atomic.AddInt32(&failures, -1) >= 0 {
+            http.Error(w, "unavailable", http.StatusServiceUnavailable)
+            return
+        }
+        forward(w, r, server.URL+r.URL.Path)
+    }))
+    defer flaky.Close()
+
+    client := a2a.NewClient().WithCredentials(a2a.NewStaticTokenCredentials(a2a.AuthSchemeAPIKey, "key-1"))
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")})}
+    if _, err := client.SendTask(context.Background(), params, flaky.URL); err == nil {
+        t.Fatal("Expected the card failure to be reported")
+    }
+    task, err := client.SendTask(context.Background(), params, flaky.URL)
+    if err != nil {
+        t.Fatalf("SendTask failed after the card became available: %v", err)
+    }
+    if task.Metadata["subject"] != "alice" {
+        t.Errorf("Principal mismatch: %v", task.Metadata)
+    }
+}
+
+func TestClientResolvesCardAtAgentOrigin(t *testing.T) {
+    server := newAuthServer(t, a2a.NewAPIKeyAuthenticator(a2a.StaticAPIKeys(map[string]string{"key-1": "alice"})),
+        a2a.NewJWTAuthenticator([]byte("unused")), a2a.NewBasicAuthenticator(nil))
+    defer server.Close()
+    // The card is published at the origin, the RPC endpoint lives below it
+    gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/agents/echo":
+            forward(w, r, server.URL)
+        case a2a.AgentCardPath:
+            forward(w, r, server.URL+a2a.AgentCardPath)
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer gateway.Close()
+
+    client := a2a.NewClient().WithCredentials(a2a.NewStaticTokenCredentials(a2a.AuthSchemeAPIKey, "key-1"))
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")})}
+    task, err := client.SendTask(context.Background(), params, gateway.URL+"/agents/echo")
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.Metadata["subject"] != "alice" {
+        t.Errorf("Principal mismatch: %v", task.Metadata)
+    }
+}
@@ -0,0 +1,219 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements agent card discovery through the well-known agent.json path
+package a2a
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// AgentCardPath is the well-known path at which an agent publishes its card
+const AgentCardPath = "/.well-known/agent.json"
+
+// defaultAgentCardMaxAge is how long clients may cache a served or resolved agent card
+const defaultAgentCardMaxAge = 5 * time.Minute
+
+// AgentCardHandler serves an agent card with caching headers
+type AgentCardHandler struct {
+    card   *AgentCard
+    maxAge time.Duration
+}
+
+// NewAgentCardHandler creates a handler serving the given agent card
+func NewAgentCardHandler(card *AgentCard) *AgentCardHandler {
+    return &AgentCardHandler{
+        card:   card,
+        maxAge: defaultAgentCardMaxAge,
+    }
+}
+
+// WithMaxAge sets how long clients may cache the card
+func (h *AgentCardHandler) WithMaxAge(maxAge time.Duration) *AgentCardHandler {
+    h.maxAge = maxAge
+    return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *AgentCardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+        w.Header().Set("Allow", "GET, HEAD")
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    body, err := h.card.ToJSON()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    sum := sha256.Sum256(body)
+    etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+    w.Header().Set("ETag", etag)
+    w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+    if etagMatches(r.Header.Get("If-None-Match"), etag) {
+        w.WriteHeader(http.StatusNotModified)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+    w.WriteHeader(http.StatusOK)
+    if r.Method == http.MethodGet {
+        w.Write(body)
+    }
+}
+
+// etagMatches reports whether an If-None-Match header matches the entity tag
+func etagMatches(ifNoneMatch, etag string) bool {
+    for _, candidate := range strings.Split(ifNoneMatch, ",") {
+        candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+        if candidate == etag || candidate == "*" {
+            return true
+        }
+    }
+    return false
+}
+
+// cachedAgentCard is an agent card kept by the resolver
+type cachedAgentCard struct {
+    body    []byte
+    etag    string
+    expires time.Time
+}
+
+// AgentCardResolver fetches agent cards from the well-known path and caches them.
+// Cached cards are revalidated with If-None-Match once they expire.
+type AgentCardResolver struct {
+    httpClient *http.Client
+    ttl        time.Duration
+    mu         sync.Mutex
+    cache      map[string]*cachedAgentCard
+}
+
+// NewAgentCardResolver creates a new agent card resolver using http.DefaultClient
+func NewAgentCardResolver() *AgentCardResolver {
+    return &AgentCardResolver{
+        httpClient: http.DefaultClient,
+        ttl:        defaultAgentCardMaxAge,
+        cache:      make(map[string]*cachedAgentCard),
+    }
+}
+
+// WithHTTPClient sets the HTTP client used to fetch cards
+func (r *AgentCardResolver) WithHTTPClient(httpClient *http.Client) *AgentCardResolver {
+    r.httpClient = httpClient
+    return r
+}
+
+// WithTTL sets how long a card is cached when the agent sends no max-age
+func (r *AgentCardResolver) WithTTL(ttl time.Duration) *AgentCardResolver {
+    r.ttl = ttl
+    return r
+}
+
+var defaultAgentCardResolver = NewAgentCardResolver()
+
+// ResolveAgentCard fetches the agent card published under baseURL using a shared resolver
+func ResolveAgentCard(ctx context.Context, baseURL string) (*AgentCard, error) {
+    return defaultAgentCardResolver.Resolve(ctx, baseURL)
+}
+
+// agentCardURL returns the URL of the card published under baseURL
+func agentCardURL(baseURL string) string {
+    if strings.HasSuffix(baseURL, AgentCardPath) {
+        return baseURL
+    }
+    return strings.TrimSuffix(baseURL, "/") + AgentCardPath
+}
+
+// Resolve returns the agent card published under baseURL, from the cache when it is still fresh
+func (r *AgentCardResolver) Resolve(ctx context.Context, baseURL string) (*AgentCard, error) {
+    url := agentCardURL(baseURL)
+
+    r.mu.Lock()
+    cached := r.cache[url]
+    r.mu.Unlock()
+    if cached != nil && time.Now().Before(cached.expires) {
+        return FromJSON(cached.body)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Accept", "application/json")
+    if cached != nil && cached.etag != "" {
+        req.Header.Set("If-None-Match", cached.etag)
+    }
+
+    resp, err := r.httpClient.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    switch {
+    case resp.StatusCode == http.StatusNotModified && cached != nil:
+        body = cached.body
+    case resp.StatusCode != http.StatusOK:
+        return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+    }
+
+    card, err := FromJSON(body)
+    if err != nil {
+        return nil, err
+    }
+    if err := card.Validate(); err != nil {
+        return nil, fmt.Errorf("a2a: invalid agent card at %s: %w", url, err)
+    }
+
+    r.mu.Lock()
+    r.cache[url] = &cachedAgentCard{
+        body:    body,
+        etag:    resp.Header.Get("ETag"),
+        expires: time.Now().Add(r.cacheTTL(resp.Header.Get("Cache-Control"))),
+    }
+    r.mu.Unlock()
+    return card, nil
+}
+
+// Invalidate removes the card published under baseURL from the cache
+func (r *AgentCardResolver) Invalidate(baseURL string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    delete(r.cache, agentCardURL(baseURL))
+}
+
+// cacheTTL returns how long to cache a card given the Cache-Control header of the response
+func (r *AgentCardResolver) cacheTTL(cacheControl string) time.Duration {
+    for _, directive := range strings.Split(cacheControl, ",") {
+        directive = strings.TrimSpace(directive)
+        if directive == "no-cache" || directive == "no-store" {
+            return 0
+        }
+        if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+            if seconds, err := strconv.Atoi(value); err == nil {
+                return time.Duration(seconds) * time.Second
+            }
+        }
+    }
+    return r.ttl
+}
+
+// ResolveAgentCard fetches the agent card published under baseURL using the client's HTTP client
+func (c *Client) ResolveAgentCard(ctx context.Context, baseURL string) (*AgentCard, error) {
+    return c.cards.Resolve(ctx, baseURL)
+}
@@ -0,0 +1,75 @@
+package a2a_test
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestResolveAgentCard(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{Streaming: true},
+        []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    cardHandler := a2a.NewAgentCardHandler(card).WithMaxAge(0)
+
+    var fetches, notModified int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&fetches, 1)
+        rec := httptest.NewRecorder()
+        cardHandler.ServeHTTP(rec, r)
+        if rec.Code == http.StatusNotModified {
+            atomic.AddInt32(&notModified, 1)
+        }
+        for key, values := range rec.Header() {
+            w.Header()[key] = values
+        }
+        w.WriteHeader(rec.Code)
+        w.Write(rec.Body.Bytes())
+    }))
+    defer server.Close()
+
+    resolver := a2a.NewAgentCardResolver()
+    resolved, err := resolver.Resolve(context.Background(), server.URL+"/")
+    if err != nil {
+        t.Fatalf("Resolve failed: %v", err)
+    }
+    if resolved.Name != "Test" || !resolved.Capabilities.Streaming {
+        t.Errorf("Card mismatch: %+v", resolved)
+    }
+
+    // max-age=0 forces revalidation, which the unchanged card answers with 304
+    if _, err := resolver.Resolve(context.Background(), server.URL); err != nil {
+        t.Fatalf("Revalidation failed: %v", err)
+    }
+    if atomic.LoadInt32(&fetches) != 2 || atomic.LoadInt32(&notModified) != 1 {
+        t.Errorf("Expected 2 fetches and 1 revalidation, got %d and %d", fetches, notModified)
+    }
+
+    // A fresh card is served from the cache
+    cardHandler.WithMaxAge(time.Hour)
+    resolver.Invalidate(server.URL)
+    resolver.Resolve(context.Background(), server.URL)
+    resolver.Resolve(context.Background(), server.URL)
+    if atomic.LoadInt32(&fetches) != 3 {
+        t.Errorf("Expected cached card, got %d fetches", fetches)
+    }
+}
+
+func TestProtocolHandlerServesAgentCard(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{},
+        []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    server := httptest.NewServer(a2a.NewProtocolHandler(card))
+    defer server.Close()
+
+    resolved, err := a2a.NewClient().ResolveAgentCard(context.Background(), server.URL)
+    if err != nil {
+        t.Fatalf("ResolveAgentCard failed: %v", err)
+    }
+    if resolved.Skills[0].ID != "echo" {
+        t.Errorf("Skills mismatch: %+v", resolved.Skills)
+    }
+}
@@ -2,6 +2,8 @@
 // This file implements the specific error types defined in the A2A schema
 package a2a
 
+import "errors"
+
 // Error codes as defined in the A2A schema
 const (
     ErrCodeParseError                  = -32700
@@ -13,6 +15,24 @@ const (
     ErrCodeTaskNotCancelable           = -32002
     ErrCodePushNotificationNotSupported = -32003
     ErrCodeUnsupportedOperation        = -32004
+    ErrCodeUnauthenticated             = -32010
+    ErrCodePermissionDenied            = -32011
+)
+
+// Sentinel protocol errors. They match any JSONRPCError with the same code under errors.Is,
+// and handlers may return them, or errors wrapping them, to answer with that code.
+var (
+    ErrParse                = JSONParseError()
+    ErrInvalidRequest       = InvalidRequestError()
+    ErrMethodNotFound       = MethodNotFoundError()
+    ErrInvalidParams        = InvalidParamsError()
+    ErrInternal             = InternalError()
+    ErrTaskNotFound         = TaskNotFoundError()
+    ErrTaskNotCancelable    = TaskNotCancelableError()
+    ErrPushNotSupported     = PushNotificationNotSupportedError()
+    ErrUnsupportedOperation = UnsupportedOperationError()
+    ErrUnauthenticated      = UnauthenticatedError()
+    ErrPermissionDenied     = PermissionDeniedError()
 )
 
 // JSONRPCError represents a JSON-RPC 2.0 error
@@ -30,10 +50,46 @@ func NewJSONRPCError(code int, message string) *JSONRPCError {
     }
 }
 
-// WithData adds data to the error
+// Error implements the error interface so handlers can return protocol errors directly
+func (e *JSONRPCError) Error() string {
+    return e.Message
+}
+
+// Is reports whether target is a JSONRPCError with the same code
+func (e *JSONRPCError) Is(target error) bool {
+    t, ok := target.(*JSONRPCError)
+    return ok && t != nil && t.Code == e.Code
+}
+
+// WithData returns a copy of the error carrying data, leaving sentinels unchanged
 func (e *JSONRPCError) WithData(data interface{}) *JSONRPCError {
-    e.Data = data
-    return e
+    copied := *e
+    copied.Data = data
+    return &copied
+}
+
+// toJSONRPCError converts an error returned by a handler into a JSON-RPC error.
+// A JSONRPCError anywhere in the chain of wrapped errors sets the code; when it is wrapped,
+// the message of the outer error is sent as data unless the JSONRPCError carries data itself.
+func toJSONRPCError(err error) *JSONRPCError {
+    if err == nil {
+        return nil
+    }
+    var rpcErr *JSONRPCError
+    if errors.As(err, &rpcErr) {
+        if rpcErr != err && rpcErr.Data == nil {
+            return rpcErr.WithData(err.Error())
+        }
+        return rpcErr
+    }
+    var validationErrs ValidationErrors
+    switch {
+    case errors.As(err, &validationErrs):
+        return validationErrs.ToJSONRPCError()
+    case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrNoCredentials):
+        return UnauthenticatedError().WithData(err.Error())
+    }
+    return InternalError().WithData(err.Error())
 }
 
 // JSONParseError creates a JSON parse error
@@ -107,3 +163,19 @@ func UnsupportedOperationError() *JSONRPCError {
         Message: "This operation is not supported",
     }
 }
+
+// UnauthenticatedError creates an error for requests without valid credentials
+func UnauthenticatedError() *JSONRPCError {
+    return &JSONRPCError{
+        Code:    ErrCodeUnauthenticated,
+        Message: "Authentication required",
+    }
+}
+
+// PermissionDeniedError creates an error for authenticated callers that may not perform the request
+func PermissionDeniedError() *JSONRPCError {
+    return &JSONRPCError{
+        Code:    ErrCodePermissionDenied,
+        Message: "Permission denied",
+    }
+}
@@ -0,0 +1,61 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestJSONRPCErrorIs(t *testing.T) {
+    err := a2a.TaskNotFoundError().WithData("task-1")
+    if !errors.Is(err, a2a.ErrTaskNotFound) || errors.Is(err, a2a.ErrTaskNotCancelable) {
+        t.Errorf("Code matching failed for %v", err)
+    }
+    if a2a.ErrTaskNotFound.Data != nil {
+        t.Errorf("WithData modified the sentinel: %v", a2a.ErrTaskNotFound.Data)
+    }
+
+    data := []byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"Task cannot be canceled","data":"done"}}`)
+    _, parseErr := a2a.NewProtocol().ParseCancelTaskResponse(data)
+    var rpcErr *a2a.JSONRPCError
+    if !errors.Is(parseErr, a2a.ErrTaskNotCancelable) || !errors.As(parseErr, &rpcErr) || rpcErr.Data != "done" {
+        t.Errorf("Parsed error lost its code or data: %#v", parseErr)
+    }
+}
+
+func TestHandlerErrorMapping(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskGet(func(ctx context.Context, params *a2a.TaskQueryParams) (*a2a.Task, error) {
+            switch params.ID {
+            case "wrapped":
+                return nil, fmt.Errorf("loading %s: %w", params.ID, a2a.ErrTaskNotFound)
+            case "denied":
+                return nil, fmt.Errorf("checking access: %w", a2a.ErrPermissionDenied)
+            }
+            return nil, errors.New("boom")
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    tests := []struct {
+        id     string
+        target error
+        data   string
+    }{
+        {"wrapped", a2a.ErrTaskNotFound, "loading wrapped: Task not found"},
+        {"denied", a2a.ErrPermissionDenied, "checking access: Permission denied"},
+        {"other", a2a.ErrInternal, "boom"},
+    }
+    client := a2a.NewClient()
+    for _, tt := range tests {
+        _, err := client.GetTask(context.Background(), &a2a.TaskQueryParams{ID: tt.id}, server.URL)
+        var rpcErr *a2a.JSONRPCError
+        if !errors.Is(err, tt.target) || !errors.As(err, &rpcErr) || rpcErr.Data != tt.data {
+            t.Errorf("%s: expected %v with data %q, got %#v", tt.id, tt.target, tt.data, err)
+        }
+    }
+}
@@ -0,0 +1,288 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the per-task event log that lets streams resume with Last-Event-ID
+package a2a
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "strconv"
+    "sync"
+)
+
+// LastEventIDHeader carries the ID of the last stream event a client received
+const LastEventIDHeader = "Last-Event-ID"
+
+// LastEventIDMetadataKey is the key of the tasks/resubscribe metadata carrying the ID of the last
+// stream event a client received, over transports without headers
+const LastEventIDMetadataKey = "lastEventId"
+
+// Default limits of an EventLog
+const (
+    DefaultEventLogCapacity = 256
+    DefaultEventLogTasks    = 1024
+)
+
+// loggedEvent is an event retained for replay
+type loggedEvent struct {
+    id    uint64
+    event TaskEvent
+}
+
+// taskEventLog holds the retained events of one task
+type taskEventLog struct {
+    events    []loggedEvent
+    contextID string
+    // message identifies the message whose tasks/sendSubscribe stream wrote the newest event
+    message string
+    // base is the task folded from the events no longer retained, and evicted the ID of the newest of them
+    base    Task
+    evicted uint64
+    // changed is closed and replaced whenever an event is appended
+    changed chan struct{}
+    seq     uint64
+}
+
+// final reports whether the newest event of the task closes its stream
+func (t *taskEventLog) final() bool {
+    if len(t.events) == 0 {
+        return false
+    }
+    status := t.events[len(t.events)-1].event.Status
+    return status != nil && status.Final
+}
+
+// EventLog assigns increasing IDs to the events streamed for tasks and keeps the newest
+// ones of each task, so that tasks/resubscribe and reconnecting clients sending
+// Last-Event-ID receive the events they missed followed by the live ones.
+// Events older than the retained ones are summarized: a client resuming before them
+// first receives the artifacts and status they produced.
+type EventLog struct {
+    capacity int
+    maxTasks int
+
+    mu     sync.Mutex
+    nextID uint64
+    seq    uint64
+    tasks  map[string]*taskEventLog
+}
+
+// NewEventLog creates an event log with the default limits
+func NewEventLog() *EventLog {
+    return &EventLog{
+        capacity: DefaultEventLogCapacity,
+        maxTasks: DefaultEventLogTasks,
+        tasks:    make(map[string]*taskEventLog),
+    }
+}
+
+// WithCapacity sets how many events are retained per task
+func (l *EventLog) WithCapacity(capacity int) *EventLog {
+    if capacity > 0 {
+        l.capacity = capacity
+    }
+    return l
+}
+
+// WithMaxTasks sets how many tasks are retained; the least recently updated task is dropped first
+func (l *EventLog) WithMaxTasks(maxTasks int) *EventLog {
+    if maxTasks > 0 {
+        l.maxTasks = maxTasks
+    }
+    return l
+}
+
+// WithEventLog records the events of streams in the log and serves tasks/resubscribe from it
+func (h *ProtocolHandler) WithEventLog(log *EventLog) *ProtocolHandler {
+    h.events = log
+    return h
+}
+
+// Append records an event of the task it belongs to and returns the ID assigned to it
+func (l *EventLog) Append(event *TaskEvent) uint64 {
+    return l.appendEvent(event, "", "")
+}
+
+// appendEvent records an event streamed with the given context ID, in answer to the message with the given key.
+// An empty key leaves the message the task answers unchanged.
+func (l *EventLog) appendEvent(event *TaskEvent, contextID, message string) uint64 {
+    taskID := eventTaskID(event)
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    task := l.tasks[taskID]
+    if task == nil {
+        l.evictTasks()
+        task = &taskEventLog{changed: make(chan struct{})}
+        l.tasks[taskID] = task
+    }
+    if contextID != "" {
+        task.contextID = contextID
+    }
+    if message != "" {
+        task.message = message
+    }
+    l.nextID++
+    l.seq++
+    task.seq = l.seq
+    task.events = append(task.events, loggedEvent{id: l.nextID, event: *event})
+    for len(task.events) > l.capacity {
+        oldest := task.events[0]
+        // The event was accepted when it was streamed, so a rejected fold only loses its summary
+        ApplyEvent(&task.base, &oldest.event)
+        task.evicted = oldest.id
+        task.events = task.events[1:]
+    }
+    close(task.changed)
+    task.changed = make(chan struct{})
+    return l.nextID
+}
+
+// evictTasks drops the least recently updated tasks to make room for a new one
+func (l *EventLog) evictTasks() {
+    for len(l.tasks) >= l.maxTasks {
+        var oldestID string
+        var oldest *taskEventLog
+        for id, task := range l.tasks {
+            if oldest == nil || task.seq < oldest.seq {
+                oldestID, oldest = id, task
+            }
+        }
+        // Streams following the task see that it is gone
+        close(oldest.changed)
+        delete(l.tasks, oldestID)
+    }
+}
+
+// Has reports whether the log holds events of the task
+func (l *EventLog) Has(taskID string) bool {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.tasks[taskID] != nil
+}
+
+// streaming reports whether the newest events of the task were streamed in answer to the message with the given key
+func (l *EventLog) streaming(taskID, message string) bool {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    task := l.tasks[taskID]
+    return task != nil && message != "" && task.message == message
+}
+
+// answering records that the next events of the task answer the message with the given key,
+// such as when a task run by a TaskManager continues with a new message
+func (l *EventLog) answering(taskID, message string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if task := l.tasks[taskID]; task != nil {
+        task.message = message
+    }
+}
+
+// errTaskEvicted is returned by replay when the task is no longer in the log
+var errTaskEvicted = errors.New("a2a: task evicted from the event log")
+
+// messageKey identifies a message sent with tasks/sendSubscribe by a hash of its JSON
+func messageKey(message *Message) string {
+    data, err := json.Marshal(message)
+    if err != nil {
+        return ""
+    }
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])
+}
+
+// replay writes the events of the task after the given ID to the stream, then follows
+// new events until the stream is closed by a final event or ctx is done.
+// It returns errTaskEvicted once the task is no longer in the log.
+func (l *EventLog) replay(ctx context.Context, taskID string, after uint64, stream *StreamWriter) error {
+    for {
+        l.mu.Lock()
+        task := l.tasks[taskID]
+        if task == nil {
+            l.mu.Unlock()
+            return errTaskEvicted
+        }
+        var pending []loggedEvent
+        if after < task.evicted {
+            pending = summarize(taskID, &task.base)
+            after = task.evicted
+        }
+        for _, logged := range task.events {
+            if logged.id > after {
+                pending = append(pending, logged)
+            }
+        }
+        newest := task.events[len(task.events)-1].id
+        final := task.final()
+        changed := task.changed
+        contextID := task.contextID
+        l.mu.Unlock()
+
+        for _, logged := range pending {
+            event := logged.event
+            // Only the newest event may close the stream; earlier final events ended earlier rounds of the task
+            if event.Status != nil && event.Status.Final && !(final && logged.id == newest) {
+                status := *event.Status
+                status.Final = false
+                event.Status = &status
+            }
+            if err := stream.relay(&event, logged.id, contextID); err != nil {
+                return err
+            }
+            if logged.id > after {
+                after = logged.id
+            }
+        }
+        if final && after >= newest {
+            return nil
+        }
+        select {
+        case <-changed:
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+}
+
+// summarize returns events recreating the artifacts and status of a task.
+// They carry no event ID: applying them again is harmless, so a client interrupted
+// while receiving them resumes from before them.
+func summarize(taskID string, task *Task) []loggedEvent {
+    var events []loggedEvent
+    for _, artifact := range task.Artifacts {
+        events = append(events, loggedEvent{event: TaskEvent{
+            Artifact: &TaskArtifactUpdateEvent{ID: taskID, Artifact: artifact},
+        }})
+    }
+    if task.Status.State != "" {
+        status := task.Status
+        status.Message = nil
+        events = append(events, loggedEvent{event: TaskEvent{
+            Status: &TaskStatusUpdateEvent{ID: taskID, Status: status},
+        }})
+    }
+    return events
+}
+
+// eventTaskID returns the ID of the task an event belongs to
+func eventTaskID(event *TaskEvent) string {
+    if event.Status != nil {
+        return event.Status.ID
+    }
+    if event.Artifact != nil {
+        return event.Artifact.ID
+    }
+    return ""
+}
+
+// parseEventID parses a Last-Event-ID value, treating anything but a positive integer as none
+func parseEventID(value string) uint64 {
+    id, err := strconv.ParseUint(value, 10, 64)
+    if err != nil {
+        return 0
+    }
+    return id
+}
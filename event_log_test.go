@@ -0,0 +1,212 @@
+package a2a_test
+
+import (
+    "context"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+// collectEvents receives the events of a stream until it ends
+func collectEvents(t *testing.T, stream *a2a.TaskEventStream) []*a2a.TaskEvent {
+    t.Helper()
+    var events []*a2a.TaskEvent
+    for {
+        event, err := stream.Recv()
+        if err == io.EOF {
+            return events
+        }
+        if err != nil {
+            t.Fatalf("Recv failed: %v", err)
+        }
+        events = append(events, event)
+    }
+}
+
+func TestEventLogResumesDroppedStream(t *testing.T) {
+    log := a2a.NewEventLog()
+    handler := a2a.NewProtocolHandler(nil).
+        WithEventLog(log).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            stream.WriteArtifactUpdate(a2a.TaskArtifactUpdateEvent{ID: params.ID, Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("out")})})
+            // The task completes while the connection is lost
+            log.Append(&a2a.TaskEvent{Status: &a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true}})
+            panic(http.ErrAbortHandler)
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    client := a2a.NewClient().WithStreamReconnect(3, time.Millisecond)
+    stream, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+
+    events := collectEvents(t, stream)
+    if len(events) != 3 {
+        t.Fatalf("Event count mismatch: expected %d, got %d", 3, len(events))
+    }
+    for i, event := range events {
+        if want := strconv.Itoa(i + 1); event.EventID != want {
+            t.Errorf("Event %d ID mismatch: expected %s, got %q", i, want, event.EventID)
+        }
+    }
+    task := stream.Task()
+    if task.Status.State != a2a.TaskStateCompleted || len(task.Artifacts) != 1 {
+        t.Errorf("Unexpected task after resuming: %#v", task)
+    }
+}
+
+func TestEventLogReplaysEvictedEvents(t *testing.T) {
+    log := a2a.NewEventLog().WithCapacity(2)
+    for _, event := range []*a2a.TaskEvent{
+        {Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}},
+        {Artifact: &a2a.TaskArtifactUpdateEvent{ID: "task-1", Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("a")})}},
+        {Artifact: &a2a.TaskArtifactUpdateEvent{ID: "task-1", Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("b")}).WithIndex(1)}},
+        {Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}},
+        {Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true}},
+    } {
+        log.Append(event)
+    }
+    server := httptest.NewServer(a2a.NewProtocolHandler(nil).WithEventLog(log))
+    defer server.Close()
+
+    // From the start: the evicted events are summarized without IDs
+    stream, err := a2a.NewClient().ResubscribeTask(context.Background(), &a2a.TaskQueryParams{ID: "task-1"}, server.URL)
+    if err != nil {
+        t.Fatalf("ResubscribeTask failed: %v", err)
+    }
+    defer stream.Close()
+    events := collectEvents(t, stream)
+    if len(events) != 5 {
+        t.Fatalf("Event count mismatch: expected %d, got %d", 5, len(events))
+    }
+    if events[0].EventID != "" || events[3].EventID != "4" || events[4].EventID != "5" {
+        t.Errorf("Unexpected event IDs: %q, %q, %q", events[0].EventID, events[3].EventID, events[4].EventID)
+    }
+    task := stream.Task()
+    if task.Status.State != a2a.TaskStateCompleted || len(task.Artifacts) != 2 {
+        t.Errorf("Unexpected replayed task: %#v", task)
+    }
+
+    // After a known event: only the newer ones
+    client := a2a.NewClient().WithHeader(a2a.LastEventIDHeader, "4")
+    stream, err = client.ResubscribeTask(context.Background(), &a2a.TaskQueryParams{ID: "task-1"}, server.URL)
+    if err != nil {
+        t.Fatalf("ResubscribeTask failed: %v", err)
+    }
+    defer stream.Close()
+    events = collectEvents(t, stream)
+    if len(events) != 1 || events[0].EventID != "5" || !events[0].Status.Final {
+        t.Errorf("Unexpected events after Last-Event-ID: %#v", events)
+    }
+}
+
+func TestEventLogSendSubscribeWithStaleLastEventID(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        WithEventLog(a2a.NewEventLog()).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateInputRequired}, Final: true})
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    send := func(client *a2a.Client, text string) (*a2a.TaskEventStream, error) {
+        return client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+            ID:      "task-1",
+            Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart(text)}),
+        }, server.URL)
+    }
+    stream, err := send(a2a.NewClient(), "first")
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    collectEvents(t, stream)
+    stream.Close()
+
+    // The next turn sent with a leftover Last-Event-ID is refused rather than replaced by a replay
+    resuming := a2a.NewClient().WithHeader(a2a.LastEventIDHeader, "1")
+    if stream, err = send(resuming, "second"); err == nil {
+        events := collectEvents(t, stream)
+        stream.Close()
+        t.Fatalf("Expected the new message to be refused, got %d events", len(events))
+    }
+
+    // Sending the same message again resumes its stream
+    stream, err = send(resuming, "first")
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+    if events := collectEvents(t, stream); len(events) != 0 {
+        t.Errorf("Expected no events after Last-Event-ID, got %d", len(events))
+    }
+}
+
+func TestEventLogResumesTaskManagerStream(t *testing.T) {
+    manager := a2a.NewTaskManager(nil, func(ctx context.Context, run *a2a.TaskRun) error {
+        return run.AddArtifact(ctx, *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("done")}))
+    })
+    defer manager.Shutdown(context.Background())
+    server := httptest.NewServer(a2a.NewProtocolHandler(nil).WithEventLog(a2a.NewEventLog()).WithTaskManager(manager))
+    defer server.Close()
+
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")})}
+    if _, err := a2a.NewClient().SendTask(context.Background(), params, server.URL); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+
+    // The message the worker answers resumes its events rather than being refused as a new one
+    stream, err := a2a.NewClient().WithHeader(a2a.LastEventIDHeader, "1").SendTaskSubscribe(context.Background(), params, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+    collectEvents(t, stream)
+    if task := stream.Task(); task.Status.State != a2a.TaskStateCompleted || len(task.Artifacts) != 1 {
+        t.Errorf("Unexpected task after resuming: %+v", task)
+    }
+}
+
+func TestEventLogReplayOfEvictedTask(t *testing.T) {
+    store := a2a.NewMemoryTaskStore()
+    if _, err := store.Create(context.Background(), a2a.NewTask("task-1", a2a.TaskStateWorking)); err != nil {
+        t.Fatalf("Create failed: %v", err)
+    }
+    log := a2a.NewEventLog().WithMaxTasks(1)
+    log.Append(&a2a.TaskEvent{Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}})
+    server := httptest.NewServer(a2a.NewProtocolHandler(nil).WithTaskStore(store).WithEventLog(log))
+    defer server.Close()
+
+    stream, err := a2a.NewClient().ResubscribeTask(context.Background(), &a2a.TaskQueryParams{ID: "task-1"}, server.URL)
+    if err != nil {
+        t.Fatalf("ResubscribeTask failed: %v", err)
+    }
+    defer stream.Close()
+    if _, err := stream.Recv(); err != nil {
+        t.Fatalf("Recv failed: %v", err)
+    }
+
+    // The task completes and leaves the log while the stream follows it
+    task, version, _ := store.Get(context.Background(), "task-1")
+    task.Status.State = a2a.TaskStateCompleted
+    if _, err := store.Update(context.Background(), task, version); err != nil {
+        t.Fatalf("Update failed: %v", err)
+    }
+    log.Append(&a2a.TaskEvent{Status: &a2a.TaskStatusUpdateEvent{ID: "task-2", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}})
+
+    events := collectEvents(t, stream)
+    if len(events) != 1 || events[0].Status == nil || events[0].Status.Status.State != a2a.TaskStateCompleted || !events[0].Status.Final {
+        t.Errorf("Expected the stream to end with the stored task, got %+v", events)
+    }
+}
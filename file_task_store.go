@@ -0,0 +1,298 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements a durable task store backed by an append-only log and snapshots
+package a2a
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+const (
+    fileTaskStoreLog      = "tasks.log"
+    fileTaskStoreSnapshot = "tasks.snapshot"
+
+    // defaultCompactEvery is the number of log records after which a snapshot is written
+    defaultCompactEvery = 1000
+)
+
+// taskLogRecord is a single mutation in the task log
+type taskLogRecord struct {
+    Op      string `json:"op"`
+    ID      string `json:"id"`
+    Version int64  `json:"version,omitempty"`
+    Task    *Task  `json:"task,omitempty"`
+}
+
+const (
+    taskLogPut    = "put"
+    taskLogDelete = "delete"
+)
+
+// FileTaskStore is a TaskStore persisted in a directory on the local filesystem.
+// Every mutation is appended and synced to a log before it is applied, and the
+// log is periodically compacted into a snapshot, so tasks survive process restarts.
+type FileTaskStore struct {
+    mu           sync.Mutex
+    dir          string
+    memory       *MemoryTaskStore
+    log          *os.File
+    records      int
+    compactEvery int
+    onCompactErr func(err error)
+}
+
+// NewFileTaskStore opens the task store in dir, creating it if needed, and
+// restores the tasks from the last snapshot and the log
+func NewFileTaskStore(dir string) (*FileTaskStore, error) {
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return nil, err
+    }
+    s := &FileTaskStore{
+        dir:          dir,
+        memory:       NewMemoryTaskStore(),
+        compactEvery: defaultCompactEvery,
+    }
+    if err := s.loadSnapshot(); err != nil {
+        return nil, err
+    }
+    if err := s.replayLog(); err != nil {
+        return nil, err
+    }
+
+    log, err := os.OpenFile(filepath.Join(dir, fileTaskStoreLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+    if err != nil {
+        return nil, err
+    }
+    s.log = log
+    return s, nil
+}
+
+// WithCompactEvery sets the number of log records after which the log is compacted into a snapshot
+func (s *FileTaskStore) WithCompactEvery(records int) *FileTaskStore {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.compactEvery = records
+    return s
+}
+
+// OnCompactError sets the function called when the log cannot be compacted into a snapshot.
+// The write that triggered the compaction is durable regardless, and compaction is attempted
+// again on the next write. By default the failure is written to the standard logger.
+func (s *FileTaskStore) OnCompactError(fn func(err error)) *FileTaskStore {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.onCompactErr = fn
+    return s
+}
+
+// Get implements TaskStore
+func (s *FileTaskStore) Get(ctx context.Context, id string) (*Task, int64, error) {
+    return s.memory.Get(ctx, id)
+}
+
+// List implements TaskStore
+func (s *FileTaskStore) List(ctx context.Context) ([]*Task, error) {
+    return s.memory.List(ctx)
+}
+
+// Create implements TaskStore
+func (s *FileTaskStore) Create(ctx context.Context, task *Task) (int64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.memory.version(task.ID) != 0 {
+        return 0, ErrTaskExists
+    }
+    return s.put(task, 1)
+}
+
+// Update implements TaskStore
+func (s *FileTaskStore) Update(ctx context.Context, task *Task, version int64) (int64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    current := s.memory.version(task.ID)
+    if current == 0 {
+        return 0, ErrTaskNotFound
+    }
+    if current != version {
+        return 0, ErrVersionConflict
+    }
+    return s.put(task, version+1)
+}
+
+// Delete implements TaskStore
+func (s *FileTaskStore) Delete(ctx context.Context, id string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.memory.version(id) == 0 {
+        return ErrTaskNotFound
+    }
+    if err := s.append(taskLogRecord{Op: taskLogDelete, ID: id}); err != nil {
+        return err
+    }
+    s.memory.remove(id)
+    s.maybeCompact()
+    return nil
+}
+
+// Close closes the log file
+func (s *FileTaskStore) Close() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.log.Close()
+}
+
+// put logs and applies a new version of a task
+func (s *FileTaskStore) put(task *Task, version int64) (int64, error) {
+    stored, err := cloneTask(task)
+    if err != nil {
+        return 0, err
+    }
+    if err := s.append(taskLogRecord{Op: taskLogPut, ID: task.ID, Version: version, Task: stored}); err != nil {
+        return 0, err
+    }
+    s.memory.put(stored, version)
+    s.maybeCompact()
+    return version, nil
+}
+
+// append writes a record to the log and syncs it to disk.
+// A record that cannot be written and synced is cut from the log again, so the log
+// holds only the mutations that were applied.
+func (s *FileTaskStore) append(record taskLogRecord) error {
+    data, err := json.Marshal(record)
+    if err != nil {
+        return err
+    }
+    info, err := s.log.Stat()
+    if err != nil {
+        return err
+    }
+    if _, err := s.log.Write(append(data, '\n')); err != nil {
+        s.log.Truncate(info.Size())
+        return err
+    }
+    if err := s.log.Sync(); err != nil {
+        s.log.Truncate(info.Size())
+        return err
+    }
+    s.records++
+    return nil
+}
+
+// maybeCompact writes a snapshot and truncates the log once enough records have accumulated.
+// A failure is reported to the OnCompactError function rather than failing the write.
+func (s *FileTaskStore) maybeCompact() {
+    if s.compactEvery <= 0 || s.records < s.compactEvery {
+        return
+    }
+    if err := s.compact(); err != nil {
+        if s.onCompactErr != nil {
+            s.onCompactErr(err)
+            return
+        }
+        log.Printf("a2a: compacting the task log in %s: %v", s.dir, err)
+    }
+}
+
+// compact writes a snapshot of all tasks and truncates the log
+func (s *FileTaskStore) compact() error {
+    data, err := json.Marshal(s.memory.snapshot())
+    if err != nil {
+        return err
+    }
+    if err := writeFileAtomic(filepath.Join(s.dir, fileTaskStoreSnapshot), data); err != nil {
+        return err
+    }
+    if err := s.log.Truncate(0); err != nil {
+        return err
+    }
+    s.records = 0
+    return s.log.Sync()
+}
+
+// loadSnapshot restores the tasks of the last snapshot
+func (s *FileTaskStore) loadSnapshot() error {
+    data, err := os.ReadFile(filepath.Join(s.dir, fileTaskStoreSnapshot))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    var tasks map[string]storedTask
+    if err := json.Unmarshal(data, &tasks); err != nil {
+        return err
+    }
+    for _, stored := range tasks {
+        s.memory.put(stored.Task, stored.Version)
+    }
+    return nil
+}
+
+// replayLog applies the records logged after the last snapshot.
+// A torn record at the end of the log, left by a crash during a write, is truncated.
+func (s *FileTaskStore) replayLog() error {
+    file, err := os.OpenFile(filepath.Join(s.dir, fileTaskStoreLog), os.O_RDWR, 0)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    reader := bufio.NewReader(file)
+    var offset int64
+    for {
+        line, err := reader.ReadBytes('\n')
+        if err == io.EOF {
+            if len(line) > 0 {
+                return file.Truncate(offset)
+            }
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        var record taskLogRecord
+        if err := json.Unmarshal(line, &record); err != nil {
+            return err
+        }
+        switch record.Op {
+        case taskLogPut:
+            s.memory.put(record.Task, record.Version)
+        case taskLogDelete:
+            s.memory.remove(record.ID)
+        }
+        offset += int64(len(line))
+        s.records++
+    }
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+    tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
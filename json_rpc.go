@@ -21,7 +21,8 @@ func NewJSONRPCMessage(id interface{}) *JSONRPCMessage {
     }
 }
 
-// JSONRPCRequest represents a JSON-RPC 2.0 request
+// JSONRPCRequest represents a JSON-RPC 2.0 request.
+// A request without ID is a notification, which the server answers without a response.
 type JSONRPCRequest struct {
     JSONRPC string      `json:"jsonrpc"`
     ID      interface{} `json:"id,omitempty"`
@@ -54,10 +55,11 @@ func RequestFromJSON(data []byte) (*JSONRPCRequest, error) {
     return &request, nil
 }
 
-// JSONRPCResponse represents a JSON-RPC 2.0 response
+// JSONRPCResponse represents a JSON-RPC 2.0 response.
+// The ID is always sent, as null when the request ID could not be determined.
 type JSONRPCResponse struct {
     JSONRPC string       `json:"jsonrpc"`
-    ID      interface{}  `json:"id,omitempty"`
+    ID      interface{}  `json:"id"`
     Result  interface{}  `json:"result,omitempty"`
     Error   *JSONRPCError `json:"error,omitempty"`
 }
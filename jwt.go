@@ -0,0 +1,167 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the compact JSON Web Tokens used to sign push notifications
+package a2a
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "strings"
+    "time"
+)
+
+// ErrInvalidJWT is returned when a JSON Web Token is malformed or its signature does not verify
+var ErrInvalidJWT = errors.New("a2a: invalid JWT")
+
+// jwtHeader is the JOSE header of a token
+type jwtHeader struct {
+    Alg string `json:"alg"`
+    Typ string `json:"typ,omitempty"`
+}
+
+// jwtAlgorithm returns the signing algorithm matching a key.
+// HMAC secrets are []byte, RSA and ECDSA keys may be private or public.
+func jwtAlgorithm(key interface{}) (string, error) {
+    switch k := key.(type) {
+    case []byte:
+        return "HS256", nil
+    case *rsa.PrivateKey, *rsa.PublicKey:
+        return "RS256", nil
+    case *ecdsa.PrivateKey:
+        return ecdsaAlgorithm(k.Curve.Params().BitSize)
+    case *ecdsa.PublicKey:
+        return ecdsaAlgorithm(k.Curve.Params().BitSize)
+    default:
+        return "", fmt.Errorf("a2a: unsupported JWT key type %T", key)
+    }
+}
+
+// ecdsaAlgorithm returns the ECDSA algorithm for a curve size
+func ecdsaAlgorithm(bitSize int) (string, error) {
+    if bitSize != 256 {
+        return "", fmt.Errorf("a2a: unsupported ECDSA curve size %d", bitSize)
+    }
+    return "ES256", nil
+}
+
+// signJWT encodes the claims as a token signed with key
+func signJWT(claims map[string]interface{}, key interface{}) (string, error) {
+    alg, err := jwtAlgorithm(key)
+    if err != nil {
+        return "", err
+    }
+    header, err := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT"})
+    if err != nil {
+        return "", err
+    }
+    payload, err := json.Marshal(claims)
+    if err != nil {
+        return "", err
+    }
+    signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+    digest := sha256.Sum256([]byte(signingInput))
+
+    var signature []byte
+    switch k := key.(type) {
+    case []byte:
+        mac := hmac.New(sha256.New, k)
+        mac.Write([]byte(signingInput))
+        signature = mac.Sum(nil)
+    case *rsa.PrivateKey:
+        signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+    case *ecdsa.PrivateKey:
+        var r, s *big.Int
+        r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+        if err == nil {
+            signature = make([]byte, 64)
+            r.FillBytes(signature[:32])
+            s.FillBytes(signature[32:])
+        }
+    default:
+        err = fmt.Errorf("a2a: cannot sign JWT with %T", key)
+    }
+    if err != nil {
+        return "", err
+    }
+    return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verifyJWT checks the signature and expiry of a token and returns its claims.
+// The algorithm is taken from the key rather than from the token header.
+func verifyJWT(token string, key interface{}) (map[string]interface{}, error) {
+    alg, err := jwtAlgorithm(key)
+    if err != nil {
+        return nil, err
+    }
+    segments := strings.Split(token, ".")
+    if len(segments) != 3 {
+        return nil, fmt.Errorf("%w: expected 3 segments", ErrInvalidJWT)
+    }
+    headerData, err := base64.RawURLEncoding.DecodeString(segments[0])
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+    }
+    var header jwtHeader
+    if err := json.Unmarshal(headerData, &header); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+    }
+    if header.Alg != alg {
+        return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidJWT, header.Alg)
+    }
+    signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+    }
+
+    signingInput := segments[0] + "." + segments[1]
+    digest := sha256.Sum256([]byte(signingInput))
+    valid := false
+    switch k := key.(type) {
+    case []byte:
+        mac := hmac.New(sha256.New, k)
+        mac.Write([]byte(signingInput))
+        valid = hmac.Equal(signature, mac.Sum(nil))
+    case *rsa.PrivateKey:
+        valid = rsa.VerifyPKCS1v15(&k.PublicKey, crypto.SHA256, digest[:], signature) == nil
+    case *rsa.PublicKey:
+        valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+    case *ecdsa.PrivateKey:
+        valid = verifyES256(&k.PublicKey, digest[:], signature)
+    case *ecdsa.PublicKey:
+        valid = verifyES256(k, digest[:], signature)
+    }
+    if !valid {
+        return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidJWT)
+    }
+
+    payload, err := base64.RawURLEncoding.DecodeString(segments[1])
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+    }
+    var claims map[string]interface{}
+    if err := json.Unmarshal(payload, &claims); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+    }
+    if exp, ok := claims["exp"].(float64); ok && time.Now().After(time.Unix(int64(exp), 0)) {
+        return nil, fmt.Errorf("%w: token expired", ErrInvalidJWT)
+    }
+    return claims, nil
+}
+
+// verifyES256 checks a raw r||s ECDSA signature
+func verifyES256(key *ecdsa.PublicKey, digest, signature []byte) bool {
+    if len(signature) != 64 {
+        return false
+    }
+    r := new(big.Int).SetBytes(signature[:32])
+    s := new(big.Int).SetBytes(signature[32:])
+    return ecdsa.Verify(key, digest, r, s)
+}
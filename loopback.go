@@ -0,0 +1,324 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the in-process loopback transport connecting a client to a handler
+package a2a
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "strconv"
+    "sync"
+)
+
+// LoopbackTransport is a Transport connecting a Client to a ProtocolHandler in the same process.
+// Requests go through the same decoding, validation and dispatch as over HTTP, without the
+// network round trip; they run with the context of the caller, which also carries the principal
+// in place of HTTP authentication. With zero copy enabled, the client hands tasks and messages
+// over as deep copies instead of serializing them to JSON.
+type LoopbackTransport struct {
+    handler  *ProtocolHandler
+    version  ProtocolVersion
+    zeroCopy bool
+
+    // ctx is canceled by Close, ending the requests in progress
+    ctx    context.Context
+    cancel context.CancelFunc
+}
+
+// NewLoopbackTransport creates a transport serving requests with the handler
+func NewLoopbackTransport(handler *ProtocolHandler) *LoopbackTransport {
+    ctx, cancel := context.WithCancel(context.Background())
+    return &LoopbackTransport{handler: handler, ctx: ctx, cancel: cancel}
+}
+
+// WithProtocolVersion sets the protocol version requested for the methods both schemas share,
+// as the A2A-Version header does over HTTP
+func (t *LoopbackTransport) WithProtocolVersion(version ProtocolVersion) *LoopbackTransport {
+    t.version = version
+    return t
+}
+
+// WithZeroCopy sets whether tasks/send, tasks/get, tasks/cancel and the streaming methods skip
+// JSON. Params, results and stream events are deep-copied instead, so neither side sees the
+// changes the other makes afterwards.
+func (t *LoopbackTransport) WithZeroCopy(enabled bool) *LoopbackTransport {
+    t.zeroCopy = enabled
+    return t
+}
+
+// Call implements Transport
+func (t *LoopbackTransport) Call(ctx context.Context, request []byte) ([]byte, error) {
+    ctx, cancel, err := t.bind(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer cancel()
+
+    var response interface{}
+    if isBatch(request) {
+        response = t.handler.processBatch(ctx, request, t.version)
+    } else {
+        var rpc rpcRequest
+        if err := json.Unmarshal(request, &rpc); err != nil {
+            response = NewJSONRPCErrorResponse(nil, JSONParseError())
+        } else {
+            rpc.version = t.handler.requestVersion(rpc.Method, t.version)
+            if answer := t.handler.handleRequest(ctx, &rpc); !rpc.isNotification() {
+                response = answer
+            }
+        }
+    }
+    if ctx.Err() != nil {
+        return nil, t.contextErr(ctx)
+    }
+    if response == nil {
+        return nil, nil
+    }
+    return json.Marshal(response)
+}
+
+// Stream implements Transport
+func (t *LoopbackTransport) Stream(ctx context.Context, request []byte) (EventReader, error) {
+    var rpc rpcRequest
+    if err := json.Unmarshal(request, &rpc); err != nil {
+        return nil, JSONParseError()
+    }
+    if rpc.JSONRPC != JSONRPCVersion {
+        return nil, InvalidRequestError()
+    }
+    rpc.version = t.handler.requestVersion(rpc.Method, t.version)
+    return t.stream(ctx, &rpc, false)
+}
+
+// Close implements Transport, canceling the requests and streams in progress
+func (t *LoopbackTransport) Close() error {
+    t.cancel()
+    return nil
+}
+
+// bind derives the context of a request, canceled when the caller's context is done or the transport is closed
+func (t *LoopbackTransport) bind(ctx context.Context) (context.Context, context.CancelFunc, error) {
+    if t.ctx.Err() != nil {
+        return nil, nil, ErrTransportClosed
+    }
+    ctx, cancel := context.WithCancel(ctx)
+    stop := context.AfterFunc(t.ctx, cancel)
+    return ctx, func() {
+        stop()
+        cancel()
+    }, nil
+}
+
+// contextErr returns the error of a request whose context is done
+func (t *LoopbackTransport) contextErr(ctx context.Context) error {
+    if t.ctx.Err() != nil {
+        return ErrTransportClosed
+    }
+    return ctx.Err()
+}
+
+// zeroCopyTransport returns the transport for the agent at url if it hands requests over as values
+func (c *Client) zeroCopyTransport(ctx context.Context, url string) *LoopbackTransport {
+    loopback, ok := c.transportFor(ctx, url).(*LoopbackTransport)
+    if !ok || !loopback.zeroCopy {
+        return nil
+    }
+    return loopback
+}
+
+// callTask dispatches a request whose params are a Go value and returns a copy of the task answering it
+func (t *LoopbackTransport) callTask(ctx context.Context, method string, params interface{}) (*Task, error) {
+    ctx, cancel, err := t.bind(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer cancel()
+
+    result, rpcErr := t.handler.dispatch(ctx, &rpcRequest{
+        JSONRPC: JSONRPCVersion,
+        Method:  method,
+        version: ProtocolVersionLegacy,
+        value:   params,
+    })
+    if ctx.Err() != nil {
+        return nil, t.contextErr(ctx)
+    }
+    if rpcErr != nil {
+        return nil, rpcErr
+    }
+    task, _ := result.(*Task)
+    if task == nil {
+        return nil, errNoResult
+    }
+    return task.Clone(), nil
+}
+
+// streamTask starts a streaming request whose params are a Go value.
+// The events of the returned reader are copies delivered without JSON.
+func (t *LoopbackTransport) streamTask(ctx context.Context, method string, params interface{}) (EventReader, error) {
+    return t.stream(ctx, &rpcRequest{
+        JSONRPC: JSONRPCVersion,
+        Method:  method,
+        version: ProtocolVersionLegacy,
+        value:   params,
+    }, true)
+}
+
+// stream runs a streaming request in the background, delivering its events to the returned reader
+func (t *LoopbackTransport) stream(ctx context.Context, request *rpcRequest, zeroCopy bool) (EventReader, error) {
+    ctx, cancel, err := t.bind(ctx)
+    if err != nil {
+        return nil, err
+    }
+    run, rpcErr := t.handler.prepareStream(request)
+    if rpcErr != nil {
+        cancel()
+        return nil, rpcErr
+    }
+
+    events := make(chan loopbackEvent, muxStreamBuffer)
+    reader := &loopbackStream{transport: t, ctx: ctx, cancel: cancel, events: events, done: make(chan struct{})}
+    stream := t.handler.newStream(request, &loopbackSink{ctx: ctx, events: events, zeroCopy: zeroCopy})
+    go func() {
+        defer close(events)
+        runStream(ctx, run, stream)
+    }()
+    if zeroCopy {
+        return zeroCopyStream{reader}, nil
+    }
+    return reader, nil
+}
+
+// loopbackEvent is one response of a loopback stream
+type loopbackEvent struct {
+    id string
+    // data is the JSON of the response; in zero copy mode, response is a copy of it instead
+    data     []byte
+    response *SendTaskStreamingResponse
+}
+
+// loopbackSink is the eventSink of a loopback stream.
+// It copies each response when written, so later changes by the handler are not seen by the client.
+type loopbackSink struct {
+    ctx      context.Context
+    events   chan<- loopbackEvent
+    zeroCopy bool
+}
+
+// writeEvent implements eventSink, blocking while the reader is behind
+func (s *loopbackSink) writeEvent(response *SendTaskStreamingResponse, eventID uint64) error {
+    var event loopbackEvent
+    if eventID != 0 {
+        event.id = strconv.FormatUint(eventID, 10)
+    }
+    if s.zeroCopy {
+        clone := *response
+        if taskEvent := copyTaskEvent(response.Result); taskEvent != nil {
+            clone.Result = taskEvent
+        }
+        event.response = &clone
+    } else {
+        data, err := json.Marshal(response)
+        if err != nil {
+            return err
+        }
+        event.data = data
+    }
+    select {
+    case s.events <- event:
+        return nil
+    case <-s.ctx.Done():
+        return s.ctx.Err()
+    }
+}
+
+// taskEventReader is implemented by event readers delivering decoded task events
+type taskEventReader interface {
+    // nextEvent returns the ID and the next event, which is nil for responses carrying none
+    nextEvent() (string, *TaskEvent, error)
+}
+
+// loopbackStream is the EventReader of a loopback stream
+type loopbackStream struct {
+    transport *LoopbackTransport
+    ctx       context.Context
+    cancel    context.CancelFunc
+    events    <-chan loopbackEvent
+
+    // done is closed by Close
+    closeOnce sync.Once
+    done      chan struct{}
+}
+
+// Next implements EventReader
+func (s *loopbackStream) Next() (string, []byte, error) {
+    event, err := s.next()
+    if err != nil {
+        return "", nil, err
+    }
+    return event.id, event.data, nil
+}
+
+// next returns the next response of the stream
+func (s *loopbackStream) next() (loopbackEvent, error) {
+    select {
+    case event, ok := <-s.events:
+        if !ok {
+            return loopbackEvent{}, io.EOF
+        }
+        return event, nil
+    case <-s.done:
+        return loopbackEvent{}, io.EOF
+    case <-s.ctx.Done():
+        select {
+        case <-s.done:
+            return loopbackEvent{}, io.EOF
+        default:
+            return loopbackEvent{}, s.transport.contextErr(s.ctx)
+        }
+    }
+}
+
+// Close implements EventReader, canceling the handler if the stream has not ended
+func (s *loopbackStream) Close() error {
+    s.closeOnce.Do(func() {
+        close(s.done)
+        s.cancel()
+    })
+    return nil
+}
+
+// zeroCopyStream is the EventReader of a loopback stream whose responses are copied rather than encoded
+type zeroCopyStream struct {
+    *loopbackStream
+}
+
+// Next implements EventReader, encoding the copied response for readers asking for JSON
+func (s zeroCopyStream) Next() (string, []byte, error) {
+    event, err := s.next()
+    if err != nil {
+        return "", nil, err
+    }
+    response := *event.response
+    if taskEvent, ok := response.Result.(*TaskEvent); ok {
+        response.Result = taskEvent.Artifact
+        if taskEvent.Status != nil {
+            response.Result = taskEvent.Status
+        }
+    }
+    data, err := json.Marshal(&response)
+    return event.id, data, err
+}
+
+// nextEvent implements taskEventReader
+func (s zeroCopyStream) nextEvent() (string, *TaskEvent, error) {
+    event, err := s.next()
+    if err != nil {
+        return "", nil, err
+    }
+    if event.response.Error != nil {
+        return "", nil, event.response.Error
+    }
+    taskEvent, _ := event.response.Result.(*TaskEvent)
+    return event.id, taskEvent, nil
+}
@@ -0,0 +1,100 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestLoopbackTransport(t *testing.T) {
+    exerciseTransport(t, a2a.NewLoopbackTransport(newTransportAgent()))
+}
+
+func TestLoopbackZeroCopyTransport(t *testing.T) {
+    exerciseTransport(t, a2a.NewLoopbackTransport(newTransportAgent()).WithZeroCopy(true))
+}
+
+func TestLoopbackZeroCopyIsolation(t *testing.T) {
+    var received *a2a.TaskSendParams
+    var returned *a2a.Task
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            received = params
+            returned = a2a.NewTask(params.ID, a2a.TaskStateCompleted)
+            returned.History = []a2a.Message{params.Message}
+            return returned, nil
+        })
+    client := a2a.NewClient().WithTransport("local://agent", a2a.NewLoopbackTransport(handler).WithZeroCopy(true))
+
+    data := map[string]interface{}{"items": []interface{}{"a"}}
+    params := &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewDataPart(data)}),
+    }
+    task, err := client.SendTask(context.Background(), params, "local://agent")
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task == returned {
+        t.Fatal("Expected a copy of the task returned by the handler")
+    }
+
+    // Changes on either side stay on that side
+    data["items"].([]interface{})[0] = "changed by client"
+    task.History[0].Parts[0].(a2a.DataPart).Data["items"].([]interface{})[0] = "changed in result"
+    returned.Status.State = a2a.TaskStateFailed
+    if item := received.Message.Parts[0].(a2a.DataPart).Data["items"].([]interface{})[0]; item != "a" {
+        t.Errorf("Handler params changed: got %v", item)
+    }
+    if item := returned.History[0].Parts[0].(a2a.DataPart).Data["items"].([]interface{})[0]; item != "a" {
+        t.Errorf("Handler task changed: got %v", item)
+    }
+    if task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("Result changed: got %s", task.Status.State)
+    }
+
+    // Params are validated as they are over the wire
+    _, err = client.SendTask(context.Background(), &a2a.TaskSendParams{Message: params.Message}, "local://agent")
+    var rpcErr *a2a.JSONRPCError
+    if !errors.As(err, &rpcErr) || rpcErr.Code != a2a.ErrCodeInvalidParams {
+        t.Errorf("Expected invalid params, got %v", err)
+    }
+}
+
+func TestLoopbackCancellation(t *testing.T) {
+    canceled := make(chan error, 1)
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            <-ctx.Done()
+            canceled <- ctx.Err()
+            return ctx.Err()
+        })
+
+    for _, zeroCopy := range []bool{false, true} {
+        transport := a2a.NewLoopbackTransport(handler).WithZeroCopy(zeroCopy)
+        client := a2a.NewClient().WithTransport("local://agent", transport)
+        params := &a2a.TaskSendParams{
+            ID:      "task-1",
+            Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+        }
+        stream, err := client.SendTaskSubscribe(context.Background(), params, "local://agent")
+        if err != nil {
+            t.Fatalf("SendTaskSubscribe failed: %v", err)
+        }
+        if event, err := stream.Recv(); err != nil || event.Status == nil || event.Status.Status.State != a2a.TaskStateWorking {
+            t.Fatalf("Unexpected first event: %#v, %v", event, err)
+        }
+        stream.Close()
+        if err := <-canceled; !errors.Is(err, context.Canceled) {
+            t.Errorf("Expected the handler to be canceled, got %v", err)
+        }
+
+        transport.Close()
+        if _, err := client.SendTask(context.Background(), params, "local://agent"); !errors.Is(err, a2a.ErrTransportClosed) {
+            t.Errorf("Expected transport closed, got %v", err)
+        }
+    }
+}
@@ -2,10 +2,7 @@
 // This file defines message structures used for communication between agents according to the A2A protocol
 package a2a
 
-import (
-    "encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 // MessageRole defines the sender role
 type MessageRole string
@@ -22,10 +19,6 @@ type Message struct {
     Metadata map[string]interface{}  `json:"metadata,omitempty"`
 }
 
-type rawPart struct {
-    Type string `json:"type"`
-}
-
 // NewMessage creates a new message
 func NewMessage(role MessageRole, parts []Part) *Message {
     return &Message{
@@ -51,58 +44,30 @@ func (m *Message) MarshalJSON() ([]byte, error) {
     return json.Marshal((*MessageAlias)(m))
 }
 
-// MessageFromJSON parses JSON into a message
-func MessageFromJSON(data []byte) (*Message, error) {
+// UnmarshalJSON decodes a message, resolving each part to its concrete type
+func (m *Message) UnmarshalJSON(data []byte) error {
+    type MessageAlias Message
     var message struct {
-        Role     MessageRole             `json:"role"`
-        Parts    []json.RawMessage       `json:"parts"`
-        Metadata map[string]interface{}  `json:"metadata,omitempty"`
+        *MessageAlias
+        Parts []json.RawMessage `json:"parts"`
     }
-    
+    message.MessageAlias = (*MessageAlias)(m)
+
     err := json.Unmarshal(data, &message)
     if err != nil {
-        return nil, err
-    }
-    
-    result := &Message{
-        Role:     message.Role,
-        Metadata: message.Metadata,
-        Parts:    make([]Part, 0, len(message.Parts)),
+        return err
     }
-    
-    for _, rawPartData := range message.Parts {
-        // First determine the type
-        var r rawPart
-        err := json.Unmarshal(rawPartData, &r)
-        if err != nil {
-            return nil, err
-        }
-        
-        // Then unmarshal to the appropriate concrete type
-        var part Part
-        switch r.Type {
-        case "text":
-            var textPart TextPart
-            err = json.Unmarshal(rawPartData, &textPart)
-            part = textPart
-        case "file":
-            var filePart FilePart
-            err = json.Unmarshal(rawPartData, &filePart)
-            part = filePart
-        case "data":
-            var dataPart DataPart
-            err = json.Unmarshal(rawPartData, &dataPart)
-            part = dataPart
-        default:
-            return nil, fmt.Errorf("unknown part type: %s", r.Type)
-        }
-        
-        if err != nil {
-            return nil, err
-        }
-        
-        result.Parts = append(result.Parts, part)
+
+    m.Parts, err = unmarshalParts(message.Parts)
+    return err
+}
+
+// MessageFromJSON parses JSON into a message
+func MessageFromJSON(data []byte) (*Message, error) {
+    var message Message
+    err := json.Unmarshal(data, &message)
+    if err != nil {
+        return nil, err
     }
-    
-    return result, nil
-}
\ No newline at end of file
+    return &message, nil
+}
@@ -2,8 +2,10 @@
 package a2a_test
 
 import (
+    "encoding/json"
+    "strings"
     "testing"
-    "github.com/A2AGateway/a2agateway/saas/pkg/a2a"
+    "github.com/A2AGateway/a2a-protocol"
 )
 
 func TestMessageSerialization(t *testing.T) {
@@ -42,4 +44,53 @@ func TestMessageSerialization(t *testing.T) {
     if parsedTextPart.Text != "Hello, world!" {
         t.Errorf("Text mismatch: expected %s, got %s", "Hello, world!", parsedTextPart.Text)
     }
-}
\ No newline at end of file
+}
+
+type reactionPart struct {
+    Type  string `json:"type"`
+    Emoji string `json:"emoji"`
+}
+
+func (r reactionPart) GetType() string {
+    return r.Type
+}
+
+func (r reactionPart) GetMetadata() map[string]interface{} {
+    return nil
+}
+
+func TestCustomAndUnknownParts(t *testing.T) {
+    a2a.RegisterPartType("reaction", func(data json.RawMessage) (a2a.Part, error) {
+        var part reactionPart
+        err := json.Unmarshal(data, &part)
+        return part, err
+    })
+
+    data := []byte(`{"role":"agent","parts":[{"type":"reaction","emoji":"+1"},{"type":"x-vendor","payload":{"a":1},"metadata":{"k":"v"}}]}`)
+    message, err := a2a.MessageFromJSON(data)
+    if err != nil {
+        t.Fatalf("Failed to deserialize message: %v", err)
+    }
+
+    reaction, ok := message.Parts[0].(reactionPart)
+    if !ok || reaction.Emoji != "+1" {
+        t.Errorf("First part mismatch: %#v", message.Parts[0])
+    }
+
+    unknown, ok := message.Parts[1].(a2a.UnknownPart)
+    if !ok {
+        t.Fatalf("Second part is not an UnknownPart")
+    }
+    if unknown.GetType() != "x-vendor" || unknown.GetMetadata()["k"] != "v" {
+        t.Errorf("Unknown part mismatch: %s %v", unknown.GetType(), unknown.GetMetadata())
+    }
+
+    // Unknown parts are forwarded unchanged
+    out, err := message.ToJSON()
+    if err != nil {
+        t.Fatalf("Failed to serialize message: %v", err)
+    }
+    if !strings.Contains(string(out), `{"type":"x-vendor","payload":{"a":1},"metadata":{"k":"v"}}`) {
+        t.Errorf("Unknown part was not preserved: %s", out)
+    }
+}
@@ -2,6 +2,8 @@
 // This file implements the different part types (TextPart, FilePart, DataPart) as defined in the A2A schema
 package a2a
 
+import "encoding/json"
+
 // Part represents a content part in a message or artifact
 type Part interface {
     GetType() string
@@ -73,16 +75,6 @@ func NewFilePart(file FileContent) FilePart {
     }
 }
 
-// ValidateFileContent validates that the FileContent follows the constraints
-// (either 'bytes' or 'uri' must be provided, but not both)
-func ValidateFileContent(file FileContent) bool {
-    // Must have either bytes or URI but not both
-    hasBoth := file.Bytes != "" && file.URI != ""
-    hasNeither := file.Bytes == "" && file.URI == ""
-    
-    return !hasBoth && !hasNeither
-}
-
 // NewFileContentWithBytes creates a new FileContent with base64 encoded data
 func NewFileContentWithBytes(name, mimeType, bytes string) FileContent {
     return FileContent{
@@ -136,4 +128,42 @@ func NewDataPart(data map[string]interface{}) DataPart {
 func (d DataPart) WithMetadata(metadata map[string]interface{}) DataPart {
     d.Metadata = metadata
     return d
+}
+
+type rawPart struct {
+    Type string `json:"type"`
+}
+
+// unmarshalPart decodes a single part into its concrete type based on the type discriminator.
+// Parts of an unregistered type are kept as an UnknownPart.
+func unmarshalPart(data json.RawMessage) (Part, error) {
+    // First determine the type
+    var r rawPart
+    err := json.Unmarshal(data, &r)
+    if err != nil {
+        return nil, err
+    }
+
+    // Then unmarshal to the appropriate concrete type
+    factory, ok := lookupPartType(r.Type)
+    if !ok {
+        return UnknownPart{
+            Type: r.Type,
+            Raw:  append(json.RawMessage(nil), data...),
+        }, nil
+    }
+    return factory(data)
+}
+
+// unmarshalParts decodes a list of raw parts into their concrete types
+func unmarshalParts(rawParts []json.RawMessage) ([]Part, error) {
+    parts := make([]Part, 0, len(rawParts))
+    for _, rawPartData := range rawParts {
+        part, err := unmarshalPart(rawPartData)
+        if err != nil {
+            return nil, err
+        }
+        parts = append(parts, part)
+    }
+    return parts, nil
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the registry of part types and the fallback for unknown parts
+package a2a
+
+import (
+    "encoding/json"
+    "sync"
+)
+
+// PartFactory decodes the JSON of a part into a concrete Part implementation
+type PartFactory func(data json.RawMessage) (Part, error)
+
+var (
+    partTypesMu sync.RWMutex
+    partTypes   = map[string]PartFactory{
+        "text": func(data json.RawMessage) (Part, error) {
+            var textPart TextPart
+            err := json.Unmarshal(data, &textPart)
+            return textPart, err
+        },
+        "file": func(data json.RawMessage) (Part, error) {
+            var filePart FilePart
+            err := json.Unmarshal(data, &filePart)
+            return filePart, err
+        },
+        "data": func(data json.RawMessage) (Part, error) {
+            var dataPart DataPart
+            err := json.Unmarshal(data, &dataPart)
+            return dataPart, err
+        },
+    }
+)
+
+// RegisterPartType registers a factory for parts with the given type discriminator.
+// Registering an existing kind replaces its factory, including the built-in kinds.
+func RegisterPartType(kind string, factory PartFactory) {
+    if kind == "" || factory == nil {
+        panic("a2a: RegisterPartType requires a kind and a factory")
+    }
+    partTypesMu.Lock()
+    defer partTypesMu.Unlock()
+    partTypes[kind] = factory
+}
+
+// lookupPartType returns the factory registered for the kind
+func lookupPartType(kind string) (PartFactory, bool) {
+    partTypesMu.RLock()
+    defer partTypesMu.RUnlock()
+    factory, ok := partTypes[kind]
+    return factory, ok
+}
+
+// UnknownPart holds a part whose type has no registered factory.
+// The raw JSON is kept so the part can be forwarded unchanged.
+type UnknownPart struct {
+    Type string
+    Raw  json.RawMessage
+}
+
+// GetType returns the part type
+func (u UnknownPart) GetType() string {
+    return u.Type
+}
+
+// GetMetadata returns the part metadata, read from the raw JSON
+func (u UnknownPart) GetMetadata() map[string]interface{} {
+    var part struct {
+        Metadata map[string]interface{} `json:"metadata"`
+    }
+    if err := json.Unmarshal(u.Raw, &part); err != nil {
+        return nil
+    }
+    return part.Metadata
+}
+
+// MarshalJSON returns the original JSON of the part
+func (u UnknownPart) MarshalJSON() ([]byte, error) {
+    if len(u.Raw) == 0 {
+        return json.Marshal(rawPart{Type: u.Type})
+    }
+    return u.Raw, nil
+}
@@ -2,9 +2,17 @@
 // This package implements the specification defined in a2a.json schema
 package a2a
 
-import (
-    "encoding/json"
-    "errors"
+import "encoding/json"
+
+// Method names defined by the A2A schema
+const (
+    MethodSendTask                = "tasks/send"
+    MethodGetTask                 = "tasks/get"
+    MethodCancelTask              = "tasks/cancel"
+    MethodSetTaskPushNotification = "tasks/pushNotification/set"
+    MethodGetTaskPushNotification = "tasks/pushNotification/get"
+    MethodSendTaskSubscribe       = "tasks/sendSubscribe"
+    MethodResubscribeTask         = "tasks/resubscribe"
 )
 
 // Task-related request/response structs
@@ -151,7 +159,7 @@ func (p *Protocol) CreateSendTaskRequest(id interface{}, params TaskSendParams)
     return &SendTaskRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/send",
+        Method:  MethodSendTask,
         Params:  params,
     }
 }
@@ -161,7 +169,7 @@ func (p *Protocol) CreateGetTaskRequest(id interface{}, params TaskQueryParams)
     return &GetTaskRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/get",
+        Method:  MethodGetTask,
         Params:  params,
     }
 }
@@ -171,7 +179,7 @@ func (p *Protocol) CreateCancelTaskRequest(id interface{}, params TaskIdParams)
     return &CancelTaskRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/cancel",
+        Method:  MethodCancelTask,
         Params:  params,
     }
 }
@@ -181,7 +189,7 @@ func (p *Protocol) CreateTaskStreamingRequest(id interface{}, params TaskSendPar
     return &SendTaskStreamingRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/sendSubscribe",
+        Method:  MethodSendTaskSubscribe,
         Params:  params,
     }
 }
@@ -191,7 +199,7 @@ func (p *Protocol) CreateTaskResubscriptionRequest(id interface{}, params TaskQu
     return &TaskResubscriptionRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/resubscribe",
+        Method:  MethodResubscribeTask,
         Params:  params,
     }
 }
@@ -201,7 +209,7 @@ func (p *Protocol) CreateSetTaskPushNotificationRequest(id interface{}, params T
     return &SetTaskPushNotificationRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/pushNotification/set",
+        Method:  MethodSetTaskPushNotification,
         Params:  params,
     }
 }
@@ -211,11 +219,46 @@ func (p *Protocol) CreateGetTaskPushNotificationRequest(id interface{}, params T
     return &GetTaskPushNotificationRequest{
         JSONRPC: JSONRPCVersion,
         ID:      id,
-        Method:  "tasks/pushNotification/get",
+        Method:  MethodGetTaskPushNotification,
         Params:  params,
     }
 }
 
+// ToJSON converts the request to JSON
+func (r *SendTaskRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
+// ToJSON converts the request to JSON
+func (r *GetTaskRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
+// ToJSON converts the request to JSON
+func (r *CancelTaskRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
+// ToJSON converts the request to JSON
+func (r *SendTaskStreamingRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
+// ToJSON converts the request to JSON
+func (r *TaskResubscriptionRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
+// ToJSON converts the request to JSON
+func (r *SetTaskPushNotificationRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
+// ToJSON converts the request to JSON
+func (r *GetTaskPushNotificationRequest) ToJSON() ([]byte, error) {
+    return json.Marshal(r)
+}
+
 // ParseResponse parses a JSON-RPC response
 func (p *Protocol) ParseResponse(data []byte) (*JSONRPCResponse, error) {
     var response JSONRPCResponse
@@ -225,7 +268,7 @@ func (p *Protocol) ParseResponse(data []byte) (*JSONRPCResponse, error) {
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -240,7 +283,7 @@ func (p *Protocol) ParseSendTaskResponse(data []byte) (*SendTaskResponse, error)
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -255,7 +298,7 @@ func (p *Protocol) ParseGetTaskResponse(data []byte) (*GetTaskResponse, error) {
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -270,7 +313,7 @@ func (p *Protocol) ParseCancelTaskResponse(data []byte) (*CancelTaskResponse, er
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -285,7 +328,7 @@ func (p *Protocol) ParseSetTaskPushNotificationResponse(data []byte) (*SetTaskPu
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -300,7 +343,7 @@ func (p *Protocol) ParseGetTaskPushNotificationResponse(data []byte) (*GetTaskPu
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -309,7 +352,7 @@ func (p *Protocol) ParseGetTaskPushNotificationResponse(data []byte) (*GetTaskPu
 // ParseTask parses a Task from a JSON-RPC response
 func (p *Protocol) ParseTask(response *JSONRPCResponse) (*Task, error) {
     if response.Error != nil {
-        return nil, errors.New(response.Error.Message)
+        return nil, response.Error
     }
     
     taskBytes, err := json.Marshal(response.Result)
@@ -335,7 +378,7 @@ func (p *Protocol) ParseStreamingResponse(data []byte) (*SendTaskStreamingRespon
     }
     
     if response.Error != nil {
-        return &response, errors.New(response.Error.Message)
+        return &response, response.Error
     }
     
     return &response, nil
@@ -344,7 +387,7 @@ func (p *Protocol) ParseStreamingResponse(data []byte) (*SendTaskStreamingRespon
 // ParseTaskStatusUpdate parses a TaskStatusUpdateEvent from a streaming response
 func (p *Protocol) ParseTaskStatusUpdate(response *SendTaskStreamingResponse) (*TaskStatusUpdateEvent, error) {
     if response.Error != nil {
-        return nil, errors.New(response.Error.Message)
+        return nil, response.Error
     }
     
     eventBytes, err := json.Marshal(response.Result)
@@ -364,7 +407,7 @@ func (p *Protocol) ParseTaskStatusUpdate(response *SendTaskStreamingResponse) (*
 // ParseTaskArtifactUpdate parses a TaskArtifactUpdateEvent from a streaming response
 func (p *Protocol) ParseTaskArtifactUpdate(response *SendTaskStreamingResponse) (*TaskArtifactUpdateEvent, error) {
     if response.Error != nil {
-        return nil, errors.New(response.Error.Message)
+        return nil, response.Error
     }
     
     eventBytes, err := json.Marshal(response.Result)
@@ -3,7 +3,7 @@ package a2a_test
 
 import (
     "testing"
-    "github.com/A2AGateway/a2agateway/saas/pkg/a2a"
+    "github.com/A2AGateway/a2a-protocol"
 )
 
 func TestCreateSendTaskRequest(t *testing.T) {
@@ -0,0 +1,342 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements negotiation of the A2A protocol version between agents
+package a2a
+
+import (
+    "context"
+    "encoding/json"
+    "strconv"
+    "strings"
+)
+
+// ProtocolVersion is a revision of the A2A specification
+type ProtocolVersion string
+
+const (
+    // ProtocolVersionLegacy is the original schema built around tasks/send
+    ProtocolVersionLegacy ProtocolVersion = "0.1.0"
+    // ProtocolVersionCurrent is the newer schema built around message/send,
+    // with messageId, contextId and kind discriminators
+    ProtocolVersionCurrent ProtocolVersion = "0.2.5"
+)
+
+// ProtocolVersionHeader is the HTTP header in which a client states the protocol version it speaks
+// and in which the server echoes the version it answered with
+const ProtocolVersionHeader = "A2A-Version"
+
+// Method names added by the newer schema
+const (
+    MethodSendMessage                   = "message/send"
+    MethodStreamMessage                 = "message/stream"
+    MethodSetTaskPushNotificationConfig = "tasks/pushNotificationConfig/set"
+    MethodGetTaskPushNotificationConfig = "tasks/pushNotificationConfig/get"
+)
+
+// IsLegacy reports whether the version uses the original tasks/send schema.
+// An empty or unparsable version is treated as legacy.
+func (v ProtocolVersion) IsLegacy() bool {
+    fields := strings.SplitN(string(v), ".", 3)
+    if len(fields) < 2 {
+        return true
+    }
+    major, err := strconv.Atoi(fields[0])
+    if err != nil {
+        return true
+    }
+    minor, err := strconv.Atoi(fields[1])
+    if err != nil {
+        return true
+    }
+    return major == 0 && minor < 2
+}
+
+// NegotiateProtocolVersion returns the newest protocol version supported by both this package and the agent.
+// Cards that do not state a protocolVersion predate it and speak the legacy schema.
+func NegotiateProtocolVersion(card *AgentCard) ProtocolVersion {
+    if card == nil || card.ProtocolVersion.IsLegacy() {
+        return ProtocolVersionLegacy
+    }
+    return ProtocolVersionCurrent
+}
+
+// WithProtocolVersion sets the protocol version advertised by the agent card
+func (a *AgentCard) WithProtocolVersion(version ProtocolVersion) *AgentCard {
+    a.ProtocolVersion = version
+    return a
+}
+
+// supportedVersion returns the newest protocol version the handler serves.
+// A handler without a card serves every version.
+func (h *ProtocolHandler) supportedVersion() ProtocolVersion {
+    if h.card == nil {
+        return ProtocolVersionCurrent
+    }
+    return NegotiateProtocolVersion(h.card)
+}
+
+// requestVersion returns the protocol version in which a request is answered.
+// Methods of the newer schema imply it; for the methods both schemas share,
+// the version requested by the client is used when the handler supports it.
+func (h *ProtocolHandler) requestVersion(method string, requested ProtocolVersion) ProtocolVersion {
+    switch method {
+    case MethodSendMessage, MethodStreamMessage, MethodSetTaskPushNotificationConfig, MethodGetTaskPushNotificationConfig:
+        return ProtocolVersionCurrent
+    case MethodSendTask, MethodSendTaskSubscribe, MethodSetTaskPushNotification, MethodGetTaskPushNotification:
+        return ProtocolVersionLegacy
+    }
+    if requested.IsLegacy() || h.supportedVersion().IsLegacy() {
+        return ProtocolVersionLegacy
+    }
+    return ProtocolVersionCurrent
+}
+
+// WithProtocolVersion sets the protocol version used with agents whose version has not been negotiated
+func (c *Client) WithProtocolVersion(version ProtocolVersion) *Client {
+    c.version = version
+    return c
+}
+
+// NegotiateVersion resolves the agent card published under baseURL and records the newest
+// protocol version both sides support, which is then used for requests to the agent
+func (c *Client) NegotiateVersion(ctx context.Context, baseURL string) (ProtocolVersion, error) {
+    card, err := c.ResolveAgentCard(ctx, baseURL)
+    if err != nil {
+        return "", err
+    }
+    version := NegotiateProtocolVersion(card)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.versions[strings.TrimSuffix(baseURL, "/")] = version
+    c.agents[strings.TrimSuffix(baseURL, "/")] = card
+    if card.URL != "" {
+        c.versions[strings.TrimSuffix(card.URL, "/")] = version
+        c.agents[strings.TrimSuffix(card.URL, "/")] = card
+    }
+    return version, nil
+}
+
+// versionFor returns the protocol version used for requests to url
+func (c *Client) versionFor(url string) ProtocolVersion {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if version, ok := c.versions[strings.TrimSuffix(url, "/")]; ok {
+        return version
+    }
+    return c.version
+}
+
+// translateRequest rewrites a request of the newer schema into the equivalent request
+// of the original schema, so that it reaches the same handlers
+func (h *ProtocolHandler) translateRequest(request *rpcRequest) *JSONRPCError {
+    request.version = h.requestVersion(request.Method, request.version)
+
+    switch request.Method {
+    case MethodSendMessage, MethodStreamMessage, MethodSetTaskPushNotificationConfig, MethodGetTaskPushNotificationConfig:
+        if h.supportedVersion().IsLegacy() {
+            return MethodNotFoundError()
+        }
+    default:
+        return nil
+    }
+
+    var params interface{}
+    switch request.Method {
+    case MethodSendMessage, MethodStreamMessage:
+        var sendParams MessageSendParams
+        if rpcErr := decodeParams(request.Params, &sendParams); rpcErr != nil {
+            return rpcErr
+        }
+        params = sendParams.ToLegacy()
+        if request.Method == MethodStreamMessage {
+            request.Method = MethodSendTaskSubscribe
+        } else {
+            request.Method = MethodSendTask
+        }
+    case MethodSetTaskPushNotificationConfig:
+        var config TaskPushNotificationConfigV2
+        if rpcErr := decodeParams(request.Params, &config); rpcErr != nil {
+            return rpcErr
+        }
+        params = config.ToLegacy()
+        request.Method = MethodSetTaskPushNotification
+    case MethodGetTaskPushNotificationConfig:
+        request.Method = MethodGetTaskPushNotification
+        return nil
+    }
+
+    data, err := json.Marshal(params)
+    if err != nil {
+        return InternalError().WithData(err.Error())
+    }
+    request.Params = data
+    return nil
+}
+
+// translateResult converts a result of the original schema to the version the request is answered in
+func translateResult(result interface{}, version ProtocolVersion) interface{} {
+    if version.IsLegacy() {
+        return result
+    }
+    switch r := result.(type) {
+    case *Task:
+        if r != nil {
+            return r.ToV2()
+        }
+    case *TaskPushNotificationConfig:
+        if r != nil {
+            return r.ToV2()
+        }
+    }
+    return result
+}
+
+// call sends a request of the newer schema and decodes its result
+func (c *Client) call(ctx context.Context, url, method string, params, result interface{}) error {
+    request := NewJSONRPCRequest(c.nextID(), method, params)
+    data, err := c.post(ctx, url, request)
+    if err != nil {
+        return err
+    }
+    var response struct {
+        ID     interface{}     `json:"id"`
+        Result json.RawMessage `json:"result"`
+        Error  *JSONRPCError   `json:"error"`
+    }
+    if err := json.Unmarshal(data, &response); err != nil {
+        return err
+    }
+    if err := checkResponse(response.Error, nil, response.ID, request.ID); err != nil {
+        return err
+    }
+    if len(response.Result) == 0 || string(response.Result) == "null" {
+        return errNoResult
+    }
+    return json.Unmarshal(response.Result, result)
+}
+
+// sendMessage sends tasks/send params as a message/send request.
+// An agent answering with a message rather than a task is reported as a completed task carrying it.
+func (c *Client) sendMessage(ctx context.Context, params *TaskSendParams, url string) (*Task, error) {
+    var result json.RawMessage
+    if err := c.call(ctx, url, MethodSendMessage, params.ToV2(), &result); err != nil {
+        return nil, err
+    }
+    return taskFromV2Result(result, params.ID)
+}
+
+// taskFromV2Result decodes a message/send result, which is either a task or a message
+func taskFromV2Result(data json.RawMessage, taskID string) (*Task, error) {
+    var kind struct {
+        Kind string `json:"kind"`
+    }
+    if err := json.Unmarshal(data, &kind); err != nil {
+        return nil, err
+    }
+    if kind.Kind == KindMessage {
+        var message MessageV2
+        if err := json.Unmarshal(data, &message); err != nil {
+            return nil, err
+        }
+        if message.TaskID != "" {
+            taskID = message.TaskID
+        }
+        task := NewTask(taskID, TaskStateCompleted).WithMessage(message.ToLegacy())
+        if message.ContextID != "" {
+            task.WithSessionID(message.ContextID)
+        }
+        return task, nil
+    }
+    var task TaskV2
+    if err := json.Unmarshal(data, &task); err != nil {
+        return nil, err
+    }
+    return task.ToLegacy(), nil
+}
+
+// getTaskV2 sends a tasks/get request answered in the newer schema
+func (c *Client) getTaskV2(ctx context.Context, params *TaskQueryParams, url string) (*Task, error) {
+    var task TaskV2
+    if err := c.call(ctx, url, MethodGetTask, params, &task); err != nil {
+        return nil, err
+    }
+    return task.ToLegacy(), nil
+}
+
+// cancelTaskV2 sends a tasks/cancel request answered in the newer schema
+func (c *Client) cancelTaskV2(ctx context.Context, params *TaskIdParams, url string) (*Task, error) {
+    var task TaskV2
+    if err := c.call(ctx, url, MethodCancelTask, params, &task); err != nil {
+        return nil, err
+    }
+    return task.ToLegacy(), nil
+}
+
+// setTaskPushNotificationV2 sends a tasks/pushNotificationConfig/set request
+func (c *Client) setTaskPushNotificationV2(ctx context.Context, params *TaskPushNotificationConfig, url string) (*TaskPushNotificationConfig, error) {
+    var config TaskPushNotificationConfigV2
+    if err := c.call(ctx, url, MethodSetTaskPushNotificationConfig, params.ToV2(), &config); err != nil {
+        return nil, err
+    }
+    return config.ToLegacy(), nil
+}
+
+// getTaskPushNotificationV2 sends a tasks/pushNotificationConfig/get request
+func (c *Client) getTaskPushNotificationV2(ctx context.Context, params *TaskIdParams, url string) (*TaskPushNotificationConfig, error) {
+    var config TaskPushNotificationConfigV2
+    if err := c.call(ctx, url, MethodGetTaskPushNotificationConfig, params, &config); err != nil {
+        return nil, err
+    }
+    return config.ToLegacy(), nil
+}
+
+// parseTaskEventV2 decodes one streaming response of the newer schema into a task event.
+// Tasks and messages sent on the stream are reported as status updates.
+// Artifacts are indexed by their ID with the indexes of the stream.
+func parseTaskEventV2(data []byte, indexes *artifactIndexes) (*TaskEvent, error) {
+    var response struct {
+        Result json.RawMessage `json:"result"`
+        Error  *JSONRPCError   `json:"error"`
+    }
+    if err := json.Unmarshal(data, &response); err != nil {
+        return nil, err
+    }
+    if response.Error != nil {
+        return nil, response.Error
+    }
+    if len(response.Result) == 0 || string(response.Result) == "null" {
+        return nil, nil
+    }
+
+    var kind struct {
+        Kind string `json:"kind"`
+    }
+    if err := json.Unmarshal(response.Result, &kind); err != nil {
+        return nil, err
+    }
+    switch kind.Kind {
+    case KindStatusUpdate:
+        var event TaskStatusUpdateEventV2
+        if err := json.Unmarshal(response.Result, &event); err != nil {
+            return nil, err
+        }
+        return &TaskEvent{Status: event.ToLegacy()}, nil
+    case KindArtifactUpdate:
+        var event TaskArtifactUpdateEventV2
+        if err := json.Unmarshal(response.Result, &event); err != nil {
+            return nil, err
+        }
+        return &TaskEvent{Artifact: event.toLegacy(indexes)}, nil
+    default:
+        task, err := taskFromV2Result(response.Result, "")
+        if err != nil {
+            return nil, err
+        }
+        return &TaskEvent{Status: &TaskStatusUpdateEvent{
+            ID:       task.ID,
+            Status:   task.Status,
+            Final:    task.Status.State.IsTerminal() || kind.Kind == KindMessage,
+            Metadata: task.Metadata,
+        }}, nil
+    }
+}
@@ -0,0 +1,216 @@
+package a2a_test
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func newVersionedServer(card *a2a.AgentCard) *httptest.Server {
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            task := a2a.NewTask(params.ID, a2a.TaskStateCompleted).WithMessage(&params.Message)
+            if params.SessionID != "" {
+                task.WithSessionID(params.SessionID)
+            }
+            return task, nil
+        }).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteArtifactUpdate(a2a.TaskArtifactUpdateEvent{ID: params.ID, Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("out")})})
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+        })
+    return httptest.NewServer(handler)
+}
+
+func TestServerTranslatesMessageSend(t *testing.T) {
+    server := newVersionedServer(a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, nil))
+    defer server.Close()
+
+    body := []byte(`{"jsonrpc":"2.0","id":1,"method":"message/send","params":{"message":{
+        "kind":"message","messageId":"m-1","contextId":"ctx-1","taskId":"task-1","role":"user",
+        "parts":[{"kind":"text","text":"hello"}]}}}`)
+    resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    defer resp.Body.Close()
+    if got := resp.Header.Get(a2a.ProtocolVersionHeader); got != string(a2a.ProtocolVersionCurrent) {
+        t.Errorf("Version header mismatch: expected %q, got %q", a2a.ProtocolVersionCurrent, got)
+    }
+
+    var response struct {
+        Result map[string]interface{} `json:"result"`
+        Error  *a2a.JSONRPCError      `json:"error"`
+    }
+    data, _ := io.ReadAll(resp.Body)
+    if err := json.Unmarshal(data, &response); err != nil || response.Error != nil {
+        t.Fatalf("Unexpected response: %s", data)
+    }
+    if response.Result["kind"] != a2a.KindTask || response.Result["id"] != "task-1" || response.Result["contextId"] != "ctx-1" {
+        t.Errorf("Task not translated: %s", data)
+    }
+    status := response.Result["status"].(map[string]interface{})
+    message := status["message"].(map[string]interface{})
+    part := message["parts"].([]interface{})[0].(map[string]interface{})
+    if part["kind"] != "text" || part["type"] != nil {
+        t.Errorf("Part not translated: %v", part)
+    }
+    if message["messageId"] != "m-1" || message["metadata"] != nil {
+        t.Errorf("Message ID not kept: %v", message)
+    }
+}
+
+func TestTaskToV2MessageIDsAreStable(t *testing.T) {
+    task := a2a.NewTask("task-1", a2a.TaskStateInputRequired)
+    task.AddToHistory(*a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("yes")}))
+    task.AddToHistory(*a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("yes")}))
+    sent := &a2a.MessageV2{Kind: a2a.KindMessage, MessageID: "m-1", Role: a2a.RoleUser, Parts: []a2a.Part{a2a.NewTextPart("hi")}}
+    task.AddToHistory(*sent.ToLegacy())
+
+    first, second := task.ToV2(), task.ToV2()
+    seen := make(map[string]bool)
+    for i := range first.History {
+        id := first.History[i].MessageID
+        if id == "" || id != second.History[i].MessageID {
+            t.Errorf("Message %d ID not stable: %q, %q", i, id, second.History[i].MessageID)
+        }
+        if seen[id] {
+            t.Errorf("Message %d ID %q repeated", i, id)
+        }
+        seen[id] = true
+    }
+    if kept := first.History[2]; kept.MessageID != "m-1" || kept.Metadata != nil {
+        t.Errorf("Message ID not kept: %+v", kept)
+    }
+}
+
+func TestArtifactIDsSurviveConversion(t *testing.T) {
+    ids := []string{"4f9c2f0e-0d1b-4a53-9a8e-0c8b3c5f5a11", "b7e3a1d2-6c44-4f0e-8d3f-2a9e1c7b6d22"}
+    remote := &a2a.TaskV2{Kind: a2a.KindTask, ID: "task-1", Status: a2a.TaskStatusV2{State: a2a.TaskStateCompleted}}
+    for i, id := range ids {
+        remote.Artifacts = append(remote.Artifacts, a2a.ArtifactV2{ArtifactID: id, Parts: []a2a.Part{a2a.NewTextPart(strconv.Itoa(i))}})
+    }
+    task := remote.ToLegacy()
+    if len(task.Artifacts) != 2 || task.Artifacts[0].Index == task.Artifacts[1].Index {
+        t.Fatalf("Artifacts share an index: %+v", task.Artifacts)
+    }
+    for i, artifact := range task.ToV2().Artifacts {
+        if artifact.ArtifactID != ids[i] || artifact.Metadata != nil {
+            t.Errorf("Artifact %d ID not kept: %+v", i, artifact)
+        }
+    }
+
+    // Streamed in the newer schema, both artifacts reach the projection of the client
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{Streaming: true}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            for i := range task.Artifacts {
+                stream.WriteArtifactUpdate(a2a.TaskArtifactUpdateEvent{ID: params.ID, Artifact: task.Artifacts[i]})
+            }
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    client := a2a.NewClient()
+    if _, err := client.NegotiateVersion(context.Background(), server.URL); err != nil {
+        t.Fatalf("NegotiateVersion failed: %v", err)
+    }
+    stream, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+    collectEvents(t, stream)
+    streamed := stream.Task()
+    if len(streamed.Artifacts) != 2 {
+        t.Fatalf("Expected both artifacts, got %+v", streamed.Artifacts)
+    }
+    for i, artifact := range streamed.Artifacts {
+        if artifact.Metadata[a2a.ArtifactIDMetadataKey] != ids[i] || artifact.Parts[0].(a2a.TextPart).Text != strconv.Itoa(i) {
+            t.Errorf("Artifact %d mismatch: %+v", i, artifact)
+        }
+    }
+}
+
+func TestClientNegotiatesVersion(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{Streaming: true}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    server := newVersionedServer(card)
+    defer server.Close()
+
+    client := a2a.NewClient()
+    version, err := client.NegotiateVersion(context.Background(), server.URL)
+    if err != nil {
+        t.Fatalf("NegotiateVersion failed: %v", err)
+    }
+    if version != a2a.ProtocolVersionCurrent {
+        t.Fatalf("Version mismatch: expected %q, got %q", a2a.ProtocolVersionCurrent, version)
+    }
+
+    params := &a2a.TaskSendParams{
+        ID:        "task-1",
+        SessionID: "session-1",
+        Message:   *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hello")}),
+    }
+    task, err := client.SendTask(context.Background(), params, server.URL)
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.ID != "task-1" || task.SessionID == nil || *task.SessionID != "session-1" {
+        t.Errorf("Task mismatch: %+v", task)
+    }
+    if _, ok := task.Status.Message.Parts[0].(a2a.TextPart); !ok {
+        t.Errorf("Part is not a TextPart: %#v", task.Status.Message.Parts[0])
+    }
+
+    stream, err := client.SendTaskSubscribe(context.Background(), params, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+    var events []*a2a.TaskEvent
+    for {
+        event, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            t.Fatalf("Recv failed: %v", err)
+        }
+        events = append(events, event)
+    }
+    if len(events) != 2 || events[0].Artifact == nil || events[1].Status == nil || !events[1].Status.Final {
+        t.Errorf("Unexpected events: %+v", events)
+    }
+}
+
+func TestLegacyAgentRejectsMessageSend(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    card.ProtocolVersion = ""
+    server := newVersionedServer(card)
+    defer server.Close()
+
+    client := a2a.NewClient()
+    version, err := client.NegotiateVersion(context.Background(), server.URL)
+    if err != nil {
+        t.Fatalf("NegotiateVersion failed: %v", err)
+    }
+    if version != a2a.ProtocolVersionLegacy {
+        t.Errorf("Version mismatch: expected %q, got %q", a2a.ProtocolVersionLegacy, version)
+    }
+
+    response := postJSONRPC(t, server.Config.Handler, `{"jsonrpc":"2.0","id":1,"method":"message/send","params":{}}`)
+    if response.Error == nil || response.Error.Code != a2a.ErrCodeMethodNotFound {
+        t.Errorf("Expected method not found, got %+v", response)
+    }
+}
@@ -0,0 +1,728 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements delivery of push notifications with retries and a dead-letter queue
+package a2a
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    mathrand "math/rand"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Headers sent with every push notification
+const (
+    // NotificationTokenHeader carries PushNotificationConfig.Token
+    NotificationTokenHeader = "X-A2A-Notification-Token"
+    // NotificationTypeHeader names the kind of payload, one of the NotificationType values
+    NotificationTypeHeader = "X-A2A-Notification-Type"
+    // NotificationTimestampHeader carries the Unix time at which the notification was sent
+    NotificationTimestampHeader = "X-A2A-Notification-Timestamp"
+    // NotificationNonceHeader carries a value unique to each sent notification
+    NotificationNonceHeader = "X-A2A-Notification-Nonce"
+    // NotificationSignatureHeader carries the HMAC signature of the notification
+    NotificationSignatureHeader = "X-A2A-Notification-Signature"
+)
+
+// Authentication schemes for which notifications are signed rather than sent with static credentials
+const (
+    // AuthSchemeHMAC signs notifications with HMAC-SHA256 keyed by AuthenticationInfo.Credentials
+    AuthSchemeHMAC = "HMAC"
+    // AuthSchemeJWT sends a JWT bearer token signed with the notifier's signing key
+    AuthSchemeJWT = "JWT"
+)
+
+// notificationTokenLifetime is how long the JWT authenticating a delivery attempt stays valid
+const notificationTokenLifetime = time.Minute
+
+// Payload kinds of push notifications
+const (
+    NotificationTypeTask           = "task"
+    NotificationTypeStatusUpdate   = "status-update"
+    NotificationTypeArtifactUpdate = "artifact-update"
+)
+
+// PushDelivery is a push notification waiting to be delivered
+type PushDelivery struct {
+    ID          string                 `json:"id"`
+    Type        string                 `json:"type,omitempty"`
+    Config      PushNotificationConfig `json:"config"`
+    Payload     json.RawMessage        `json:"payload"`
+    Attempts    int                    `json:"attempts"`
+    NextAttempt time.Time              `json:"nextAttempt"`
+    LastError   string                 `json:"lastError,omitempty"`
+    CreatedAt   time.Time              `json:"createdAt"`
+}
+
+// DeliveryStore persists pending and dead-lettered push deliveries
+type DeliveryStore interface {
+    // SavePending stores or replaces a pending delivery
+    SavePending(delivery *PushDelivery) error
+    // RemovePending deletes a pending delivery
+    RemovePending(id string) error
+    // Pending returns all pending deliveries
+    Pending() ([]*PushDelivery, error)
+    // SaveDeadLetter stores a delivery that failed permanently
+    SaveDeadLetter(delivery *PushDelivery) error
+    // RemoveDeadLetter deletes a dead-lettered delivery
+    RemoveDeadLetter(id string) error
+    // DeadLetters returns all dead-lettered deliveries
+    DeadLetters() ([]*PushDelivery, error)
+}
+
+// PushConfigStore persists the push notification configurations of tasks.
+// A ProtocolHandler keeps configurations in its notifier's delivery store when it implements
+// PushConfigStore, so they survive restarts along with the pending deliveries.
+type PushConfigStore interface {
+    // SaveConfig stores or replaces the configuration of a task
+    SaveConfig(taskID string, config PushNotificationConfig) error
+    // Config returns the configuration of a task, or nil when it has none
+    Config(taskID string) (*PushNotificationConfig, error)
+    // RemoveConfig deletes the configuration of a task
+    RemoveConfig(taskID string) error
+}
+
+// MemoryDeliveryStore is a DeliveryStore and PushConfigStore kept in memory
+type MemoryDeliveryStore struct {
+    mu      sync.Mutex
+    pending map[string]PushDelivery
+    dead    map[string]PushDelivery
+    configs map[string]PushNotificationConfig
+}
+
+// NewMemoryDeliveryStore creates a new in-memory delivery store
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+    return &MemoryDeliveryStore{
+        pending: make(map[string]PushDelivery),
+        dead:    make(map[string]PushDelivery),
+        configs: make(map[string]PushNotificationConfig),
+    }
+}
+
+// SavePending implements DeliveryStore
+func (s *MemoryDeliveryStore) SavePending(delivery *PushDelivery) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.pending[delivery.ID] = *delivery
+    return nil
+}
+
+// RemovePending implements DeliveryStore
+func (s *MemoryDeliveryStore) RemovePending(id string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.pending, id)
+    return nil
+}
+
+// Pending implements DeliveryStore
+func (s *MemoryDeliveryStore) Pending() ([]*PushDelivery, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return sortedDeliveries(s.pending), nil
+}
+
+// SaveDeadLetter implements DeliveryStore
+func (s *MemoryDeliveryStore) SaveDeadLetter(delivery *PushDelivery) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.dead[delivery.ID] = *delivery
+    return nil
+}
+
+// RemoveDeadLetter implements DeliveryStore
+func (s *MemoryDeliveryStore) RemoveDeadLetter(id string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.dead, id)
+    return nil
+}
+
+// DeadLetters implements DeliveryStore
+func (s *MemoryDeliveryStore) DeadLetters() ([]*PushDelivery, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return sortedDeliveries(s.dead), nil
+}
+
+// SaveConfig implements PushConfigStore
+func (s *MemoryDeliveryStore) SaveConfig(taskID string, config PushNotificationConfig) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.configs[taskID] = config
+    return nil
+}
+
+// Config implements PushConfigStore
+func (s *MemoryDeliveryStore) Config(taskID string) (*PushNotificationConfig, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    config, ok := s.configs[taskID]
+    if !ok {
+        return nil, nil
+    }
+    return &config, nil
+}
+
+// RemoveConfig implements PushConfigStore
+func (s *MemoryDeliveryStore) RemoveConfig(taskID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.configs, taskID)
+    return nil
+}
+
+// sortedDeliveries returns copies of the deliveries ordered by creation time
+func sortedDeliveries(deliveries map[string]PushDelivery) []*PushDelivery {
+    result := make([]*PushDelivery, 0, len(deliveries))
+    for _, delivery := range deliveries {
+        delivery := delivery
+        result = append(result, &delivery)
+    }
+    sort.Slice(result, func(i, j int) bool {
+        return result[i].CreatedAt.Before(result[j].CreatedAt)
+    })
+    return result
+}
+
+// FileDeliveryStore is a DeliveryStore and PushConfigStore keeping one JSON file per delivery
+// and per task configuration in a directory, so both survive process restarts
+type FileDeliveryStore struct {
+    mu  sync.Mutex
+    dir string
+}
+
+const (
+    pendingDeliveriesDir = "pending"
+    deadLettersDir       = "dead"
+    pushConfigsDir       = "configs"
+)
+
+// NewFileDeliveryStore opens the delivery store in dir, creating it if needed
+func NewFileDeliveryStore(dir string) (*FileDeliveryStore, error) {
+    for _, sub := range []string{pendingDeliveriesDir, deadLettersDir, pushConfigsDir} {
+        if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+            return nil, err
+        }
+    }
+    return &FileDeliveryStore{dir: dir}, nil
+}
+
+// SavePending implements DeliveryStore
+func (s *FileDeliveryStore) SavePending(delivery *PushDelivery) error {
+    return s.save(pendingDeliveriesDir, delivery)
+}
+
+// RemovePending implements DeliveryStore
+func (s *FileDeliveryStore) RemovePending(id string) error {
+    return s.remove(pendingDeliveriesDir, id)
+}
+
+// Pending implements DeliveryStore
+func (s *FileDeliveryStore) Pending() ([]*PushDelivery, error) {
+    return s.list(pendingDeliveriesDir)
+}
+
+// SaveDeadLetter implements DeliveryStore
+func (s *FileDeliveryStore) SaveDeadLetter(delivery *PushDelivery) error {
+    return s.save(deadLettersDir, delivery)
+}
+
+// RemoveDeadLetter implements DeliveryStore
+func (s *FileDeliveryStore) RemoveDeadLetter(id string) error {
+    return s.remove(deadLettersDir, id)
+}
+
+// DeadLetters implements DeliveryStore
+func (s *FileDeliveryStore) DeadLetters() ([]*PushDelivery, error) {
+    return s.list(deadLettersDir)
+}
+
+// SaveConfig implements PushConfigStore
+func (s *FileDeliveryStore) SaveConfig(taskID string, config PushNotificationConfig) error {
+    data, err := json.Marshal(NewTaskPushNotificationConfig(taskID, config))
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return writeFileAtomic(s.configPath(taskID), data)
+}
+
+// Config implements PushConfigStore
+func (s *FileDeliveryStore) Config(taskID string) (*PushNotificationConfig, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    data, err := os.ReadFile(s.configPath(taskID))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    var stored TaskPushNotificationConfig
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return nil, err
+    }
+    return &stored.PushNotificationConfig, nil
+}
+
+// RemoveConfig implements PushConfigStore
+func (s *FileDeliveryStore) RemoveConfig(taskID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    err := os.Remove(s.configPath(taskID))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    return err
+}
+
+// configPath returns the file of a task's configuration, named by a hash since task IDs are chosen by clients
+func (s *FileDeliveryStore) configPath(taskID string) string {
+    sum := sha256.Sum256([]byte(taskID))
+    return filepath.Join(s.dir, pushConfigsDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// path returns the file of a delivery
+func (s *FileDeliveryStore) path(sub, id string) string {
+    return filepath.Join(s.dir, sub, filepath.Base(id)+".json")
+}
+
+// save writes a delivery file atomically
+func (s *FileDeliveryStore) save(sub string, delivery *PushDelivery) error {
+    data, err := json.Marshal(delivery)
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return writeFileAtomic(s.path(sub, delivery.ID), data)
+}
+
+// remove deletes a delivery file
+func (s *FileDeliveryStore) remove(sub, id string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    err := os.Remove(s.path(sub, id))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    return err
+}
+
+// list reads all delivery files of a directory
+func (s *FileDeliveryStore) list(sub string) ([]*PushDelivery, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    entries, err := os.ReadDir(filepath.Join(s.dir, sub))
+    if err != nil {
+        return nil, err
+    }
+    deliveries := make(map[string]PushDelivery, len(entries))
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+            continue
+        }
+        data, err := os.ReadFile(filepath.Join(s.dir, sub, entry.Name()))
+        if err != nil {
+            return nil, err
+        }
+        var delivery PushDelivery
+        if err := json.Unmarshal(data, &delivery); err != nil {
+            return nil, err
+        }
+        deliveries[delivery.ID] = delivery
+    }
+    return sortedDeliveries(deliveries), nil
+}
+
+// Notifier delivers push notifications to the URLs of PushNotificationConfigs.
+// Deliveries are persisted before they are attempted, retried with exponential
+// backoff and jitter, and moved to the dead-letter queue when they fail permanently.
+type Notifier struct {
+    httpClient  *http.Client
+    store       DeliveryStore
+    maxAttempts int
+    baseDelay   time.Duration
+    maxDelay    time.Duration
+    concurrency int
+    signingKey  interface{}
+
+    mu       sync.Mutex
+    inflight map[string]bool
+    wake     chan struct{}
+    cancel   context.CancelFunc
+    done     chan struct{}
+    workers  sync.WaitGroup
+}
+
+// NewNotifier creates a notifier persisting its deliveries in the given store
+func NewNotifier(store DeliveryStore) *Notifier {
+    return &Notifier{
+        httpClient:  &http.Client{Timeout: 30 * time.Second},
+        store:       store,
+        maxAttempts: 8,
+        baseDelay:   time.Second,
+        maxDelay:    5 * time.Minute,
+        concurrency: 4,
+        inflight:    make(map[string]bool),
+        wake:        make(chan struct{}, 1),
+    }
+}
+
+// WithHTTPClient sets the HTTP client used to deliver notifications
+func (n *Notifier) WithHTTPClient(httpClient *http.Client) *Notifier {
+    n.httpClient = httpClient
+    return n
+}
+
+// WithMaxAttempts sets how many times a delivery is attempted before it is dead-lettered
+func (n *Notifier) WithMaxAttempts(maxAttempts int) *Notifier {
+    n.maxAttempts = maxAttempts
+    return n
+}
+
+// WithBackoff sets the delay before the first retry and the maximum delay between retries
+func (n *Notifier) WithBackoff(baseDelay, maxDelay time.Duration) *Notifier {
+    n.baseDelay = baseDelay
+    n.maxDelay = maxDelay
+    return n
+}
+
+// WithConcurrency sets how many deliveries may be in flight at once
+func (n *Notifier) WithConcurrency(concurrency int) *Notifier {
+    n.concurrency = concurrency
+    return n
+}
+
+// WithSigningKey sets the key signing JWT bearer tokens for configurations using the JWT scheme.
+// The key is a []byte HMAC secret, an *rsa.PrivateKey or a P-256 *ecdsa.PrivateKey.
+func (n *Notifier) WithSigningKey(key interface{}) *Notifier {
+    n.signingKey = key
+    return n
+}
+
+// Start begins delivering pending notifications, including those persisted by a previous process
+func (n *Notifier) Start() {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    if n.cancel != nil {
+        return
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    n.cancel = cancel
+    n.done = make(chan struct{})
+    go n.run(ctx)
+}
+
+// Close stops delivering and waits for in-flight deliveries.
+// Deliveries that are still pending stay in the store.
+func (n *Notifier) Close() error {
+    n.mu.Lock()
+    cancel, done := n.cancel, n.done
+    n.cancel = nil
+    n.mu.Unlock()
+    if cancel == nil {
+        return nil
+    }
+    cancel()
+    <-done
+    n.workers.Wait()
+    return nil
+}
+
+// Notify queues the payload for delivery to the configured URL
+func (n *Notifier) Notify(config PushNotificationConfig, payload interface{}) (*PushDelivery, error) {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now()
+    delivery := &PushDelivery{
+        ID:          randomID(),
+        Type:        notificationType(payload),
+        Config:      config,
+        Payload:     data,
+        NextAttempt: now,
+        CreatedAt:   now,
+    }
+    if err := n.store.SavePending(delivery); err != nil {
+        return nil, err
+    }
+    n.signal()
+    return delivery, nil
+}
+
+// notificationType returns the payload kind of a notification, or "" if it is not a task or task event
+func notificationType(payload interface{}) string {
+    switch payload.(type) {
+    case *Task, Task:
+        return NotificationTypeTask
+    case *TaskStatusUpdateEvent, TaskStatusUpdateEvent:
+        return NotificationTypeStatusUpdate
+    case *TaskArtifactUpdateEvent, TaskArtifactUpdateEvent:
+        return NotificationTypeArtifactUpdate
+    default:
+        return ""
+    }
+}
+
+// DeadLetters returns the deliveries that failed permanently
+func (n *Notifier) DeadLetters() ([]*PushDelivery, error) {
+    return n.store.DeadLetters()
+}
+
+// Redeliver moves a dead-lettered delivery back to the pending queue
+func (n *Notifier) Redeliver(id string) error {
+    deadLetters, err := n.store.DeadLetters()
+    if err != nil {
+        return err
+    }
+    for _, delivery := range deadLetters {
+        if delivery.ID != id {
+            continue
+        }
+        delivery.Attempts = 0
+        delivery.NextAttempt = time.Now()
+        if err := n.store.SavePending(delivery); err != nil {
+            return err
+        }
+        if err := n.store.RemoveDeadLetter(id); err != nil {
+            return err
+        }
+        n.signal()
+        return nil
+    }
+    return fmt.Errorf("a2a: no dead-lettered delivery %s", id)
+}
+
+// signal wakes the delivery loop
+func (n *Notifier) signal() {
+    select {
+    case n.wake <- struct{}{}:
+    default:
+    }
+}
+
+// run starts the deliveries that are due and sleeps until the next one is
+func (n *Notifier) run(ctx context.Context) {
+    defer close(n.done)
+    concurrency := n.concurrency
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    sem := make(chan struct{}, concurrency)
+    for {
+        wait := n.maxDelay
+        pending, err := n.store.Pending()
+        if err == nil {
+            now := time.Now()
+            for _, delivery := range pending {
+                if n.isInflight(delivery.ID) {
+                    continue
+                }
+                if delay := delivery.NextAttempt.Sub(now); delay > 0 {
+                    if delay < wait {
+                        wait = delay
+                    }
+                    continue
+                }
+                select {
+                case sem <- struct{}{}:
+                case <-ctx.Done():
+                    return
+                }
+                n.setInflight(delivery.ID, true)
+                n.workers.Add(1)
+                go func(delivery *PushDelivery) {
+                    defer n.workers.Done()
+                    n.attempt(ctx, delivery)
+                    n.setInflight(delivery.ID, false)
+                    <-sem
+                    n.signal()
+                }(delivery)
+            }
+        }
+
+        timer := time.NewTimer(wait)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return
+        case <-n.wake:
+            timer.Stop()
+        case <-timer.C:
+        }
+    }
+}
+
+// isInflight reports whether a delivery is being attempted
+func (n *Notifier) isInflight(id string) bool {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    return n.inflight[id]
+}
+
+// setInflight marks a delivery as being attempted or not
+func (n *Notifier) setInflight(id string, inflight bool) {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    if inflight {
+        n.inflight[id] = true
+    } else {
+        delete(n.inflight, id)
+    }
+}
+
+// attempt delivers once and reschedules or dead-letters the delivery on failure
+func (n *Notifier) attempt(ctx context.Context, delivery *PushDelivery) {
+    err := n.deliver(ctx, delivery)
+    if err == nil {
+        n.store.RemovePending(delivery.ID)
+        return
+    }
+    if ctx.Err() != nil {
+        // Interrupted by Close: leave the delivery pending for the next start
+        return
+    }
+
+    delivery.Attempts++
+    delivery.LastError = err.Error()
+    var permanent *permanentDeliveryError
+    if errors.As(err, &permanent) || delivery.Attempts >= n.maxAttempts {
+        if n.store.SaveDeadLetter(delivery) == nil {
+            n.store.RemovePending(delivery.ID)
+        }
+        return
+    }
+    delivery.NextAttempt = time.Now().Add(n.backoff(delivery.Attempts))
+    n.store.SavePending(delivery)
+}
+
+// backoff returns the delay before the next attempt, with jitter between half and the full delay
+func (n *Notifier) backoff(attempts int) time.Duration {
+    delay := n.baseDelay
+    for i := 1; i < attempts && delay < n.maxDelay; i++ {
+        delay *= 2
+    }
+    if delay > n.maxDelay {
+        delay = n.maxDelay
+    }
+    if delay <= 1 {
+        return delay
+    }
+    half := delay / 2
+    return half + time.Duration(mathrand.Int63n(int64(delay-half)))
+}
+
+// permanentDeliveryError marks a failure that retrying cannot fix
+type permanentDeliveryError struct {
+    err error
+}
+
+// Error implements the error interface
+func (e *permanentDeliveryError) Error() string {
+    return e.err.Error()
+}
+
+// deliver sends the notification once
+func (n *Notifier) deliver(ctx context.Context, delivery *PushDelivery) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Config.URL, bytes.NewReader(delivery.Payload))
+    if err != nil {
+        return &permanentDeliveryError{err: err}
+    }
+    if err := n.sign(req, delivery); err != nil {
+        return &permanentDeliveryError{err: err}
+    }
+
+    resp, err := n.httpClient.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+    if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+        return nil
+    }
+    err = &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+    if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+        resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+        return &permanentDeliveryError{err: err}
+    }
+    return err
+}
+
+// sign sets the headers authenticating a delivery attempt.
+// Every attempt gets a fresh timestamp and nonce, so receivers rejecting replays still accept retries.
+func (n *Notifier) sign(req *http.Request, delivery *PushDelivery) error {
+    now := time.Now()
+    timestamp := strconv.FormatInt(now.Unix(), 10)
+    nonce := randomID()
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(NotificationTimestampHeader, timestamp)
+    req.Header.Set(NotificationNonceHeader, nonce)
+    if delivery.Type != "" {
+        req.Header.Set(NotificationTypeHeader, delivery.Type)
+    }
+    if delivery.Config.Token != nil {
+        req.Header.Set(NotificationTokenHeader, *delivery.Config.Token)
+    }
+
+    auth := delivery.Config.Authentication
+    if auth == nil || len(auth.Schemes) == 0 {
+        return nil
+    }
+    switch {
+    case hasScheme(auth.Schemes, AuthSchemeHMAC) && auth.Credentials != nil:
+        signature := notificationSignature([]byte(*auth.Credentials), timestamp, nonce, delivery.Payload)
+        req.Header.Set(NotificationSignatureHeader, signature)
+    case hasScheme(auth.Schemes, AuthSchemeJWT) && n.signingKey != nil:
+        sum := sha256.Sum256(delivery.Payload)
+        // iat repeats the timestamp header, binding it to the signature
+        token, err := signJWT(map[string]interface{}{
+            "iat":                 now.Unix(),
+            "exp":                 now.Add(notificationTokenLifetime).Unix(),
+            "jti":                 nonce,
+            "request_body_sha256": hex.EncodeToString(sum[:]),
+        }, n.signingKey)
+        if err != nil {
+            return err
+        }
+        req.Header.Set("Authorization", "Bearer "+token)
+    case auth.Credentials != nil:
+        req.Header.Set("Authorization", auth.Schemes[0]+" "+*auth.Credentials)
+    }
+    return nil
+}
+
+// hasScheme reports whether the schemes include scheme, ignoring case
+func hasScheme(schemes []string, scheme string) bool {
+    for _, s := range schemes {
+        if strings.EqualFold(s, scheme) {
+            return true
+        }
+    }
+    return false
+}
+
+// randomID returns a random hex identifier
+func randomID() string {
+    id := make([]byte, 16)
+    rand.Read(id)
+    return hex.EncodeToString(id)
+}
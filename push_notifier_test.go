@@ -0,0 +1,259 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+// waitFor polls the condition until it holds or the timeout expires
+func waitFor(t *testing.T, condition func() bool) {
+    t.Helper()
+    deadline := time.Now().Add(5 * time.Second)
+    for !condition() {
+        if time.Now().After(deadline) {
+            t.Fatalf("Condition not met before timeout")
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+func TestNotifierRetriesUntilDelivered(t *testing.T) {
+    var calls int32
+    var token atomic.Value
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&calls, 1) < 3 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        token.Store(r.Header.Get(a2a.NotificationTokenHeader))
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer server.Close()
+
+    store := a2a.NewMemoryDeliveryStore()
+    notifier := a2a.NewNotifier(store).WithBackoff(time.Millisecond, 10*time.Millisecond)
+    notifier.Start()
+    defer notifier.Close()
+
+    config := a2a.NewPushNotificationConfig(server.URL).WithToken("secret")
+    if _, err := notifier.Notify(*config, a2a.NewTask("task-1", a2a.TaskStateCompleted)); err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+
+    waitFor(t, func() bool {
+        pending, _ := store.Pending()
+        return len(pending) == 0
+    })
+    if got := atomic.LoadInt32(&calls); got != 3 {
+        t.Errorf("Attempt count mismatch: expected %d, got %d", 3, got)
+    }
+    if got, _ := token.Load().(string); got != "secret" {
+        t.Errorf("Token header mismatch: expected %q, got %q", "secret", got)
+    }
+}
+
+func TestNotifierDeadLetters(t *testing.T) {
+    var calls int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&calls, 1) == 1 {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer server.Close()
+
+    store, err := a2a.NewFileDeliveryStore(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewFileDeliveryStore failed: %v", err)
+    }
+    notifier := a2a.NewNotifier(store).WithBackoff(time.Millisecond, 10*time.Millisecond)
+    notifier.Start()
+    defer notifier.Close()
+
+    delivery, err := notifier.Notify(*a2a.NewPushNotificationConfig(server.URL), map[string]string{"id": "task-1"})
+    if err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+
+    // A client error is permanent: the delivery goes to the dead-letter queue without retries
+    var deadLetters []*a2a.PushDelivery
+    waitFor(t, func() bool {
+        deadLetters, _ = notifier.DeadLetters()
+        return len(deadLetters) == 1
+    })
+    if deadLetters[0].ID != delivery.ID || deadLetters[0].Attempts != 1 {
+        t.Errorf("Dead letter mismatch: %+v", deadLetters[0])
+    }
+
+    if err := notifier.Redeliver(delivery.ID); err != nil {
+        t.Fatalf("Redeliver failed: %v", err)
+    }
+    waitFor(t, func() bool {
+        pending, _ := store.Pending()
+        return len(pending) == 0
+    })
+    if deadLetters, _ := notifier.DeadLetters(); len(deadLetters) != 0 {
+        t.Errorf("Dead letter not removed after redelivery")
+    }
+    if got := atomic.LoadInt32(&calls); got != 2 {
+        t.Errorf("Attempt count mismatch: expected %d, got %d", 2, got)
+    }
+}
+
+func TestFileDeliveryStorePersistsPending(t *testing.T) {
+    dir := t.TempDir()
+    store, err := a2a.NewFileDeliveryStore(dir)
+    if err != nil {
+        t.Fatalf("NewFileDeliveryStore failed: %v", err)
+    }
+    // The notifier is not started, so the delivery stays pending
+    delivery, err := a2a.NewNotifier(store).Notify(*a2a.NewPushNotificationConfig("http://localhost/hook"), "payload")
+    if err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+
+    reopened, err := a2a.NewFileDeliveryStore(dir)
+    if err != nil {
+        t.Fatalf("NewFileDeliveryStore failed: %v", err)
+    }
+    pending, err := reopened.Pending()
+    if err != nil {
+        t.Fatalf("Pending failed: %v", err)
+    }
+    if len(pending) != 1 || pending[0].ID != delivery.ID || string(pending[0].Payload) != `"payload"` {
+        t.Errorf("Pending delivery not persisted: %+v", pending)
+    }
+}
+
+func TestProtocolHandlerPushNotifications(t *testing.T) {
+    received := make(chan struct{}, 1)
+    receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case received <- struct{}{}:
+        default:
+        }
+    }))
+    defer receiver.Close()
+
+    dir := t.TempDir()
+    newHandler := func() (*a2a.ProtocolHandler, *a2a.Notifier) {
+        store, err := a2a.NewFileDeliveryStore(dir)
+        if err != nil {
+            t.Fatalf("NewFileDeliveryStore failed: %v", err)
+        }
+        notifier := a2a.NewNotifier(store)
+        notifier.Start()
+        card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{PushNotifications: true}, nil)
+        handler := a2a.NewProtocolHandler(card).
+            WithNotifier(notifier).
+            HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+                if params.ID == "task-fail" {
+                    return nil, errors.New("rejected")
+                }
+                return a2a.NewTask(params.ID, a2a.TaskState(params.Message.Parts[0].(a2a.TextPart).Text)), nil
+            })
+        return handler, notifier
+    }
+    handler, notifier := newHandler()
+    defer notifier.Close()
+    server := httptest.NewServer(handler)
+    defer server.Close()
+    client := a2a.NewClient()
+
+    config, err := client.GetTaskPushNotification(context.Background(), &a2a.TaskIdParams{ID: "task-1"}, server.URL)
+    if err == nil {
+        t.Errorf("Expected an error for a task without configuration, got %+v", config)
+    }
+
+    send := func(url, id string, state a2a.TaskState) error {
+        _, err := client.SendTask(context.Background(), &a2a.TaskSendParams{
+            ID:               id,
+            Message:          *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart(string(state))}),
+            PushNotification: a2a.NewPushNotificationConfig(receiver.URL),
+        }, url)
+        return err
+    }
+    if err := send(server.URL, "task-1", a2a.TaskStateWorking); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    select {
+    case <-received:
+    case <-time.After(5 * time.Second):
+        t.Fatalf("Notification not delivered")
+    }
+
+    // The configuration survives a restart of the agent
+    restarted, restartedNotifier := newHandler()
+    defer restartedNotifier.Close()
+    restartedServer := httptest.NewServer(restarted)
+    defer restartedServer.Close()
+    config, err = client.GetTaskPushNotification(context.Background(), &a2a.TaskIdParams{ID: "task-1"}, restartedServer.URL)
+    if err != nil {
+        t.Fatalf("GetTaskPushNotification failed: %v", err)
+    }
+    if config.PushNotificationConfig.URL != receiver.URL {
+        t.Errorf("Config URL mismatch: expected %q, got %q", receiver.URL, config.PushNotificationConfig.URL)
+    }
+
+    // It is removed once the task is terminal, and a failed send leaves none behind
+    if err := send(restartedServer.URL, "task-1", a2a.TaskStateCompleted); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if err := send(restartedServer.URL, "task-fail", a2a.TaskStateWorking); err == nil {
+        t.Fatal("Expected the send to fail")
+    }
+    for _, id := range []string{"task-1", "task-fail"} {
+        if _, err := client.GetTaskPushNotification(context.Background(), &a2a.TaskIdParams{ID: id}, restartedServer.URL); err == nil {
+            t.Errorf("Expected no configuration left for %s", id)
+        }
+    }
+}
+
+// failingDeliveryStore keeps push configurations but cannot queue deliveries
+type failingDeliveryStore struct {
+    *a2a.MemoryDeliveryStore
+}
+
+func (s failingDeliveryStore) SavePending(delivery *a2a.PushDelivery) error {
+    return errors.New("disk full")
+}
+
+func TestProtocolHandlerReportsUnqueuedNotification(t *testing.T) {
+    notifier := a2a.NewNotifier(failingDeliveryStore{a2a.NewMemoryDeliveryStore()})
+    var reported []error
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{PushNotifications: true}, nil)
+    handler := a2a.NewProtocolHandler(card).
+        WithNotifier(notifier).
+        OnNotifyError(func(task *a2a.Task, err error) {
+            reported = append(reported, err)
+        }).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    client := a2a.NewClient()
+    if _, err := client.SendTask(context.Background(), &a2a.TaskSendParams{
+        ID:               "task-1",
+        Message:          *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+        PushNotification: a2a.NewPushNotificationConfig("http://localhost/hook"),
+    }, server.URL); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if len(reported) != 1 {
+        t.Fatalf("Expected the failure to be reported once, got %v", reported)
+    }
+    // The configuration is kept so the notification can still be sent
+    if _, err := client.GetTaskPushNotification(context.Background(), &a2a.TaskIdParams{ID: "task-1"}, server.URL); err != nil {
+        t.Errorf("Expected the configuration to be kept: %v", err)
+    }
+}
@@ -0,0 +1,340 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the receiving side of push notifications
+package a2a
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Errors reported when a received notification fails verification
+var (
+    ErrNotificationUnauthenticated = errors.New("a2a: notification authentication failed")
+    ErrNotificationReplayed        = errors.New("a2a: notification replayed or expired")
+    ErrTooManyNotifications        = errors.New("a2a: too many recent notifications")
+)
+
+// defaultMaxClockSkew is how far a notification timestamp may be from the receiver's clock
+const defaultMaxClockSkew = 5 * time.Minute
+
+// defaultMaxNotificationSize limits the body of a received notification
+const defaultMaxNotificationSize = 10 * 1024 * 1024
+
+// defaultMaxNonces limits how many nonces a receiver remembers at once
+const defaultMaxNonces = 100000
+
+// NotificationTaskFunc handles a notification carrying a task
+type NotificationTaskFunc func(ctx context.Context, task *Task) error
+
+// NotificationStatusUpdateFunc handles a notification carrying a status update
+type NotificationStatusUpdateFunc func(ctx context.Context, event *TaskStatusUpdateEvent) error
+
+// NotificationArtifactUpdateFunc handles a notification carrying an artifact update
+type NotificationArtifactUpdateFunc func(ctx context.Context, event *TaskArtifactUpdateEvent) error
+
+// NotificationReceiver is an http.Handler accepting push notifications sent by a Notifier.
+// It checks the shared token, verifies the HMAC or JWT signature required by the
+// configured authentication schemes, rejects replays, and dispatches the decoded
+// payload to the registered callbacks.
+type NotificationReceiver struct {
+    token        *string
+    schemes      []string
+    credentials  *string
+    hmacKey      []byte
+    jwtKey       interface{}
+    maxClockSkew time.Duration
+    maxBodySize  int64
+    onTask       NotificationTaskFunc
+    onStatus     NotificationStatusUpdateFunc
+    onArtifact   NotificationArtifactUpdateFunc
+
+    mu        sync.Mutex
+    maxNonces int
+    nonces    map[string]bool
+    expiries  map[int64][]string // nonces by the second after which they are forgotten
+    nextSweep int64
+}
+
+// NewNotificationReceiver creates a receiver for notifications sent to the given configuration.
+// For the HMAC scheme the configured credentials are the signing key.
+func NewNotificationReceiver(config *PushNotificationConfig) *NotificationReceiver {
+    r := &NotificationReceiver{
+        maxClockSkew: defaultMaxClockSkew,
+        maxBodySize:  defaultMaxNotificationSize,
+        maxNonces:    defaultMaxNonces,
+        nonces:       make(map[string]bool),
+        expiries:     make(map[int64][]string),
+    }
+    if config == nil {
+        return r
+    }
+    r.token = config.Token
+    if auth := config.Authentication; auth != nil {
+        r.schemes = auth.Schemes
+        r.credentials = auth.Credentials
+        if auth.Credentials != nil && hasScheme(auth.Schemes, AuthSchemeHMAC) {
+            r.hmacKey = []byte(*auth.Credentials)
+        }
+    }
+    return r
+}
+
+// WithHMACKey sets the key verifying HMAC signatures
+func (r *NotificationReceiver) WithHMACKey(key []byte) *NotificationReceiver {
+    r.hmacKey = key
+    return r
+}
+
+// WithJWTKey sets the key verifying JWT bearer tokens.
+// The key is a []byte HMAC secret, an *rsa.PublicKey or a P-256 *ecdsa.PublicKey.
+func (r *NotificationReceiver) WithJWTKey(key interface{}) *NotificationReceiver {
+    r.jwtKey = key
+    return r
+}
+
+// WithMaxClockSkew sets how old or how far in the future a notification may be
+func (r *NotificationReceiver) WithMaxClockSkew(skew time.Duration) *NotificationReceiver {
+    r.maxClockSkew = skew
+    return r
+}
+
+// WithMaxNonces sets how many nonces are remembered at once.
+// Notifications beyond the limit are refused with ErrTooManyNotifications until older nonces expire.
+func (r *NotificationReceiver) WithMaxNonces(n int) *NotificationReceiver {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.maxNonces = n
+    return r
+}
+
+// HandleTask registers the callback for task notifications
+func (r *NotificationReceiver) HandleTask(fn NotificationTaskFunc) *NotificationReceiver {
+    r.onTask = fn
+    return r
+}
+
+// HandleStatusUpdate registers the callback for status update notifications
+func (r *NotificationReceiver) HandleStatusUpdate(fn NotificationStatusUpdateFunc) *NotificationReceiver {
+    r.onStatus = fn
+    return r
+}
+
+// HandleArtifactUpdate registers the callback for artifact update notifications
+func (r *NotificationReceiver) HandleArtifactUpdate(fn NotificationArtifactUpdateFunc) *NotificationReceiver {
+    r.onArtifact = fn
+    return r
+}
+
+// ServeHTTP implements http.Handler
+func (r *NotificationReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+    if req.Method != http.MethodPost {
+        w.Header().Set("Allow", http.MethodPost)
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    body, err := io.ReadAll(io.LimitReader(req.Body, r.maxBodySize+1))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if int64(len(body)) > r.maxBodySize {
+        http.Error(w, "notification too large", http.StatusRequestEntityTooLarge)
+        return
+    }
+
+    if err := r.Verify(req.Header, body); err != nil {
+        if errors.Is(err, ErrTooManyNotifications) {
+            http.Error(w, err.Error(), http.StatusTooManyRequests)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusUnauthorized)
+        return
+    }
+    if err := r.dispatch(req.Context(), req.Header.Get(NotificationTypeHeader), body); err != nil {
+        var syntaxErr *json.SyntaxError
+        var typeErr *json.UnmarshalTypeError
+        if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// Verify checks the token, signature and freshness of a notification.
+// A notification that verifies is remembered, so verifying it again reports ErrNotificationReplayed.
+func (r *NotificationReceiver) Verify(header http.Header, body []byte) error {
+    if r.token != nil {
+        got := header.Get(NotificationTokenHeader)
+        if subtle.ConstantTimeCompare([]byte(got), []byte(*r.token)) != 1 {
+            return fmt.Errorf("%w: token mismatch", ErrNotificationUnauthenticated)
+        }
+    }
+
+    timestamp := header.Get(NotificationTimestampHeader)
+    nonce := header.Get(NotificationNonceHeader)
+    if timestamp == "" || nonce == "" {
+        return fmt.Errorf("%w: missing timestamp or nonce", ErrNotificationReplayed)
+    }
+    seconds, err := strconv.ParseInt(timestamp, 10, 64)
+    if err != nil {
+        return fmt.Errorf("%w: invalid timestamp", ErrNotificationReplayed)
+    }
+    sent := time.Unix(seconds, 0)
+    if skew := time.Since(sent); skew > r.maxClockSkew || skew < -r.maxClockSkew {
+        return fmt.Errorf("%w: timestamp outside the allowed clock skew", ErrNotificationReplayed)
+    }
+
+    if err := r.verifySignature(header, timestamp, nonce, body); err != nil {
+        return err
+    }
+    return r.rememberNonce(nonce, sent)
+}
+
+// verifySignature checks the signature required by the configured schemes.
+// When several schemes are configured, any one of them verifying is enough.
+func (r *NotificationReceiver) verifySignature(header http.Header, timestamp, nonce string, body []byte) error {
+    if len(r.schemes) == 0 {
+        return nil
+    }
+    if hasScheme(r.schemes, AuthSchemeHMAC) && r.hmacKey != nil {
+        expected := notificationSignature(r.hmacKey, timestamp, nonce, body)
+        if hmac.Equal([]byte(header.Get(NotificationSignatureHeader)), []byte(expected)) {
+            return nil
+        }
+    }
+    if hasScheme(r.schemes, AuthSchemeJWT) && r.jwtKey != nil {
+        if token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); ok {
+            claims, err := verifyJWT(token, r.jwtKey)
+            if err != nil {
+                return fmt.Errorf("%w: %v", ErrNotificationUnauthenticated, err)
+            }
+            // The header timestamp is only trusted when it is the signed iat,
+            // so a token cannot be replayed with a fresh timestamp once its nonce is forgotten
+            if _, ok := claims["exp"].(float64); !ok {
+                return fmt.Errorf("%w: token has no expiry", ErrNotificationUnauthenticated)
+            }
+            iat, ok := claims["iat"].(float64)
+            if !ok || strconv.FormatInt(int64(iat), 10) != timestamp {
+                return fmt.Errorf("%w: token issued at a different time than the notification", ErrNotificationUnauthenticated)
+            }
+            sum := sha256.Sum256(body)
+            if claims["jti"] != nonce || claims["request_body_sha256"] != hex.EncodeToString(sum[:]) {
+                return fmt.Errorf("%w: token does not match the notification", ErrNotificationUnauthenticated)
+            }
+            return nil
+        }
+    }
+    if r.credentials != nil && !hasScheme(r.schemes, AuthSchemeHMAC) && !hasScheme(r.schemes, AuthSchemeJWT) {
+        // Static credentials, sent by the notifier with the first configured scheme
+        expected := r.schemes[0] + " " + *r.credentials
+        if subtle.ConstantTimeCompare([]byte(header.Get("Authorization")), []byte(expected)) == 1 {
+            return nil
+        }
+    }
+    return fmt.Errorf("%w: no valid signature for schemes %s", ErrNotificationUnauthenticated, strings.Join(r.schemes, ", "))
+}
+
+// rememberNonce records a nonce, failing if it was already seen.
+// Nonces are forgotten once their timestamp falls outside the clock skew window;
+// expired nonces are dropped a whole second at a time, at most once a second.
+func (r *NotificationReceiver) rememberNonce(nonce string, sent time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if now := time.Now().Unix(); now >= r.nextSweep {
+        for second, expired := range r.expiries {
+            if now > second {
+                for _, seen := range expired {
+                    delete(r.nonces, seen)
+                }
+                delete(r.expiries, second)
+            }
+        }
+        r.nextSweep = now + 1
+    }
+    if r.nonces[nonce] {
+        return fmt.Errorf("%w: nonce already used", ErrNotificationReplayed)
+    }
+    if len(r.nonces) >= r.maxNonces {
+        return ErrTooManyNotifications
+    }
+    expires := sent.Add(r.maxClockSkew).Unix()
+    r.nonces[nonce] = true
+    r.expiries[expires] = append(r.expiries[expires], nonce)
+    return nil
+}
+
+// dispatch decodes the payload and calls the matching callback.
+// Notifications without a callback are acknowledged and dropped.
+func (r *NotificationReceiver) dispatch(ctx context.Context, kind string, body []byte) error {
+    if kind == "" {
+        kind = detectNotificationType(body)
+    }
+    switch kind {
+    case NotificationTypeStatusUpdate:
+        var event TaskStatusUpdateEvent
+        if err := json.Unmarshal(body, &event); err != nil {
+            return err
+        }
+        if r.onStatus != nil {
+            return r.onStatus(ctx, &event)
+        }
+    case NotificationTypeArtifactUpdate:
+        var event TaskArtifactUpdateEvent
+        if err := json.Unmarshal(body, &event); err != nil {
+            return err
+        }
+        if r.onArtifact != nil {
+            return r.onArtifact(ctx, &event)
+        }
+    default:
+        var task Task
+        if err := json.Unmarshal(body, &task); err != nil {
+            return err
+        }
+        if r.onTask != nil {
+            return r.onTask(ctx, &task)
+        }
+    }
+    return nil
+}
+
+// detectNotificationType guesses the payload kind of a notification sent without a type header
+func detectNotificationType(body []byte) string {
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal(body, &fields); err != nil {
+        return NotificationTypeTask
+    }
+    if _, ok := fields["artifact"]; ok {
+        return NotificationTypeArtifactUpdate
+    }
+    if _, ok := fields["final"]; ok {
+        return NotificationTypeStatusUpdate
+    }
+    return NotificationTypeTask
+}
+
+// notificationSignature returns the HMAC-SHA256 signature of a notification
+func notificationSignature(key []byte, timestamp, nonce string, body []byte) string {
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(timestamp))
+    mac.Write([]byte("."))
+    mac.Write([]byte(nonce))
+    mac.Write([]byte("."))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,234 @@
+package a2a_test
+
+import (
+    "bytes"
+    "context"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestNotificationReceiverHMAC(t *testing.T) {
+    config := a2a.NewPushNotificationConfig("").
+        WithToken("secret").
+        WithAuthentication(a2a.NewAuthenticationInfo([]string{a2a.AuthSchemeHMAC}).WithCredentials("shared-key"))
+
+    received := make(chan *a2a.TaskStatusUpdateEvent, 1)
+    receiver := a2a.NewNotificationReceiver(config).
+        HandleStatusUpdate(func(ctx context.Context, event *a2a.TaskStatusUpdateEvent) error {
+            received <- event
+            return nil
+        })
+    server := httptest.NewServer(receiver)
+    defer server.Close()
+    config.URL = server.URL
+
+    notifier := a2a.NewNotifier(a2a.NewMemoryDeliveryStore())
+    notifier.Start()
+    defer notifier.Close()
+    event := a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}
+    if _, err := notifier.Notify(*config, event); err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+
+    select {
+    case got := <-received:
+        if got.ID != "task-1" || got.Status.State != a2a.TaskStateWorking {
+            t.Errorf("Event mismatch: %+v", got)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatalf("Notification not received")
+    }
+}
+
+func TestNotificationReceiverRejects(t *testing.T) {
+    config := a2a.NewPushNotificationConfig("http://localhost/hook").
+        WithAuthentication(a2a.NewAuthenticationInfo([]string{a2a.AuthSchemeHMAC}).WithCredentials("shared-key"))
+    receiver := a2a.NewNotificationReceiver(config)
+
+    body := []byte(`{"id":"task-1","status":{"state":"completed"}}`)
+    header := http.Header{}
+    header.Set(a2a.NotificationTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+    header.Set(a2a.NotificationNonceHeader, "nonce-1")
+    header.Set(a2a.NotificationSignatureHeader, "sha256=00")
+    if err := receiver.Verify(header, body); !errors.Is(err, a2a.ErrNotificationUnauthenticated) {
+        t.Errorf("Expected bad signature to be rejected, got %v", err)
+    }
+
+    header.Set(a2a.NotificationTimestampHeader, strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+    if err := receiver.Verify(header, body); !errors.Is(err, a2a.ErrNotificationReplayed) {
+        t.Errorf("Expected stale timestamp to be rejected, got %v", err)
+    }
+
+    // Replaying a request captured from a notifier
+    type capturedRequest struct {
+        header http.Header
+        body   []byte
+    }
+    captured := make(chan capturedRequest, 1)
+    capture := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var buf bytes.Buffer
+        buf.ReadFrom(r.Body)
+        captured <- capturedRequest{header: r.Header, body: buf.Bytes()}
+    }))
+    defer capture.Close()
+    config.URL = capture.URL
+    notifier := a2a.NewNotifier(a2a.NewMemoryDeliveryStore())
+    notifier.Start()
+    defer notifier.Close()
+    if _, err := notifier.Notify(*config, a2a.NewTask("task-1", a2a.TaskStateCompleted)); err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+    var request capturedRequest
+    select {
+    case request = <-captured:
+    case <-time.After(5 * time.Second):
+        t.Fatalf("Notification not sent")
+    }
+
+    if err := receiver.Verify(request.header, request.body); err != nil {
+        t.Fatalf("Verify failed: %v", err)
+    }
+    if err := receiver.Verify(request.header, request.body); !errors.Is(err, a2a.ErrNotificationReplayed) {
+        t.Errorf("Expected replay to be rejected, got %v", err)
+    }
+}
+
+func TestNotificationReceiverJWT(t *testing.T) {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatalf("GenerateKey failed: %v", err)
+    }
+
+    received := make(chan *a2a.Task, 1)
+    receiver := a2a.NewNotificationReceiver(a2a.NewPushNotificationConfig("").
+        WithAuthentication(a2a.NewAuthenticationInfo([]string{a2a.AuthSchemeJWT}))).
+        WithJWTKey(&key.PublicKey).
+        HandleTask(func(ctx context.Context, task *a2a.Task) error {
+            received <- task
+            return nil
+        })
+    server := httptest.NewServer(receiver)
+    defer server.Close()
+
+    notifier := a2a.NewNotifier(a2a.NewMemoryDeliveryStore()).WithSigningKey(key)
+    notifier.Start()
+    defer notifier.Close()
+    config := a2a.NewPushNotificationConfig(server.URL).
+        WithAuthentication(a2a.NewAuthenticationInfo([]string{a2a.AuthSchemeJWT}))
+    if _, err := notifier.Notify(*config, a2a.NewTask("task-1", a2a.TaskStateCompleted)); err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+
+    select {
+    case task := <-received:
+        if task.ID != "task-1" {
+            t.Errorf("Task ID mismatch: expected %q, got %q", "task-1", task.ID)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatalf("Notification not received")
+    }
+
+    // An unsigned request is rejected before reaching the callback
+    req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte(`{"id":"task-2"}`)))
+    req.Header.Set(a2a.NotificationTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+    req.Header.Set(a2a.NotificationNonceHeader, "nonce-2")
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("Request failed: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusUnauthorized {
+        t.Errorf("Status mismatch: expected %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+    }
+}
+
+func TestNotificationReceiverJWTReplayAfterNonceWindow(t *testing.T) {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatalf("GenerateKey failed: %v", err)
+    }
+    const skew = 2 * time.Second
+    receiver := a2a.NewNotificationReceiver(a2a.NewPushNotificationConfig("").
+        WithAuthentication(a2a.NewAuthenticationInfo([]string{a2a.AuthSchemeJWT}))).
+        WithJWTKey(&key.PublicKey).
+        WithMaxClockSkew(skew)
+
+    captured := make(chan *http.Request, 1)
+    bodies := make(chan []byte, 1)
+    capture := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var buf bytes.Buffer
+        buf.ReadFrom(r.Body)
+        captured <- r
+        bodies <- buf.Bytes()
+    }))
+    defer capture.Close()
+    notifier := a2a.NewNotifier(a2a.NewMemoryDeliveryStore()).WithSigningKey(key)
+    notifier.Start()
+    defer notifier.Close()
+    config := a2a.NewPushNotificationConfig(capture.URL).
+        WithAuthentication(a2a.NewAuthenticationInfo([]string{a2a.AuthSchemeJWT}))
+    if _, err := notifier.Notify(*config, a2a.NewTask("task-1", a2a.TaskStateCompleted)); err != nil {
+        t.Fatalf("Notify failed: %v", err)
+    }
+    var header http.Header
+    var body []byte
+    select {
+    case r := <-captured:
+        header, body = r.Header, <-bodies
+    case <-time.After(5 * time.Second):
+        t.Fatalf("Notification not sent")
+    }
+    if err := receiver.Verify(header, body); err != nil {
+        t.Fatalf("Verify failed: %v", err)
+    }
+
+    // Once the nonce is forgotten, the same token with a fresh timestamp is still rejected
+    sent, _ := strconv.ParseInt(header.Get(a2a.NotificationTimestampHeader), 10, 64)
+    time.Sleep(time.Until(time.Unix(sent, 0).Add(skew + 100*time.Millisecond)))
+    header.Set(a2a.NotificationTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+    if err := receiver.Verify(header, body); !errors.Is(err, a2a.ErrNotificationUnauthenticated) {
+        t.Errorf("Expected the replay to be rejected, got %v", err)
+    }
+}
+
+func TestNotificationReceiverLimitsNonces(t *testing.T) {
+    receiver := a2a.NewNotificationReceiver(nil).WithMaxNonces(2)
+    body := []byte(`{"id":"task-1","status":{"state":"completed"}}`)
+    verify := func(nonce string) error {
+        header := http.Header{}
+        header.Set(a2a.NotificationTimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+        header.Set(a2a.NotificationNonceHeader, nonce)
+        return receiver.Verify(header, body)
+    }
+
+    for _, nonce := range []string{"nonce-1", "nonce-2"} {
+        if err := verify(nonce); err != nil {
+            t.Fatalf("Verify %s failed: %v", nonce, err)
+        }
+    }
+    if err := verify("nonce-3"); !errors.Is(err, a2a.ErrTooManyNotifications) {
+        t.Errorf("Expected ErrTooManyNotifications, got %v", err)
+    }
+    if err := verify("nonce-1"); !errors.Is(err, a2a.ErrNotificationReplayed) {
+        t.Errorf("Expected replay to be rejected at the limit, got %v", err)
+    }
+
+    // Nonces are forgotten once they leave the clock skew window
+    receiver = a2a.NewNotificationReceiver(nil).WithMaxNonces(1).WithMaxClockSkew(time.Second)
+    if err := verify("nonce-1"); err != nil {
+        t.Fatalf("Verify failed: %v", err)
+    }
+    time.Sleep(2 * time.Second)
+    if err := verify("nonce-2"); err != nil {
+        t.Errorf("Expected expired nonce to free its slot, got %v", err)
+    }
+}
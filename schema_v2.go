@@ -0,0 +1,535 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file defines the types of the newer message/send schema and their translation to the original types
+package a2a
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "strconv"
+    "time"
+)
+
+// Kind discriminators of the newer schema
+const (
+    KindMessage        = "message"
+    KindTask           = "task"
+    KindStatusUpdate   = "status-update"
+    KindArtifactUpdate = "artifact-update"
+)
+
+// MessageIDMetadataKey is the key of the message metadata keeping the ID of a message converted from the newer schema
+const MessageIDMetadataKey = "messageId"
+
+// ArtifactIDMetadataKey is the key of the artifact metadata keeping a non-numeric ID of an artifact converted from the newer schema
+const ArtifactIDMetadataKey = "artifactId"
+
+// Task states added by the newer schema
+const (
+    TaskStateRejected     TaskState = "rejected"
+    TaskStateAuthRequired TaskState = "auth-required"
+)
+
+// MessageV2 is a message in the newer schema.
+// Its parts are the same Part values as in Message, encoded with a kind discriminator instead of type.
+type MessageV2 struct {
+    Kind             string                 `json:"kind"`
+    MessageID        string                 `json:"messageId"`
+    ContextID        string                 `json:"contextId,omitempty"`
+    TaskID           string                 `json:"taskId,omitempty"`
+    ReferenceTaskIDs []string               `json:"referenceTaskIds,omitempty"`
+    Role             MessageRole            `json:"role"`
+    Parts            []Part                 `json:"parts"`
+    Metadata         map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the message with kind discriminated parts
+func (m MessageV2) MarshalJSON() ([]byte, error) {
+    type MessageV2Alias MessageV2
+    parts, err := marshalPartsV2(m.Parts)
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(&struct {
+        *MessageV2Alias
+        Parts []json.RawMessage `json:"parts"`
+    }{(*MessageV2Alias)(&m), parts})
+}
+
+// UnmarshalJSON decodes a message, resolving each kind discriminated part to its concrete type
+func (m *MessageV2) UnmarshalJSON(data []byte) error {
+    type MessageV2Alias MessageV2
+    var message struct {
+        *MessageV2Alias
+        Parts []json.RawMessage `json:"parts"`
+    }
+    message.MessageV2Alias = (*MessageV2Alias)(m)
+
+    err := json.Unmarshal(data, &message)
+    if err != nil {
+        return err
+    }
+    m.Parts, err = unmarshalPartsV2(message.Parts)
+    return err
+}
+
+// ArtifactV2 is an artifact in the newer schema
+type ArtifactV2 struct {
+    ArtifactID  string                 `json:"artifactId"`
+    Name        *string                `json:"name,omitempty"`
+    Description *string                `json:"description,omitempty"`
+    Parts       []Part                 `json:"parts"`
+    Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the artifact with kind discriminated parts
+func (a ArtifactV2) MarshalJSON() ([]byte, error) {
+    type ArtifactV2Alias ArtifactV2
+    parts, err := marshalPartsV2(a.Parts)
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(&struct {
+        *ArtifactV2Alias
+        Parts []json.RawMessage `json:"parts"`
+    }{(*ArtifactV2Alias)(&a), parts})
+}
+
+// UnmarshalJSON decodes an artifact, resolving each kind discriminated part to its concrete type
+func (a *ArtifactV2) UnmarshalJSON(data []byte) error {
+    type ArtifactV2Alias ArtifactV2
+    var artifact struct {
+        *ArtifactV2Alias
+        Parts []json.RawMessage `json:"parts"`
+    }
+    artifact.ArtifactV2Alias = (*ArtifactV2Alias)(a)
+
+    err := json.Unmarshal(data, &artifact)
+    if err != nil {
+        return err
+    }
+    a.Parts, err = unmarshalPartsV2(artifact.Parts)
+    return err
+}
+
+// TaskStatusV2 is the status of a task in the newer schema
+type TaskStatusV2 struct {
+    State     TaskState  `json:"state"`
+    Message   *MessageV2 `json:"message,omitempty"`
+    Timestamp time.Time  `json:"timestamp"`
+}
+
+// TaskV2 is a task in the newer schema
+type TaskV2 struct {
+    Kind      string                 `json:"kind"`
+    ID        string                 `json:"id"`
+    ContextID string                 `json:"contextId"`
+    Status    TaskStatusV2           `json:"status"`
+    Artifacts []ArtifactV2           `json:"artifacts,omitempty"`
+    History   []MessageV2            `json:"history,omitempty"`
+    Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TaskStatusUpdateEventV2 is a status update event in the newer schema
+type TaskStatusUpdateEventV2 struct {
+    Kind      string                 `json:"kind"`
+    TaskID    string                 `json:"taskId"`
+    ContextID string                 `json:"contextId"`
+    Status    TaskStatusV2           `json:"status"`
+    Final     bool                   `json:"final"`
+    Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TaskArtifactUpdateEventV2 is an artifact update event in the newer schema
+type TaskArtifactUpdateEventV2 struct {
+    Kind      string                 `json:"kind"`
+    TaskID    string                 `json:"taskId"`
+    ContextID string                 `json:"contextId"`
+    Artifact  ArtifactV2             `json:"artifact"`
+    Append    *bool                  `json:"append,omitempty"`
+    LastChunk *bool                  `json:"lastChunk,omitempty"`
+    Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MessageSendConfiguration configures a message/send or message/stream request
+type MessageSendConfiguration struct {
+    AcceptedOutputModes    []string                `json:"acceptedOutputModes,omitempty"`
+    HistoryLength          *int                    `json:"historyLength,omitempty"`
+    PushNotificationConfig *PushNotificationConfig `json:"pushNotificationConfig,omitempty"`
+    Blocking               *bool                   `json:"blocking,omitempty"`
+}
+
+// MessageSendParams represents parameters for the message/send and message/stream methods
+type MessageSendParams struct {
+    Message       MessageV2                 `json:"message"`
+    Configuration *MessageSendConfiguration `json:"configuration,omitempty"`
+    Metadata      map[string]interface{}    `json:"metadata,omitempty"`
+}
+
+// TaskPushNotificationConfigV2 is the push notification configuration of a task in the newer schema
+type TaskPushNotificationConfigV2 struct {
+    TaskID                 string                 `json:"taskId"`
+    PushNotificationConfig PushNotificationConfig `json:"pushNotificationConfig"`
+}
+
+// marshalPartsV2 encodes parts with a kind discriminator
+func marshalPartsV2(parts []Part) ([]json.RawMessage, error) {
+    raws := make([]json.RawMessage, 0, len(parts))
+    for _, part := range parts {
+        raw, err := renamePartDiscriminator(part, "type", "kind")
+        if err != nil {
+            return nil, err
+        }
+        raws = append(raws, raw)
+    }
+    return raws, nil
+}
+
+// unmarshalPartsV2 decodes kind discriminated parts into their concrete types
+func unmarshalPartsV2(raws []json.RawMessage) ([]Part, error) {
+    parts := make([]Part, 0, len(raws))
+    for _, raw := range raws {
+        renamed, err := renamePartDiscriminator(raw, "kind", "type")
+        if err != nil {
+            return nil, err
+        }
+        part, err := unmarshalPart(renamed)
+        if err != nil {
+            return nil, err
+        }
+        parts = append(parts, part)
+    }
+    return parts, nil
+}
+
+// renamePartDiscriminator encodes a part and moves its discriminator from one key to another
+func renamePartDiscriminator(part interface{}, from, to string) (json.RawMessage, error) {
+    data, err := json.Marshal(part)
+    if err != nil {
+        return nil, err
+    }
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal(data, &fields); err != nil {
+        return nil, err
+    }
+    if value, ok := fields[from]; ok {
+        delete(fields, from)
+        fields[to] = value
+    }
+    return json.Marshal(fields)
+}
+
+// ToV2 converts the message to the newer schema.
+// The message keeps the ID it had in the newer schema, if any, and gets a new one otherwise.
+func (m *Message) ToV2() *MessageV2 {
+    return m.toV2(randomID)
+}
+
+// toV2 converts the message to the newer schema, calling newID only when the message kept no ID
+func (m *Message) toV2(newID func() string) *MessageV2 {
+    result := &MessageV2{
+        Kind:     KindMessage,
+        Role:     m.Role,
+        Parts:    m.Parts,
+        Metadata: m.Metadata,
+    }
+    if id, ok := m.Metadata[MessageIDMetadataKey].(string); ok && id != "" {
+        result.MessageID = id
+        result.Metadata = withoutMetadata(m.Metadata, MessageIDMetadataKey)
+        return result
+    }
+    result.MessageID = newID()
+    return result
+}
+
+// ToLegacy converts the message to the original schema.
+// The message ID is kept in the metadata under MessageIDMetadataKey.
+func (m *MessageV2) ToLegacy() *Message {
+    result := &Message{
+        Role:     m.Role,
+        Parts:    m.Parts,
+        Metadata: m.Metadata,
+    }
+    if m.MessageID != "" {
+        result.Metadata = withMetadata(m.Metadata, MessageIDMetadataKey, m.MessageID)
+    }
+    return result
+}
+
+// derivedMessageID returns a function deriving the ID of a message of the task that kept none.
+// The ID depends on the task, the content of the message and how many identical messages precede it,
+// so the message gets the same ID each time the task is converted.
+func derivedMessageID(taskID string, m *Message, occurrence int) func() string {
+    return func() string {
+        data, _ := json.Marshal(m)
+        sum := sha256.Sum256([]byte(taskID + "\x00" + strconv.Itoa(occurrence) + "\x00" + string(data)))
+        return hex.EncodeToString(sum[:16])
+    }
+}
+
+// withMetadata returns a copy of the metadata with the entry set
+func withMetadata(metadata map[string]interface{}, key string, value interface{}) map[string]interface{} {
+    result := make(map[string]interface{}, len(metadata)+1)
+    for name, current := range metadata {
+        result[name] = current
+    }
+    result[key] = value
+    return result
+}
+
+// withoutMetadata returns a copy of the metadata without the entry, or nil if nothing else is left
+func withoutMetadata(metadata map[string]interface{}, key string) map[string]interface{} {
+    var result map[string]interface{}
+    for name, value := range metadata {
+        if name == key {
+            continue
+        }
+        if result == nil {
+            result = make(map[string]interface{}, len(metadata))
+        }
+        result[name] = value
+    }
+    return result
+}
+
+// ToV2 converts the artifact to the newer schema.
+// The artifact keeps the ID it had in the newer schema, if any; otherwise the ID is its index.
+func (a *Artifact) ToV2() *ArtifactV2 {
+    result := &ArtifactV2{
+        ArtifactID:  strconv.Itoa(a.Index),
+        Name:        a.Name,
+        Description: a.Description,
+        Parts:       a.Parts,
+        Metadata:    a.Metadata,
+    }
+    if id, ok := a.Metadata[ArtifactIDMetadataKey].(string); ok && id != "" {
+        result.ArtifactID = id
+        result.Metadata = withoutMetadata(a.Metadata, ArtifactIDMetadataKey)
+    }
+    return result
+}
+
+// ToLegacy converts the artifact to the original schema.
+// A numeric artifact ID becomes the index of the artifact; any other ID is kept in the metadata
+// under ArtifactIDMetadataKey with index 0. Convert the artifacts of one task with TaskV2.ToLegacy,
+// or receive them on a TaskEventStream, so that each ID gets its own index.
+func (a *ArtifactV2) ToLegacy() *Artifact {
+    return a.toLegacy(newArtifactIndexes())
+}
+
+// toLegacy converts the artifact to the original schema with the index assigned to its ID
+func (a *ArtifactV2) toLegacy(indexes *artifactIndexes) *Artifact {
+    result := &Artifact{
+        Name:        a.Name,
+        Description: a.Description,
+        Parts:       a.Parts,
+        Index:       indexes.index(a.ArtifactID),
+        Metadata:    a.Metadata,
+    }
+    if a.ArtifactID != strconv.Itoa(result.Index) {
+        result.Metadata = withMetadata(a.Metadata, ArtifactIDMetadataKey, a.ArtifactID)
+    }
+    return result
+}
+
+// artifactIndexes assigns the original schema index of the artifacts of a task by their ID
+type artifactIndexes struct {
+    ids  map[string]int
+    used map[int]bool
+}
+
+// newArtifactIndexes creates an empty assignment
+func newArtifactIndexes() *artifactIndexes {
+    return &artifactIndexes{ids: make(map[string]int), used: make(map[int]bool)}
+}
+
+// index returns the index of the artifact ID: its number if it is numeric and still free,
+// otherwise the lowest free index
+func (x *artifactIndexes) index(id string) int {
+    if index, ok := x.ids[id]; ok {
+        return index
+    }
+    index, err := strconv.Atoi(id)
+    if err != nil || index < 0 || strconv.Itoa(index) != id || x.used[index] {
+        for index = 0; x.used[index]; index++ {
+        }
+    }
+    x.ids[id] = index
+    x.used[index] = true
+    return index
+}
+
+// statusToV2 converts a task status to the newer schema
+func statusToV2(status TaskStatus, contextID, taskID string) TaskStatusV2 {
+    result := TaskStatusV2{State: status.State, Timestamp: status.Timestamp}
+    if status.Message != nil {
+        result.Message = status.Message.toV2(derivedMessageID(taskID, status.Message, 0))
+        result.Message.ContextID = contextID
+        result.Message.TaskID = taskID
+    }
+    return result
+}
+
+// statusToLegacy converts a task status to the original schema.
+// States the original schema lacks are mapped to their closest equivalent.
+func statusToLegacy(status TaskStatusV2) TaskStatus {
+    result := TaskStatus{State: status.State, Timestamp: status.Timestamp}
+    switch status.State {
+    case TaskStateRejected:
+        result.State = TaskStateFailed
+    case TaskStateAuthRequired:
+        result.State = TaskStateInputRequired
+    }
+    if status.Message != nil {
+        result.Message = status.Message.ToLegacy()
+    }
+    return result
+}
+
+// ToV2 converts the task to the newer schema; the session ID becomes the context ID.
+// Messages that kept no ID from the newer schema get one derived from the task, stable across conversions.
+func (t *Task) ToV2() *TaskV2 {
+    result := &TaskV2{
+        Kind:     KindTask,
+        ID:       t.ID,
+        Metadata: t.Metadata,
+    }
+    if t.SessionID != nil {
+        result.ContextID = *t.SessionID
+    }
+    result.Status = statusToV2(t.Status, result.ContextID, t.ID)
+    for i := range t.Artifacts {
+        result.Artifacts = append(result.Artifacts, *t.Artifacts[i].ToV2())
+    }
+    occurrences := make(map[string]int)
+    for i := range t.History {
+        data, _ := json.Marshal(&t.History[i])
+        message := t.History[i].toV2(derivedMessageID(t.ID, &t.History[i], occurrences[string(data)]))
+        occurrences[string(data)]++
+        message.ContextID = result.ContextID
+        message.TaskID = t.ID
+        result.History = append(result.History, *message)
+    }
+    return result
+}
+
+// ToLegacy converts the task to the original schema; the context ID becomes the session ID
+func (t *TaskV2) ToLegacy() *Task {
+    result := &Task{
+        ID:       t.ID,
+        Status:   statusToLegacy(t.Status),
+        Metadata: t.Metadata,
+    }
+    if t.ContextID != "" {
+        result.WithSessionID(t.ContextID)
+    }
+    indexes := newArtifactIndexes()
+    for i := range t.Artifacts {
+        result.AddArtifact(*t.Artifacts[i].toLegacy(indexes))
+    }
+    for i := range t.History {
+        result.AddToHistory(*t.History[i].ToLegacy())
+    }
+    return result
+}
+
+// ToV2 converts the event to the newer schema within the given context
+func (e *TaskStatusUpdateEvent) ToV2(contextID string) *TaskStatusUpdateEventV2 {
+    return &TaskStatusUpdateEventV2{
+        Kind:      KindStatusUpdate,
+        TaskID:    e.ID,
+        ContextID: contextID,
+        Status:    statusToV2(e.Status, contextID, e.ID),
+        Final:     e.Final,
+        Metadata:  e.Metadata,
+    }
+}
+
+// ToLegacy converts the event to the original schema
+func (e *TaskStatusUpdateEventV2) ToLegacy() *TaskStatusUpdateEvent {
+    return &TaskStatusUpdateEvent{
+        ID:       e.TaskID,
+        Status:   statusToLegacy(e.Status),
+        Final:    e.Final,
+        Metadata: e.Metadata,
+    }
+}
+
+// ToV2 converts the event to the newer schema within the given context.
+// The append and lastChunk flags move from the artifact to the event.
+func (e *TaskArtifactUpdateEvent) ToV2(contextID string) *TaskArtifactUpdateEventV2 {
+    return &TaskArtifactUpdateEventV2{
+        Kind:      KindArtifactUpdate,
+        TaskID:    e.ID,
+        ContextID: contextID,
+        Artifact:  *e.Artifact.ToV2(),
+        Append:    e.Artifact.Append,
+        LastChunk: e.Artifact.LastChunk,
+        Metadata:  e.Metadata,
+    }
+}
+
+// ToLegacy converts the event to the original schema; the artifact is converted as by ArtifactV2.ToLegacy
+func (e *TaskArtifactUpdateEventV2) ToLegacy() *TaskArtifactUpdateEvent {
+    return e.toLegacy(newArtifactIndexes())
+}
+
+// toLegacy converts the event to the original schema with the artifact indexes of its stream
+func (e *TaskArtifactUpdateEventV2) toLegacy(indexes *artifactIndexes) *TaskArtifactUpdateEvent {
+    artifact := e.Artifact.toLegacy(indexes)
+    artifact.Append = e.Append
+    artifact.LastChunk = e.LastChunk
+    return &TaskArtifactUpdateEvent{
+        ID:       e.TaskID,
+        Artifact: *artifact,
+        Metadata: e.Metadata,
+    }
+}
+
+// ToV2 converts tasks/send params to message/send params.
+// The task and session IDs travel on the message as taskId and contextId.
+func (p *TaskSendParams) ToV2() *MessageSendParams {
+    message := p.Message.ToV2()
+    message.TaskID = p.ID
+    message.ContextID = p.SessionID
+    result := &MessageSendParams{
+        Message:  *message,
+        Metadata: p.Metadata,
+    }
+    if p.PushNotification != nil || p.HistoryLength != nil {
+        result.Configuration = &MessageSendConfiguration{
+            PushNotificationConfig: p.PushNotification,
+            HistoryLength:          p.HistoryLength,
+        }
+    }
+    return result
+}
+
+// ToLegacy converts message/send params to tasks/send params.
+// A message that does not continue a task starts a new one with a generated ID.
+func (p *MessageSendParams) ToLegacy() *TaskSendParams {
+    result := &TaskSendParams{
+        ID:        p.Message.TaskID,
+        SessionID: p.Message.ContextID,
+        Message:   *p.Message.ToLegacy(),
+        Metadata:  p.Metadata,
+    }
+    if result.ID == "" {
+        result.ID = randomID()
+    }
+    if p.Configuration != nil {
+        result.PushNotification = p.Configuration.PushNotificationConfig
+        result.HistoryLength = p.Configuration.HistoryLength
+    }
+    return result
+}
+
+// ToV2 converts the push notification configuration of a task to the newer schema
+func (c *TaskPushNotificationConfig) ToV2() *TaskPushNotificationConfigV2 {
+    return &TaskPushNotificationConfigV2{TaskID: c.ID, PushNotificationConfig: c.PushNotificationConfig}
+}
+
+// ToLegacy converts the push notification configuration of a task to the original schema
+func (c *TaskPushNotificationConfigV2) ToLegacy() *TaskPushNotificationConfig {
+    return NewTaskPushNotificationConfig(c.TaskID, c.PushNotificationConfig)
+}
@@ -0,0 +1,692 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the JSON-RPC HTTP server that dispatches A2A methods to task handlers
+package a2a
+
+import (
+    "context"
+    "crypto/tls"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net"
+    "net/http"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// TaskSendFunc handles a tasks/send request
+type TaskSendFunc func(ctx context.Context, params *TaskSendParams) (*Task, error)
+
+// TaskGetFunc handles a tasks/get request
+type TaskGetFunc func(ctx context.Context, params *TaskQueryParams) (*Task, error)
+
+// TaskCancelFunc handles a tasks/cancel request
+type TaskCancelFunc func(ctx context.Context, params *TaskIdParams) (*Task, error)
+
+// NotifyErrorFunc is called when the push notification of a task cannot be queued or its configuration kept
+type NotifyErrorFunc func(task *Task, err error)
+
+// SetTaskPushNotificationFunc handles a tasks/pushNotification/set request
+type SetTaskPushNotificationFunc func(ctx context.Context, params *TaskPushNotificationConfig) (*TaskPushNotificationConfig, error)
+
+// GetTaskPushNotificationFunc handles a tasks/pushNotification/get request
+type GetTaskPushNotificationFunc func(ctx context.Context, params *TaskIdParams) (*TaskPushNotificationConfig, error)
+
+// TaskHandler implements the request/response methods of the A2A protocol.
+// Returning a *JSONRPCError sends that error to the caller; any other error
+// is reported as an internal error.
+type TaskHandler interface {
+    SendTask(ctx context.Context, params *TaskSendParams) (*Task, error)
+    GetTask(ctx context.Context, params *TaskQueryParams) (*Task, error)
+    CancelTask(ctx context.Context, params *TaskIdParams) (*Task, error)
+    SetTaskPushNotification(ctx context.Context, params *TaskPushNotificationConfig) (*TaskPushNotificationConfig, error)
+    GetTaskPushNotification(ctx context.Context, params *TaskIdParams) (*TaskPushNotificationConfig, error)
+}
+
+// ProtocolHandler is an http.Handler that decodes JSON-RPC requests and
+// dispatches each A2A method to the registered handler function.
+// GET requests for AgentCardPath are answered with the agent card.
+type ProtocolHandler struct {
+    card                    *AgentCard
+    sendTask                TaskSendFunc
+    getTask                 TaskGetFunc
+    cancelTask              TaskCancelFunc
+    setTaskPushNotification SetTaskPushNotificationFunc
+    getTaskPushNotification GetTaskPushNotificationFunc
+    sendTaskSubscribe       TaskSendSubscribeFunc
+    resubscribeTask         TaskResubscribeFunc
+    store                   TaskStore
+    notifier                *Notifier
+    pushMu                  sync.Mutex
+    pushConfigs             *MemoryDeliveryStore
+    maxBatchSize            int
+    batchConcurrency        int
+    events                  *EventLog
+    wsPingInterval          *time.Duration
+    onNotifyError           NotifyErrorFunc
+}
+
+// NewProtocolHandler creates a new protocol handler for the given agent
+func NewProtocolHandler(card *AgentCard) *ProtocolHandler {
+    return &ProtocolHandler{
+        card: card,
+    }
+}
+
+// WithTaskHandler registers every method of the given task handler,
+// including the streaming methods when it implements StreamingTaskHandler
+func (h *ProtocolHandler) WithTaskHandler(handler TaskHandler) *ProtocolHandler {
+    h.sendTask = handler.SendTask
+    h.getTask = handler.GetTask
+    h.cancelTask = handler.CancelTask
+    h.setTaskPushNotification = handler.SetTaskPushNotification
+    h.getTaskPushNotification = handler.GetTaskPushNotification
+    if streaming, ok := handler.(StreamingTaskHandler); ok {
+        h.sendTaskSubscribe = streaming.SendTaskSubscribe
+        h.resubscribeTask = streaming.ResubscribeTask
+    }
+    return h
+}
+
+// WithTaskStore sets the store that keeps the tasks returned by tasks/send.
+// The store also answers tasks/get and tasks/cancel when no handler is registered for them.
+func (h *ProtocolHandler) WithTaskStore(store TaskStore) *ProtocolHandler {
+    h.store = store
+    return h
+}
+
+// WithNotifier sets the notifier that delivers push notifications for tasks.
+// When the agent card advertises PushNotifications, the handler then keeps the
+// push configuration of each task, answers tasks/pushNotification/set and get
+// when no handler is registered for them, and notifies the configured URL
+// whenever tasks/send or tasks/cancel returns a task.
+func (h *ProtocolHandler) WithNotifier(notifier *Notifier) *ProtocolHandler {
+    h.notifier = notifier
+    return h
+}
+
+// OnNotifyError sets the function called when the push notification of a task cannot be queued,
+// or its push configuration cannot be loaded or removed, such as when the delivery store fails.
+// By default the failure is written to the standard logger.
+func (h *ProtocolHandler) OnNotifyError(fn NotifyErrorFunc) *ProtocolHandler {
+    h.onNotifyError = fn
+    return h
+}
+
+// HandleTaskSend registers the handler for tasks/send
+func (h *ProtocolHandler) HandleTaskSend(fn TaskSendFunc) *ProtocolHandler {
+    h.sendTask = fn
+    return h
+}
+
+// HandleTaskGet registers the handler for tasks/get
+func (h *ProtocolHandler) HandleTaskGet(fn TaskGetFunc) *ProtocolHandler {
+    h.getTask = fn
+    return h
+}
+
+// HandleTaskCancel registers the handler for tasks/cancel
+func (h *ProtocolHandler) HandleTaskCancel(fn TaskCancelFunc) *ProtocolHandler {
+    h.cancelTask = fn
+    return h
+}
+
+// HandleSetTaskPushNotification registers the handler for tasks/pushNotification/set
+func (h *ProtocolHandler) HandleSetTaskPushNotification(fn SetTaskPushNotificationFunc) *ProtocolHandler {
+    h.setTaskPushNotification = fn
+    return h
+}
+
+// HandleGetTaskPushNotification registers the handler for tasks/pushNotification/get
+func (h *ProtocolHandler) HandleGetTaskPushNotification(fn GetTaskPushNotificationFunc) *ProtocolHandler {
+    h.getTaskPushNotification = fn
+    return h
+}
+
+// Card returns the agent card served by this handler
+func (h *ProtocolHandler) Card() *AgentCard {
+    return h.card
+}
+
+// rpcRequest is the wire form of an incoming JSON-RPC request.
+// The ID and params are kept raw so they can be echoed and decoded per method.
+type rpcRequest struct {
+    JSONRPC string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id,omitempty"`
+    Method  string          `json:"method"`
+    Params  json.RawMessage `json:"params,omitempty"`
+
+    // version is the protocol version the request is answered in
+    version ProtocolVersion
+    // lastEventID is the ID of the last stream event a reconnecting client received
+    lastEventID uint64
+    // value holds the params of a request handed over in process, which are copied rather than decoded
+    value interface{}
+}
+
+// responseID returns the ID to echo in the response
+func (r *rpcRequest) responseID() interface{} {
+    if len(r.ID) == 0 {
+        return nil
+    }
+    return r.ID
+}
+
+// ServeHTTP implements http.Handler
+func (h *ProtocolHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.URL.Path == AgentCardPath && h.card != nil && r.Method != http.MethodPost {
+        NewAgentCardHandler(h.card).ServeHTTP(w, r)
+        return
+    }
+    if isWebSocketUpgrade(r) {
+        h.serveWebSocket(w, r)
+        return
+    }
+    if r.Method != http.MethodPost {
+        w.Header().Set("Allow", http.MethodPost)
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    body, err := io.ReadAll(r.Body)
+    if err != nil {
+        writeJSON(w, http.StatusOK, NewJSONRPCErrorResponse(nil, InvalidRequestError().WithData(err.Error())))
+        return
+    }
+
+    if isBatch(body) {
+        h.serveBatch(w, r, body)
+        return
+    }
+
+    var request rpcRequest
+    if err := json.Unmarshal(body, &request); err != nil {
+        writeJSON(w, http.StatusOK, NewJSONRPCErrorResponse(nil, JSONParseError()))
+        return
+    }
+
+    request.version = h.requestVersion(request.Method, ProtocolVersion(r.Header.Get(ProtocolVersionHeader)))
+    request.lastEventID = parseEventID(r.Header.Get(LastEventIDHeader))
+    w.Header().Set(ProtocolVersionHeader, string(request.version))
+
+    if isStreamingMethod(request.Method) {
+        h.serveStream(w, r, &request)
+        return
+    }
+
+    response := h.handleRequest(r.Context(), &request)
+    if request.isNotification() {
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+    writeJSON(w, http.StatusOK, response)
+}
+
+// handleRequest dispatches a decoded request to the matching method handler
+func (h *ProtocolHandler) handleRequest(ctx context.Context, request *rpcRequest) *JSONRPCResponse {
+    if request.JSONRPC != JSONRPCVersion || request.Method == "" {
+        return NewJSONRPCErrorResponse(request.responseID(), InvalidRequestError())
+    }
+
+    if rpcErr := h.translateRequest(request); rpcErr != nil {
+        return NewJSONRPCErrorResponse(request.responseID(), rpcErr)
+    }
+    result, rpcErr := h.dispatch(ctx, request)
+    if rpcErr != nil {
+        return NewJSONRPCErrorResponse(request.responseID(), rpcErr)
+    }
+    return NewJSONRPCResponse(request.responseID(), translateResult(result, request.version))
+}
+
+// dispatch routes the request by method name
+func (h *ProtocolHandler) dispatch(ctx context.Context, request *rpcRequest) (interface{}, *JSONRPCError) {
+    switch request.Method {
+    case MethodSendTask:
+        var params TaskSendParams
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if err := params.Validate(); err != nil {
+            return nil, validationError(err)
+        }
+        if h.sendTask == nil {
+            return nil, UnsupportedOperationError()
+        }
+        // The configuration is in place before the handler runs, so updates it reports
+        // while the request is in progress are delivered; it is rolled back if the send fails
+        restore, err := h.replacePushConfig(params.ID, params.PushNotification)
+        if err != nil {
+            return nil, InternalError().WithData(err.Error())
+        }
+        task, err := h.sendTask(ctx, &params)
+        if err != nil {
+            restore()
+            return nil, toJSONRPCError(err)
+        }
+        if h.store != nil && task != nil {
+            if err := saveTask(ctx, h.store, task); errors.Is(err, ErrInvalidTransition) {
+                restore()
+                return nil, InvalidRequestError().WithData(err.Error())
+            } else if err != nil {
+                restore()
+                return nil, InternalError().WithData(err.Error())
+            }
+        }
+        h.notifyTask(task)
+        return task, nil
+
+    case MethodGetTask:
+        var params TaskQueryParams
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if rpcErr := requireTaskID(params.ID); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if h.getTask != nil {
+            task, err := h.getTask(ctx, &params)
+            return task, toJSONRPCError(err)
+        }
+        if h.store != nil {
+            return h.getStoredTask(ctx, &params)
+        }
+        return nil, UnsupportedOperationError()
+
+    case MethodCancelTask:
+        var params TaskIdParams
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if rpcErr := requireTaskID(params.ID); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if h.cancelTask != nil {
+            task, rpcErr := h.cancelWithHandler(ctx, &params)
+            if rpcErr != nil {
+                return nil, rpcErr
+            }
+            h.notifyTask(task)
+            return task, nil
+        }
+        if h.store != nil {
+            task, rpcErr := h.cancelStoredTask(ctx, &params)
+            if rpcErr != nil {
+                return nil, rpcErr
+            }
+            h.notifyTask(task)
+            return task, nil
+        }
+        return nil, UnsupportedOperationError()
+
+    case MethodSetTaskPushNotification:
+        var params TaskPushNotificationConfig
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if rpcErr := requireTaskID(params.ID); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if err := params.PushNotificationConfig.Validate(); err != nil {
+            return nil, validationError(err)
+        }
+        if h.setTaskPushNotification != nil {
+            config, err := h.setTaskPushNotification(ctx, &params)
+            return config, toJSONRPCError(err)
+        }
+        if !h.pushNotificationsEnabled() {
+            return nil, PushNotificationNotSupportedError()
+        }
+        if h.store != nil {
+            if _, _, err := h.store.Get(ctx, params.ID); err != nil {
+                return nil, storeError(err)
+            }
+        }
+        if err := h.pushConfigStore().SaveConfig(params.ID, params.PushNotificationConfig); err != nil {
+            return nil, InternalError().WithData(err.Error())
+        }
+        return &params, nil
+
+    case MethodGetTaskPushNotification:
+        var params TaskIdParams
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if rpcErr := requireTaskID(params.ID); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if h.getTaskPushNotification != nil {
+            config, err := h.getTaskPushNotification(ctx, &params)
+            return config, toJSONRPCError(err)
+        }
+        if !h.pushNotificationsEnabled() {
+            return nil, PushNotificationNotSupportedError()
+        }
+        config, err := h.pushConfigStore().Config(params.ID)
+        if err != nil {
+            return nil, InternalError().WithData(err.Error())
+        }
+        if config == nil {
+            return nil, TaskNotFoundError().WithData("no push notification configuration for task")
+        }
+        return NewTaskPushNotificationConfig(params.ID, *config), nil
+
+    case MethodSendTaskSubscribe, MethodResubscribeTask:
+        // Streaming methods need a transport that can push events
+        return nil, UnsupportedOperationError().WithData("streaming requires an event stream transport")
+
+    default:
+        return nil, MethodNotFoundError()
+    }
+}
+
+// getStoredTask answers tasks/get from the task store
+func (h *ProtocolHandler) getStoredTask(ctx context.Context, params *TaskQueryParams) (interface{}, *JSONRPCError) {
+    task, _, err := h.store.Get(ctx, params.ID)
+    if err != nil {
+        return nil, storeError(err)
+    }
+    trimHistory(task, params.HistoryLength)
+    return task, nil
+}
+
+// cancelStoredTask answers tasks/cancel by marking the stored task as canceled
+func (h *ProtocolHandler) cancelStoredTask(ctx context.Context, params *TaskIdParams) (*Task, *JSONRPCError) {
+    for {
+        task, version, err := h.store.Get(ctx, params.ID)
+        if err != nil {
+            return nil, storeError(err)
+        }
+        if err := h.card.TransitionTask(task, TaskStateCanceled, nil); err != nil {
+            return nil, TaskNotCancelableError().WithData(err.Error())
+        }
+        _, err = h.store.Update(ctx, task, version)
+        if errors.Is(err, ErrVersionConflict) {
+            continue
+        }
+        if err != nil {
+            return nil, storeError(err)
+        }
+        return task, nil
+    }
+}
+
+// cancelWithHandler answers tasks/cancel with the registered cancel handler.
+// With a task store, tasks that can no longer be canceled are rejected before the handler runs,
+// and the task it returns must follow the stored state.
+func (h *ProtocolHandler) cancelWithHandler(ctx context.Context, params *TaskIdParams) (*Task, *JSONRPCError) {
+    if h.store != nil {
+        stored, _, err := h.store.Get(ctx, params.ID)
+        if err == nil && !CanTransition(stored.Status.State, TaskStateCanceled) {
+            return nil, TaskNotCancelableError().WithData((&TransitionError{From: stored.Status.State, To: TaskStateCanceled}).Error())
+        }
+    }
+    task, err := h.cancelTask(ctx, params)
+    if err != nil {
+        return nil, toJSONRPCError(err)
+    }
+    if h.store != nil && task != nil {
+        if err := saveTask(ctx, h.store, task); errors.Is(err, ErrInvalidTransition) {
+            return nil, TaskNotCancelableError().WithData(err.Error())
+        } else if err != nil {
+            return nil, InternalError().WithData(err.Error())
+        }
+    }
+    return task, nil
+}
+
+// pushNotificationsEnabled reports whether the handler delivers push notifications itself
+func (h *ProtocolHandler) pushNotificationsEnabled() bool {
+    return h.notifier != nil && (h.card == nil || h.card.Capabilities.PushNotifications)
+}
+
+// pushConfigStore returns where the push notification configurations of tasks are kept:
+// the notifier's delivery store when it implements PushConfigStore, otherwise memory
+func (h *ProtocolHandler) pushConfigStore() PushConfigStore {
+    if store, ok := h.notifier.store.(PushConfigStore); ok {
+        return store
+    }
+    h.pushMu.Lock()
+    defer h.pushMu.Unlock()
+    if h.pushConfigs == nil {
+        h.pushConfigs = NewMemoryDeliveryStore()
+    }
+    return h.pushConfigs
+}
+
+// replacePushConfig sets the configuration sent with a task, if push notifications are enabled.
+// The returned function puts back the configuration the task had before.
+func (h *ProtocolHandler) replacePushConfig(taskID string, config *PushNotificationConfig) (func(), error) {
+    if config == nil || !h.pushNotificationsEnabled() {
+        return func() {}, nil
+    }
+    store := h.pushConfigStore()
+    previous, err := store.Config(taskID)
+    if err != nil {
+        return nil, err
+    }
+    if err := store.SaveConfig(taskID, *config); err != nil {
+        return nil, err
+    }
+    return func() {
+        if previous != nil {
+            store.SaveConfig(taskID, *previous)
+        } else {
+            store.RemoveConfig(taskID)
+        }
+    }, nil
+}
+
+// notifyTask queues a push notification of the task if one is configured.
+// Once queued, delivery failures are kept in the notifier's dead-letter queue; a notification that
+// cannot be queued is reported to the OnNotifyError function. Neither fails the request.
+// No update follows a terminal state, so the configuration is then removed, unless the
+// notification could not be queued and a later update of the task may still be delivered.
+func (h *ProtocolHandler) notifyTask(task *Task) {
+    if task == nil || !h.pushNotificationsEnabled() {
+        return
+    }
+    store := h.pushConfigStore()
+    config, err := store.Config(task.ID)
+    if err != nil {
+        h.notifyError(task, fmt.Errorf("a2a: loading push configuration: %w", err))
+        return
+    }
+    if config == nil {
+        return
+    }
+    if _, err := h.notifier.Notify(*config, task); err != nil {
+        h.notifyError(task, fmt.Errorf("a2a: queuing push notification: %w", err))
+        return
+    }
+    if task.Status.State.IsTerminal() {
+        if err := store.RemoveConfig(task.ID); err != nil {
+            h.notifyError(task, fmt.Errorf("a2a: removing push configuration: %w", err))
+        }
+    }
+}
+
+// notifyError reports a push notification of the task that could not be queued
+func (h *ProtocolHandler) notifyError(task *Task, err error) {
+    if h.onNotifyError != nil {
+        h.onNotifyError(task, err)
+        return
+    }
+    log.Printf("a2a: push notification of task %s: %v", task.ID, err)
+}
+
+// storeError converts an error returned by a task store into a JSON-RPC error
+func storeError(err error) *JSONRPCError {
+    if errors.Is(err, ErrTaskNotFound) {
+        return TaskNotFoundError()
+    }
+    return toJSONRPCError(err)
+}
+
+// decodeParams decodes the params of the request into v
+func (r *rpcRequest) decodeParams(v interface{}) *JSONRPCError {
+    if r.value == nil {
+        return decodeParams(r.Params, v)
+    }
+    if !copyParams(v, r.value) {
+        return InvalidParamsError().WithData(fmt.Sprintf("unexpected params %T", r.value))
+    }
+    return nil
+}
+
+// decodeParams decodes the raw params of a request into v
+func decodeParams(raw json.RawMessage, v interface{}) *JSONRPCError {
+    if len(raw) == 0 {
+        return InvalidParamsError().WithData("params are required")
+    }
+    if err := json.Unmarshal(raw, v); err != nil {
+        return InvalidParamsError().WithData(err.Error())
+    }
+    return nil
+}
+
+// requireTaskID checks that the task ID of the params is set
+func requireTaskID(id string) *JSONRPCError {
+    if id == "" {
+        return InvalidParamsError().WithData("task id is required")
+    }
+    return nil
+}
+
+// writeJSON writes v as a JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+// ListenAndServe starts an A2A server for this handler on the given address
+func (h *ProtocolHandler) ListenAndServe(addr string) error {
+    config := DefaultServerConfig()
+    host, port, err := net.SplitHostPort(addr)
+    if err != nil {
+        return err
+    }
+    config.Host = host
+    if port != "" {
+        config.Port, err = strconv.Atoi(port)
+        if err != nil {
+            return err
+        }
+    }
+    return NewServer(config, h).ListenAndServe()
+}
+
+// ServerConfig holds the HTTP server settings of an A2A server
+type ServerConfig struct {
+    Host           string
+    Port           int
+    ReadTimeout    time.Duration
+    WriteTimeout   time.Duration
+    MaxMessageSize int64
+    EnableCORS     bool
+    // TLSConfig serves HTTPS when set; request client certificates in it for mutual TLS
+    TLSConfig *tls.Config
+    // Auth enforces the authentication schemes of the agent card when set
+    Auth *AuthMiddleware
+}
+
+// DefaultServerConfig returns the default server configuration
+func DefaultServerConfig() *ServerConfig {
+    return &ServerConfig{
+        Port:           8080,
+        ReadTimeout:    30 * time.Second,
+        MaxMessageSize: 10 * 1024 * 1024,
+    }
+}
+
+// Server serves a ProtocolHandler over HTTP
+type Server struct {
+    config     *ServerConfig
+    handler    *ProtocolHandler
+    httpServer *http.Server
+}
+
+// NewServer creates a new A2A server
+func NewServer(config *ServerConfig, handler *ProtocolHandler) *Server {
+    if config == nil {
+        config = DefaultServerConfig()
+    }
+    s := &Server{
+        config:  config,
+        handler: handler,
+    }
+    s.httpServer = &http.Server{
+        Addr:         net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+        Handler:      s.Handler(),
+        ReadTimeout:  config.ReadTimeout,
+        WriteTimeout: config.WriteTimeout,
+        TLSConfig:    config.TLSConfig,
+        ConnContext:  PeerCredentialsContext,
+    }
+    return s
+}
+
+// Handler returns the http.Handler of the server with its configured limits applied
+func (s *Server) Handler() http.Handler {
+    var handler http.Handler = s.handler
+    if s.config.Auth != nil {
+        handler = s.config.Auth.Wrap(handler)
+    }
+    if s.config.MaxMessageSize > 0 {
+        handler = http.MaxBytesHandler(handler, s.config.MaxMessageSize)
+    }
+    if s.config.EnableCORS {
+        handler = corsHandler(handler)
+    }
+    return handler
+}
+
+// ListenAndServe listens on the configured address and serves requests
+func (s *Server) ListenAndServe() error {
+    var err error
+    if s.config.TLSConfig != nil {
+        err = s.httpServer.ListenAndServeTLS("", "")
+    } else {
+        err = s.httpServer.ListenAndServe()
+    }
+    if errors.Is(err, http.ErrServerClosed) {
+        return nil
+    }
+    return err
+}
+
+// Serve accepts connections on the listener and serves requests
+func (s *Server) Serve(listener net.Listener) error {
+    var err error
+    if s.config.TLSConfig != nil {
+        err = s.httpServer.ServeTLS(listener, "", "")
+    } else {
+        err = s.httpServer.Serve(listener)
+    }
+    if errors.Is(err, http.ErrServerClosed) {
+        return nil
+    }
+    return err
+}
+
+// Shutdown gracefully stops the server
+func (s *Server) Shutdown(ctx context.Context) error {
+    return s.httpServer.Shutdown(ctx)
+}
+
+// corsHandler adds permissive CORS headers and answers preflight requests
+func corsHandler(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Access-Control-Allow-Origin", "*")
+        w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+        if r.Method == http.MethodOptions {
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
@@ -0,0 +1,98 @@
+package a2a_test
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func postJSONRPC(t *testing.T, handler http.Handler, body string) *a2a.JSONRPCResponse {
+    t.Helper()
+    req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, req)
+
+    if rec.Code != http.StatusOK {
+        t.Fatalf("Status mismatch: expected %d, got %d", http.StatusOK, rec.Code)
+    }
+    response, err := a2a.ResponseFromJSON(rec.Body.Bytes())
+    if err != nil {
+        t.Fatalf("Failed to parse response: %v", err)
+    }
+    return response
+}
+
+func TestProtocolHandlerDispatch(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, nil)
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        }).
+        HandleTaskGet(func(ctx context.Context, params *a2a.TaskQueryParams) (*a2a.Task, error) {
+            return nil, a2a.TaskNotFoundError()
+        })
+
+    request := a2a.NewProtocol().CreateSendTaskRequest("req-1", a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")}),
+    })
+    body, err := request.ToJSON()
+    if err != nil {
+        t.Fatalf("Failed to serialize request: %v", err)
+    }
+
+    response := postJSONRPC(t, handler, string(body))
+    if response.IsError() {
+        t.Fatalf("Unexpected error: %v", response.Error.Message)
+    }
+    if response.ID != "req-1" {
+        t.Errorf("ID mismatch: expected %s, got %v", "req-1", response.ID)
+    }
+    result, _ := json.Marshal(response.Result)
+    task, err := a2a.TaskFromJSON(result)
+    if err != nil {
+        t.Fatalf("Failed to parse task: %v", err)
+    }
+    if task.ID != "task-1" || task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("Unexpected task: %s %s", task.ID, task.Status.State)
+    }
+
+    tests := []struct {
+        name string
+        body string
+        code int
+    }{
+        {"handler error", `{"jsonrpc":"2.0","id":2,"method":"tasks/get","params":{"id":"missing"}}`, a2a.ErrCodeTaskNotFound},
+        {"parse error", `{"jsonrpc":`, a2a.ErrCodeParseError},
+        {"invalid request", `{"jsonrpc":"1.0","id":3,"method":"tasks/get"}`, a2a.ErrCodeInvalidRequest},
+        {"unknown method", `{"jsonrpc":"2.0","id":4,"method":"tasks/unknown"}`, a2a.ErrCodeMethodNotFound},
+        {"missing task id", `{"jsonrpc":"2.0","id":5,"method":"tasks/get","params":{}}`, a2a.ErrCodeInvalidParams},
+        {"unregistered method", `{"jsonrpc":"2.0","id":6,"method":"tasks/cancel","params":{"id":"t"}}`, a2a.ErrCodeUnsupportedOperation},
+        {"push not supported", `{"jsonrpc":"2.0","id":7,"method":"tasks/pushNotification/get","params":{"id":"t"}}`, a2a.ErrCodePushNotificationNotSupported},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            response := postJSONRPC(t, handler, tt.body)
+            if !response.IsError() {
+                t.Fatalf("Expected error response")
+            }
+            if response.Error.Code != tt.code {
+                t.Errorf("Code mismatch: expected %d, got %d", tt.code, response.Error.Code)
+            }
+        })
+    }
+}
+
+func TestProtocolHandlerRejectsGet(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil)
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+    if rec.Code != http.StatusMethodNotAllowed {
+        t.Errorf("Status mismatch: expected %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+    }
+}
@@ -0,0 +1,282 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the stdio transport that runs A2A agents as subprocesses
+package a2a
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "os/exec"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// Framing is the way JSON-RPC messages are delimited on a byte stream
+type Framing int
+
+// Supported framings
+const (
+    // FramingNewline sends each message on one line
+    FramingNewline Framing = iota
+    // FramingContentLength precedes each message with a Content-Length header, as LSP does
+    FramingContentLength
+)
+
+// contentLengthHeader is the header announcing the size of a Content-Length framed message
+const contentLengthHeader = "Content-Length"
+
+// DefaultStdioMaxMessageSize is the size limit of the messages read over stdio
+const DefaultStdioMaxMessageSize = 16 << 20
+
+// ErrStdioMessageTooLarge is returned when a peer sends a message above the size limit
+var ErrStdioMessageTooLarge = errors.New("a2a: stdio message too large")
+
+// frameReader reads messages in either framing, recognizing each by its first line
+type frameReader struct {
+    reader         *bufio.Reader
+    maxMessageSize int64
+}
+
+// newFrameReader creates a reader of framed messages
+func newFrameReader(r io.Reader) *frameReader {
+    return &frameReader{reader: bufio.NewReader(r), maxMessageSize: DefaultStdioMaxMessageSize}
+}
+
+// readLine reads up to and including the next newline, failing once the line exceeds the size limit
+func (r *frameReader) readLine() (string, error) {
+    var line []byte
+    for {
+        chunk, err := r.reader.ReadSlice('\n')
+        line = append(line, chunk...)
+        // The line may end with \r\n on top of the message
+        if r.maxMessageSize > 0 && int64(len(line)) > r.maxMessageSize+2 {
+            return "", ErrStdioMessageTooLarge
+        }
+        if err != bufio.ErrBufferFull {
+            return string(line), err
+        }
+    }
+}
+
+// readFrame returns the next message and its framing, or io.EOF at the end of the stream.
+// Messages above the size limit fail with ErrStdioMessageTooLarge before they are read.
+func (r *frameReader) readFrame() ([]byte, Framing, error) {
+    for {
+        line, err := r.readLine()
+        if err != nil && (err != io.EOF || line == "") {
+            return nil, 0, err
+        }
+        line = strings.TrimRight(line, "\r\n")
+        if strings.TrimSpace(line) == "" {
+            if err == io.EOF {
+                return nil, 0, io.EOF
+            }
+            continue
+        }
+
+        name, value, found := strings.Cut(line, ":")
+        if !found || !strings.EqualFold(strings.TrimSpace(name), contentLengthHeader) {
+            return []byte(line), FramingNewline, nil
+        }
+        length, err := strconv.Atoi(strings.TrimSpace(value))
+        if err != nil || length < 0 {
+            return nil, 0, fmt.Errorf("a2a: invalid %s %q", contentLengthHeader, value)
+        }
+        if r.maxMessageSize > 0 && int64(length) > r.maxMessageSize {
+            return nil, 0, fmt.Errorf("%w: %s %d exceeds %d bytes", ErrStdioMessageTooLarge, contentLengthHeader, length, r.maxMessageSize)
+        }
+        // Skip the remaining headers up to the blank line
+        for {
+            header, err := r.readLine()
+            if errors.Is(err, ErrStdioMessageTooLarge) {
+                return nil, 0, err
+            }
+            if err != nil {
+                return nil, 0, io.ErrUnexpectedEOF
+            }
+            if strings.TrimRight(header, "\r\n") == "" {
+                break
+            }
+        }
+        data := make([]byte, length)
+        if _, err := io.ReadFull(r.reader, data); err != nil {
+            return nil, 0, io.ErrUnexpectedEOF
+        }
+        return data, FramingContentLength, nil
+    }
+}
+
+// writeFrame writes one message in the given framing
+func writeFrame(w io.Writer, framing Framing, data []byte) error {
+    if framing == FramingContentLength {
+        if _, err := fmt.Fprintf(w, "%s: %d\r\n\r\n", contentLengthHeader, len(data)); err != nil {
+            return err
+        }
+        _, err := w.Write(data)
+        return err
+    }
+    // Marshaled JSON holds no raw newlines, so one message fits on one line
+    _, err := w.Write(append(data, '\n'))
+    return err
+}
+
+// StdioServer serves a ProtocolHandler over a pair of byte streams, such as the standard
+// input and output of an agent run as a subprocess. Requests are read one per frame and
+// answered concurrently; the events of streaming requests are sent as MethodStreamEvent
+// notifications interleaved with the other responses.
+type StdioServer struct {
+    handler *ProtocolHandler
+    framing *Framing
+}
+
+// NewStdioServer creates a stdio server for the handler
+func NewStdioServer(handler *ProtocolHandler) *StdioServer {
+    return &StdioServer{handler: handler}
+}
+
+// WithFraming sets the framing of the messages written.
+// By default messages are written in the framing of the last message read.
+func (s *StdioServer) WithFraming(framing Framing) *StdioServer {
+    s.framing = &framing
+    return s
+}
+
+// Serve answers the requests read from r on w until r ends.
+// Requests in progress are canceled when ctx is done or r ends.
+func (s *StdioServer) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+    return s.handler.serveFrames(ctx, &stdioConn{reader: newFrameReader(r), w: w, framing: s.framing}, "", 0)
+}
+
+// ServeStdio serves the handler on the standard input and output of the process
+func (h *ProtocolHandler) ServeStdio(ctx context.Context) error {
+    return NewStdioServer(h).Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// stdioConn is the frameConn of a stdio server
+type stdioConn struct {
+    reader  *frameReader
+    w       io.Writer
+    framing *Framing
+
+    // peerFraming is the framing of the last message read
+    mu          sync.Mutex
+    peerFraming Framing
+}
+
+// readFrame implements frameConn
+func (c *stdioConn) readFrame() ([]byte, error) {
+    data, framing, err := c.reader.readFrame()
+    if err == nil {
+        c.mu.Lock()
+        c.peerFraming = framing
+        c.mu.Unlock()
+    }
+    return data, err
+}
+
+// writeFrame implements frameConn
+func (c *stdioConn) writeFrame(data []byte) error {
+    c.mu.Lock()
+    framing := c.peerFraming
+    c.mu.Unlock()
+    if c.framing != nil {
+        framing = *c.framing
+    }
+    return writeFrame(c.w, framing, data)
+}
+
+// StdioTransport is a Transport speaking to an agent over a pair of byte streams,
+// typically the standard input and output of an agent started with SpawnStdioAgent
+type StdioTransport struct {
+    mux     *rpcMux
+    w       io.WriteCloser
+    framing Framing
+    cmd     *exec.Cmd
+
+    writeMu   sync.Mutex
+    closeOnce sync.Once
+    closeErr  error
+}
+
+// NewStdioTransport creates a transport writing requests to w and reading responses from r
+func NewStdioTransport(r io.Reader, w io.WriteCloser) *StdioTransport {
+    t := &StdioTransport{w: w}
+    t.mux = newRPCMux(t.write)
+    go t.readLoop(newFrameReader(r))
+    return t
+}
+
+// SpawnStdioAgent starts the agent command and returns a transport speaking to it over its
+// standard input and output. The standard error of the command is left as configured.
+func SpawnStdioAgent(cmd *exec.Cmd) (*StdioTransport, error) {
+    stdin, err := cmd.StdinPipe()
+    if err != nil {
+        return nil, err
+    }
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return nil, err
+    }
+    if err := cmd.Start(); err != nil {
+        return nil, err
+    }
+    t := NewStdioTransport(stdout, stdin)
+    t.cmd = cmd
+    return t, nil
+}
+
+// WithFraming sets the framing of the requests written
+func (t *StdioTransport) WithFraming(framing Framing) *StdioTransport {
+    t.writeMu.Lock()
+    defer t.writeMu.Unlock()
+    t.framing = framing
+    return t
+}
+
+// Call implements Transport
+func (t *StdioTransport) Call(ctx context.Context, request []byte) ([]byte, error) {
+    return t.mux.call(ctx, request)
+}
+
+// Stream implements Transport
+func (t *StdioTransport) Stream(ctx context.Context, request []byte) (EventReader, error) {
+    return t.mux.stream(ctx, request)
+}
+
+// Close implements Transport. It closes the input of the agent and, for a spawned agent,
+// waits for the process to exit.
+func (t *StdioTransport) Close() error {
+    t.closeOnce.Do(func() {
+        t.mux.fail(ErrTransportClosed)
+        t.closeErr = t.w.Close()
+        if t.cmd != nil {
+            if err := t.cmd.Wait(); err != nil {
+                t.closeErr = err
+            }
+        }
+    })
+    return t.closeErr
+}
+
+// write sends one request
+func (t *StdioTransport) write(data []byte) error {
+    t.writeMu.Lock()
+    defer t.writeMu.Unlock()
+    return writeFrame(t.w, t.framing, data)
+}
+
+// readLoop dispatches the messages of the agent until its output ends
+func (t *StdioTransport) readLoop(reader *frameReader) {
+    for {
+        frame, _, err := reader.readFrame()
+        if err != nil {
+            t.mux.fail(err)
+            return
+        }
+        t.mux.dispatch(frame)
+    }
+}
@@ -0,0 +1,141 @@
+package a2a_test
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "io"
+    "os"
+    "os/exec"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+// newTransportAgent returns the handler of the agent served over the transports in these tests
+func newTransportAgent() *a2a.ProtocolHandler {
+    return a2a.NewProtocolHandler(nil).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        }).
+        HandleTaskGet(func(ctx context.Context, params *a2a.TaskQueryParams) (*a2a.Task, error) {
+            return nil, a2a.TaskNotFoundError()
+        }).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            stream.WriteArtifactUpdate(a2a.TaskArtifactUpdateEvent{ID: params.ID, Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("out")})})
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+        })
+}
+
+// exerciseTransport sends requests over the transport and checks the answers of newTransportAgent
+func exerciseTransport(t *testing.T, transport a2a.Transport) {
+    t.Helper()
+    ctx := context.Background()
+    client := a2a.NewClient().WithTransport("stdio://agent", transport)
+    params := &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }
+
+    task, err := client.SendTask(ctx, params, "stdio://agent")
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("State mismatch: expected %s, got %s", a2a.TaskStateCompleted, task.Status.State)
+    }
+    if _, err := client.GetTask(ctx, &a2a.TaskQueryParams{ID: "task-1"}, "stdio://agent"); !errors.Is(err, a2a.ErrTaskNotFound) {
+        t.Errorf("Expected task not found, got %v", err)
+    }
+
+    stream, err := client.SendTaskSubscribe(ctx, params, "stdio://agent")
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+    events := collectEvents(t, stream)
+    if len(events) != 3 || events[1].Artifact == nil || !events[2].Status.Final {
+        t.Errorf("Unexpected stream events: %#v", events)
+    }
+}
+
+func TestStdioTransport(t *testing.T) {
+    requests, requestWriter := io.Pipe()
+    responses, responseWriter := io.Pipe()
+    served := make(chan error, 1)
+    go func() {
+        served <- a2a.NewStdioServer(newTransportAgent()).Serve(context.Background(), requests, responseWriter)
+    }()
+
+    transport := a2a.NewStdioTransport(responses, requestWriter)
+    exerciseTransport(t, transport)
+    if err := transport.Close(); err != nil {
+        t.Errorf("Close failed: %v", err)
+    }
+    if err := <-served; err != nil {
+        t.Errorf("Serve failed: %v", err)
+    }
+}
+
+func TestStdioContentLengthFraming(t *testing.T) {
+    request := `{"jsonrpc":"2.0","id":7,"method":"tasks/get","params":{"id":"task-1"}}`
+    input := strings.NewReader("Content-Length: " + strconv.Itoa(len(request)) + "\r\n\r\n" + request)
+    var output strings.Builder
+    if err := a2a.NewStdioServer(newTransportAgent()).Serve(context.Background(), input, &output); err != nil {
+        t.Fatalf("Serve failed: %v", err)
+    }
+
+    reader := bufio.NewReader(strings.NewReader(output.String()))
+    header, _ := reader.ReadString('\n')
+    if !strings.HasPrefix(header, "Content-Length: ") {
+        t.Fatalf("Response is not Content-Length framed: %q", output.String())
+    }
+    reader.ReadString('\n')
+    body, _ := io.ReadAll(reader)
+    response, err := a2a.ResponseFromJSON(body)
+    if err != nil {
+        t.Fatalf("Failed to parse response: %v", err)
+    }
+    if response.Error == nil || response.Error.Code != a2a.ErrCodeTaskNotFound {
+        t.Errorf("Expected task not found, got %#v", response)
+    }
+}
+
+func TestStdioContentLengthTooLarge(t *testing.T) {
+    // The announced body is refused before any of it is read or allocated
+    input := strings.NewReader("Content-Length: 1099511627776\r\n\r\n{}")
+    var output strings.Builder
+    err := a2a.NewStdioServer(newTransportAgent()).Serve(context.Background(), input, &output)
+    if !errors.Is(err, a2a.ErrStdioMessageTooLarge) {
+        t.Fatalf("Expected ErrStdioMessageTooLarge, got %v", err)
+    }
+    if output.Len() != 0 {
+        t.Errorf("Unexpected output: %q", output.String())
+    }
+}
+
+// TestStdioHelperProcess is the agent spawned by TestSpawnStdioAgent
+func TestStdioHelperProcess(t *testing.T) {
+    if os.Getenv("A2A_STDIO_HELPER") != "1" {
+        t.Skip("run as the agent of TestSpawnStdioAgent")
+    }
+    newTransportAgent().ServeStdio(context.Background())
+    os.Exit(0)
+}
+
+func TestSpawnStdioAgent(t *testing.T) {
+    cmd := exec.Command(os.Args[0], "-test.run=^TestStdioHelperProcess$")
+    cmd.Env = append(os.Environ(), "A2A_STDIO_HELPER=1")
+    cmd.Stderr = os.Stderr
+    transport, err := a2a.SpawnStdioAgent(cmd)
+    if err != nil {
+        t.Fatalf("SpawnStdioAgent failed: %v", err)
+    }
+    exerciseTransport(t, transport)
+    if err := transport.Close(); err != nil {
+        t.Errorf("Close failed: %v", err)
+    }
+}
@@ -0,0 +1,728 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements Server-Sent Events streaming for tasks/sendSubscribe and tasks/resubscribe
+package a2a
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// ErrStreamClosed is returned when writing to a stream that has already sent its final event
+var ErrStreamClosed = errors.New("a2a: stream closed")
+
+// TaskSendSubscribeFunc handles a tasks/sendSubscribe request by writing events to the stream
+type TaskSendSubscribeFunc func(ctx context.Context, params *TaskSendParams, stream *StreamWriter) error
+
+// TaskResubscribeFunc handles a tasks/resubscribe request by writing events to the stream
+type TaskResubscribeFunc func(ctx context.Context, params *TaskQueryParams, stream *StreamWriter) error
+
+// StreamingTaskHandler implements the streaming methods of the A2A protocol.
+// A TaskHandler passed to WithTaskHandler that also implements this interface
+// serves tasks/sendSubscribe and tasks/resubscribe as well.
+type StreamingTaskHandler interface {
+    SendTaskSubscribe(ctx context.Context, params *TaskSendParams, stream *StreamWriter) error
+    ResubscribeTask(ctx context.Context, params *TaskQueryParams, stream *StreamWriter) error
+}
+
+// HandleTaskSendSubscribe registers the handler for tasks/sendSubscribe
+func (h *ProtocolHandler) HandleTaskSendSubscribe(fn TaskSendSubscribeFunc) *ProtocolHandler {
+    h.sendTaskSubscribe = fn
+    return h
+}
+
+// HandleTaskResubscribe registers the handler for tasks/resubscribe
+func (h *ProtocolHandler) HandleTaskResubscribe(fn TaskResubscribeFunc) *ProtocolHandler {
+    h.resubscribeTask = fn
+    return h
+}
+
+// eventSink delivers the responses of a stream over a transport
+type eventSink interface {
+    // writeEvent sends a response; eventID is zero for responses without an event ID
+    writeEvent(response *SendTaskStreamingResponse, eventID uint64) error
+}
+
+// StreamWriter sends the events of a streaming request to the caller.
+// The stream is closed once a status update marked Final has been written.
+type StreamWriter struct {
+    id     interface{}
+    sink   eventSink
+    mu     sync.Mutex
+    closed bool
+
+    // version and contextID shape the events for the newer schema
+    version   ProtocolVersion
+    contextID string
+
+    // store receives the events written, folded into the stored task; artifacts is the index of the next artifact writer.
+    // Status messages join the stored history only when recordHistory is set.
+    store         TaskStore
+    artifacts     int
+    recordHistory bool
+
+    // events records the events written, assigning their IDs; message is the key of the message they answer
+    events  *EventLog
+    message string
+}
+
+// newStreamWriter creates a stream writer answering the request with the given ID
+func newStreamWriter(id interface{}, sink eventSink) *StreamWriter {
+    return &StreamWriter{
+        id:   id,
+        sink: sink,
+    }
+}
+
+// WriteStatusUpdate sends a status update event and closes the stream if it is final
+func (s *StreamWriter) WriteStatusUpdate(event TaskStatusUpdateEvent) error {
+    return s.write(event, nil, event.Final)
+}
+
+// WriteArtifactUpdate sends an artifact update event
+func (s *StreamWriter) WriteArtifactUpdate(event TaskArtifactUpdateEvent) error {
+    return s.write(event, nil, false)
+}
+
+// Closed reports whether the final event has been sent
+func (s *StreamWriter) Closed() bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.closed
+}
+
+// fail sends an error as the last response of the stream
+func (s *StreamWriter) fail(rpcErr *JSONRPCError) error {
+    return s.write(nil, rpcErr, true)
+}
+
+// write records an event on the stored task and in the event log, if any, and sends it on the stream.
+// An event the stored task cannot take, such as an invalid transition, is not sent.
+func (s *StreamWriter) write(result interface{}, rpcErr *JSONRPCError, final bool) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return ErrStreamClosed
+    }
+    if s.store != nil {
+        // The task is kept up to date even after the client went away
+        var err error
+        switch event := result.(type) {
+        case TaskStatusUpdateEvent:
+            err = applyStoredEvent(context.Background(), s.store, event.ID, &event, s.recordHistory)
+        case TaskArtifactUpdateEvent:
+            err = applyStoredEvent(context.Background(), s.store, event.ID, &event, s.recordHistory)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    var eventID uint64
+    if s.events != nil {
+        switch event := result.(type) {
+        case TaskStatusUpdateEvent:
+            eventID = s.events.appendEvent(&TaskEvent{Status: &event}, s.contextID, s.message)
+        case TaskArtifactUpdateEvent:
+            eventID = s.events.appendEvent(&TaskEvent{Artifact: &event}, s.contextID, s.message)
+        }
+    }
+    return s.send(result, rpcErr, final, eventID)
+}
+
+// relay sends an event already recorded in the event log
+func (s *StreamWriter) relay(event *TaskEvent, eventID uint64, contextID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return ErrStreamClosed
+    }
+    if s.contextID == "" {
+        s.contextID = contextID
+    }
+    if event.Status != nil {
+        return s.send(*event.Status, nil, event.Status.Final, eventID)
+    }
+    return s.send(*event.Artifact, nil, false, eventID)
+}
+
+// send writes one response to the sink; the caller holds s.mu
+func (s *StreamWriter) send(result interface{}, rpcErr *JSONRPCError, final bool, eventID uint64) error {
+    if final {
+        s.closed = true
+    }
+    if !s.version.IsLegacy() {
+        switch event := result.(type) {
+        case TaskStatusUpdateEvent:
+            result = event.ToV2(s.contextID)
+        case TaskArtifactUpdateEvent:
+            result = event.ToV2(s.contextID)
+        }
+    }
+    return s.sink.writeEvent(&SendTaskStreamingResponse{
+        JSONRPC: JSONRPCVersion,
+        ID:      s.id,
+        Result:  result,
+        Error:   rpcErr,
+    }, eventID)
+}
+
+// isStreamingMethod reports whether the method answers with an event stream
+func isStreamingMethod(method string) bool {
+    return method == MethodSendTaskSubscribe || method == MethodResubscribeTask || method == MethodStreamMessage
+}
+
+// streamFunc runs a streaming handler against a stream writer
+type streamFunc func(ctx context.Context, stream *StreamWriter) error
+
+// prepareStream decodes the params of a streaming request and binds them to the registered handler
+func (h *ProtocolHandler) prepareStream(request *rpcRequest) (streamFunc, *JSONRPCError) {
+    if rpcErr := h.translateRequest(request); rpcErr != nil {
+        return nil, rpcErr
+    }
+    switch request.Method {
+    case MethodSendTaskSubscribe:
+        var params TaskSendParams
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if err := params.Validate(); err != nil {
+            return nil, validationError(err)
+        }
+        message := messageKey(&params.Message)
+        if request.lastEventID != 0 && h.events != nil && h.events.Has(params.ID) {
+            // A reconnecting client resumes the stream rather than sending the message again.
+            // A new message with a leftover Last-Event-ID would be lost by resuming, so it is refused.
+            if !h.events.streaming(params.ID, message) {
+                return nil, InvalidRequestError().WithData("Last-Event-ID resumes the stream of an earlier message; send a new message without it")
+            }
+            return h.resumeStream(params.ID, request.lastEventID), nil
+        }
+        if h.sendTaskSubscribe == nil {
+            return nil, UnsupportedOperationError()
+        }
+        return func(ctx context.Context, stream *StreamWriter) error {
+            stream.contextID = params.SessionID
+            stream.events = h.events
+            stream.message = message
+            return h.sendTaskSubscribe(ctx, &params, stream)
+        }, nil
+
+    case MethodResubscribeTask:
+        var params TaskQueryParams
+        if rpcErr := request.decodeParams(&params); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if rpcErr := requireTaskID(params.ID); rpcErr != nil {
+            return nil, rpcErr
+        }
+        if h.events != nil && h.events.Has(params.ID) {
+            lastEventID := request.lastEventID
+            if id, ok := params.Metadata[LastEventIDMetadataKey].(string); ok && lastEventID == 0 {
+                lastEventID = parseEventID(id)
+            }
+            return h.resumeStream(params.ID, lastEventID), nil
+        }
+        if h.resubscribeTask == nil {
+            return nil, UnsupportedOperationError()
+        }
+        return func(ctx context.Context, stream *StreamWriter) error {
+            return h.resubscribeTask(ctx, &params, stream)
+        }, nil
+
+    default:
+        return nil, MethodNotFoundError()
+    }
+}
+
+// newStream creates the stream writer answering a request over the sink
+func (h *ProtocolHandler) newStream(request *rpcRequest, sink eventSink) *StreamWriter {
+    stream := newStreamWriter(request.responseID(), sink)
+    stream.version = request.version
+    stream.store = h.store
+    stream.recordHistory = h.card != nil && h.card.Capabilities.StateTransitionHistory
+    return stream
+}
+
+// resumeStream replays the logged events of the task after the given event ID and follows new ones.
+// If the task is evicted from the log meanwhile, the stream ends with the task as it is now.
+func (h *ProtocolHandler) resumeStream(taskID string, after uint64) streamFunc {
+    return func(ctx context.Context, stream *StreamWriter) error {
+        err := h.events.replay(ctx, taskID, after, stream)
+        if errors.Is(err, errTaskEvicted) {
+            return h.relayCurrentTask(ctx, taskID, stream)
+        }
+        return err
+    }
+}
+
+// relayCurrentTask writes events recreating the task as it is now, closing the stream if it is terminal.
+// Without a task handler or store to get it from, or if it is not found, the stream ends without events.
+func (h *ProtocolHandler) relayCurrentTask(ctx context.Context, taskID string, stream *StreamWriter) error {
+    var task *Task
+    var err error
+    switch {
+    case h.getTask != nil:
+        task, err = h.getTask(ctx, &TaskQueryParams{ID: taskID})
+    case h.store != nil:
+        task, _, err = h.store.Get(ctx, taskID)
+    }
+    if err != nil || task == nil {
+        return nil
+    }
+    contextID := ""
+    if task.SessionID != nil {
+        contextID = *task.SessionID
+    }
+    for _, logged := range summarize(taskID, task) {
+        event := logged.event
+        if event.Status != nil {
+            event.Status.Final = task.Status.State.IsTerminal()
+        }
+        if err := stream.relay(&event, 0, contextID); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// runStream runs a prepared stream and reports a handler error as the last event
+func runStream(ctx context.Context, run streamFunc, stream *StreamWriter) {
+    if err := run(ctx, stream); err != nil && !stream.Closed() {
+        stream.fail(toJSONRPCError(err))
+    }
+}
+
+// serveStream answers a streaming request with a Server-Sent Events response
+func (h *ProtocolHandler) serveStream(w http.ResponseWriter, r *http.Request, request *rpcRequest) {
+    if request.JSONRPC != JSONRPCVersion {
+        writeJSON(w, http.StatusOK, NewJSONRPCErrorResponse(request.responseID(), InvalidRequestError()))
+        return
+    }
+    run, rpcErr := h.prepareStream(request)
+    if rpcErr != nil {
+        writeJSON(w, http.StatusOK, NewJSONRPCErrorResponse(request.responseID(), rpcErr))
+        return
+    }
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        writeJSON(w, http.StatusOK, NewJSONRPCErrorResponse(request.responseID(), InternalError().WithData("streaming is not supported by the connection")))
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    w.WriteHeader(http.StatusOK)
+    flusher.Flush()
+
+    stream := h.newStream(request, &sseSink{w: w, flusher: flusher})
+    runStream(r.Context(), run, stream)
+}
+
+// sseSink writes stream responses as Server-Sent Events data frames
+type sseSink struct {
+    w       io.Writer
+    flusher http.Flusher
+}
+
+// writeEvent implements eventSink
+func (s *sseSink) writeEvent(response *SendTaskStreamingResponse, eventID uint64) error {
+    data, err := json.Marshal(response)
+    if err != nil {
+        return err
+    }
+    if eventID != 0 {
+        if _, err := fmt.Fprintf(s.w, "id: %d\n", eventID); err != nil {
+            return err
+        }
+    }
+    if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+        return err
+    }
+    s.flusher.Flush()
+    return nil
+}
+
+// sseEvent is a single event read from a Server-Sent Events stream
+type sseEvent struct {
+    ID    string
+    Event string
+    Data  []byte
+}
+
+// sseReader reads events from a Server-Sent Events stream
+type sseReader struct {
+    reader *bufio.Reader
+}
+
+// newSSEReader creates a reader of Server-Sent Events
+func newSSEReader(r io.Reader) *sseReader {
+    return &sseReader{reader: bufio.NewReader(r)}
+}
+
+// next returns the next event of the stream, or io.EOF at the end of the stream
+func (r *sseReader) next() (*sseEvent, error) {
+    event := &sseEvent{}
+    var data bytes.Buffer
+    hasData := false
+    for {
+        line, err := r.reader.ReadString('\n')
+        if err != nil && (err != io.EOF || line == "") {
+            if err == io.EOF && hasData {
+                event.Data = data.Bytes()
+                return event, nil
+            }
+            return nil, err
+        }
+        line = strings.TrimRight(line, "\r\n")
+
+        // A blank line dispatches the event
+        if line == "" {
+            if hasData {
+                event.Data = data.Bytes()
+                return event, nil
+            }
+            continue
+        }
+        if strings.HasPrefix(line, ":") {
+            continue
+        }
+
+        field, value, _ := strings.Cut(line, ":")
+        value = strings.TrimPrefix(value, " ")
+        switch field {
+        case "data":
+            if hasData {
+                data.WriteByte('\n')
+            }
+            data.WriteString(value)
+            hasData = true
+        case "id":
+            event.ID = value
+        case "event":
+            event.Event = value
+        }
+    }
+}
+
+// TaskEvent is a single event received from a task stream.
+// Exactly one of Status and Artifact is set.
+type TaskEvent struct {
+    Status   *TaskStatusUpdateEvent
+    Artifact *TaskArtifactUpdateEvent
+    // EventID is the ID the server assigned to the event, if any
+    EventID string
+}
+
+// Default reconnection behavior of task event streams
+const (
+    DefaultStreamReconnectAttempts = 5
+    DefaultStreamReconnectBackoff  = 250 * time.Millisecond
+)
+
+// WithStreamReconnect sets how often a task stream dropped before its final event is resumed,
+// and the delay before the first attempt, doubled after each failed one. Zero attempts disables it.
+func (c *Client) WithStreamReconnect(attempts int, backoff time.Duration) *Client {
+    c.reconnectAttempts = attempts
+    c.reconnectBackoff = backoff
+    return c
+}
+
+// TaskEventStream reads the events of a tasks/sendSubscribe or tasks/resubscribe response.
+// The events received are folded into a TaskProjection, so Task reports the task they describe.
+// When the server assigns event IDs and the connection drops before the final event, the
+// stream resubscribes with Last-Event-ID and continues without losing or repeating events.
+type TaskEventStream struct {
+    protocol   *Protocol
+    version    ProtocolVersion
+    done       bool
+    projection *TaskProjection
+
+    // artifactIndexes assigns the indexes of the artifacts received in the newer schema
+    artifactIndexes *artifactIndexes
+
+    // client, ctx, url and taskID are used to resume the stream; lastEventID is the newest event ID received
+    client      *Client
+    ctx         context.Context
+    url         string
+    taskID      string
+    lastEventID uint64
+
+    mu     sync.Mutex
+    events EventReader
+    closed bool
+    // projectionErr is the first event the task could not take
+    projectionErr error
+}
+
+// Recv returns the next event of the stream.
+// It returns io.EOF once the final status update has been received or the stream ends.
+func (s *TaskEventStream) Recv() (*TaskEvent, error) {
+    if s.done {
+        return nil, io.EOF
+    }
+    for {
+        s.mu.Lock()
+        events := s.events
+        s.mu.Unlock()
+        var eventID string
+        var data []byte
+        var event *TaskEvent
+        var err error
+        typed, decoded := events.(taskEventReader)
+        if decoded {
+            // Events handed over in process need no decoding
+            eventID, event, err = typed.nextEvent()
+        } else {
+            eventID, data, err = events.Next()
+        }
+        // A clean end means the handler finished; only a broken connection is resumed
+        if err != nil {
+            if err != io.EOF && s.reconnect() {
+                continue
+            }
+            s.done = true
+            return nil, err
+        }
+
+        id := parseEventID(eventID)
+        if id != 0 && id <= s.lastEventID {
+            // Already received before the stream was resumed
+            continue
+        }
+        if !decoded {
+            if s.version.IsLegacy() {
+                event, err = s.protocol.parseTaskEvent(data)
+            } else {
+                event, err = parseTaskEventV2(data, s.artifactIndexes)
+            }
+            if err != nil {
+                s.done = true
+                return nil, err
+            }
+        }
+        if event == nil {
+            continue
+        }
+        if id != 0 {
+            s.lastEventID = id
+            event.EventID = eventID
+        }
+        // Events the task cannot take, such as an invalid transition, are still returned
+        if err := s.projection.Apply(event); err != nil {
+            s.mu.Lock()
+            if s.projectionErr == nil {
+                s.projectionErr = err
+            }
+            s.mu.Unlock()
+        }
+        if event.Status != nil && event.Status.Final {
+            s.done = true
+        }
+        return event, nil
+    }
+}
+
+// reconnect resubscribes to the task after the stream dropped, reporting whether it succeeded.
+// Streams without event IDs are not resumed, since the events missed could not be told apart.
+// Over a Transport, the last event ID travels in the metadata, and a WebSocket connection
+// opened from the agent card is dialed again.
+func (s *TaskEventStream) reconnect() bool {
+    if s.client == nil || s.lastEventID == 0 || s.taskID == "" {
+        return false
+    }
+    backoff := s.client.reconnectBackoff
+    for attempt := 0; attempt < s.client.reconnectAttempts; attempt++ {
+        select {
+        case <-time.After(backoff):
+        case <-s.ctx.Done():
+            return false
+        }
+        backoff *= 2
+
+        s.mu.Lock()
+        closed := s.closed
+        s.mu.Unlock()
+        if closed {
+            return false
+        }
+        lastEventID := strconv.FormatUint(s.lastEventID, 10)
+        params := TaskQueryParams{ID: s.taskID, Metadata: map[string]interface{}{LastEventIDMetadataKey: lastEventID}}
+        request := s.client.protocol.CreateTaskResubscriptionRequest(s.client.nextID(), params)
+        header := http.Header{LastEventIDHeader: {lastEventID}}
+        events, err := s.client.openEventStream(s.ctx, s.url, request, header)
+        var rpcErr *JSONRPCError
+        if errors.As(err, &rpcErr) {
+            // The server answered; the task cannot be resumed there
+            return false
+        }
+        if err != nil {
+            continue
+        }
+
+        s.mu.Lock()
+        if s.closed {
+            s.mu.Unlock()
+            events.Close()
+            return false
+        }
+        s.events.Close()
+        s.events = events
+        s.mu.Unlock()
+        return true
+    }
+    return false
+}
+
+// Task returns the task rebuilt from the events received so far
+func (s *TaskEventStream) Task() *Task {
+    return s.projection.Task()
+}
+
+// ProjectionError returns the error of the first event received that Task could not take, such as
+// an invalid state transition; the task then no longer matches the events returned by Recv
+func (s *TaskEventStream) ProjectionError() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.projectionErr
+}
+
+// Close releases the connection of the stream
+func (s *TaskEventStream) Close() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.closed = true
+    return s.events.Close()
+}
+
+// parseTaskEvent decodes one streaming response into a task event.
+// It returns a nil event for responses that carry no result.
+func (p *Protocol) parseTaskEvent(data []byte) (*TaskEvent, error) {
+    response, err := p.ParseStreamingResponse(data)
+    if response != nil && response.Error != nil {
+        return nil, response.Error
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    fields, ok := response.Result.(map[string]interface{})
+    if !ok {
+        return nil, nil
+    }
+    if _, ok := fields["artifact"]; ok {
+        event, err := p.ParseTaskArtifactUpdate(response)
+        if err != nil {
+            return nil, err
+        }
+        return &TaskEvent{Artifact: event}, nil
+    }
+    event, err := p.ParseTaskStatusUpdate(response)
+    if err != nil {
+        return nil, err
+    }
+    return &TaskEvent{Status: event}, nil
+}
+
+// SendTaskSubscribe sends a tasks/sendSubscribe request and returns the stream of task events
+func (c *Client) SendTaskSubscribe(ctx context.Context, params *TaskSendParams, url string) (*TaskEventStream, error) {
+    if loopback := c.zeroCopyTransport(ctx, url); loopback != nil {
+        events, err := loopback.streamTask(ctx, MethodSendTaskSubscribe, params)
+        if err != nil {
+            return nil, err
+        }
+        return c.newTaskEventStream(ctx, url, params.ID, events), nil
+    }
+    if !c.versionFor(url).IsLegacy() {
+        return c.openStream(ctx, url, params.ID, NewJSONRPCRequest(c.nextID(), MethodStreamMessage, params.ToV2()))
+    }
+    return c.openStream(ctx, url, params.ID, c.protocol.CreateTaskStreamingRequest(c.nextID(), *params))
+}
+
+// ResubscribeTask sends a tasks/resubscribe request and returns the stream of task events
+func (c *Client) ResubscribeTask(ctx context.Context, params *TaskQueryParams, url string) (*TaskEventStream, error) {
+    if loopback := c.zeroCopyTransport(ctx, url); loopback != nil {
+        events, err := loopback.streamTask(ctx, MethodResubscribeTask, params)
+        if err != nil {
+            return nil, err
+        }
+        return c.newTaskEventStream(ctx, url, params.ID, events), nil
+    }
+    return c.openStream(ctx, url, params.ID, c.protocol.CreateTaskResubscriptionRequest(c.nextID(), *params))
+}
+
+// openStream sends a streaming request about the task and wraps the event stream of the response
+func (c *Client) openStream(ctx context.Context, url, taskID string, request interface{ ToJSON() ([]byte, error) }) (*TaskEventStream, error) {
+    events, err := c.openEventStream(ctx, url, request, nil)
+    if err != nil {
+        return nil, err
+    }
+    return c.newTaskEventStream(ctx, url, taskID, events), nil
+}
+
+// newTaskEventStream wraps the events answering a streaming request about the task
+func (c *Client) newTaskEventStream(ctx context.Context, url, taskID string, events EventReader) *TaskEventStream {
+    return &TaskEventStream{
+        events:     events,
+        version:    c.versionFor(url),
+        protocol:   c.protocol,
+        projection: NewTaskProjection(nil),
+        client:     c,
+        ctx:        ctx,
+        url:        url,
+        taskID:     taskID,
+
+        artifactIndexes: newArtifactIndexes(),
+    }
+}
+
+// openEventStream sends a streaming request and returns the events answering it.
+// The headers are sent only over HTTP.
+func (c *Client) openEventStream(ctx context.Context, url string, request interface{ ToJSON() ([]byte, error) }, header http.Header) (EventReader, error) {
+    body, err := request.ToJSON()
+    if err != nil {
+        return nil, err
+    }
+    if transport := c.transportFor(ctx, url); transport != nil {
+        return transport.Stream(ctx, body)
+    }
+    resp, err := c.do(ctx, url, body, "text/event-stream", header)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        defer resp.Body.Close()
+        data, _ := io.ReadAll(resp.Body)
+        return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+    }
+
+    // Errors raised before the stream starts come back as a plain JSON-RPC response
+    mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+    if mediaType != "text/event-stream" {
+        defer resp.Body.Close()
+        data, err := io.ReadAll(resp.Body)
+        if err != nil {
+            return nil, err
+        }
+        response, err := c.protocol.ParseResponse(data)
+        if response != nil && response.Error != nil {
+            return nil, response.Error
+        }
+        if err != nil {
+            return nil, err
+        }
+        return nil, fmt.Errorf("a2a: expected an event stream, got %q", mediaType)
+    }
+
+    return newSSEEventReader(resp.Body), nil
+}
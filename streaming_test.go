@@ -0,0 +1,96 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestStreamingRoundTrip(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            stream.WriteArtifactUpdate(a2a.TaskArtifactUpdateEvent{ID: params.ID, Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("out")})})
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+            if err := stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID}); !errors.Is(err, a2a.ErrStreamClosed) {
+                t.Errorf("Expected closed stream, got %v", err)
+            }
+            return nil
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    client := a2a.NewClient()
+    stream, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+
+    var events []*a2a.TaskEvent
+    for {
+        event, err := stream.Recv()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            t.Fatalf("Recv failed: %v", err)
+        }
+        events = append(events, event)
+    }
+
+    if len(events) != 3 {
+        t.Fatalf("Event count mismatch: expected %d, got %d", 3, len(events))
+    }
+    if events[0].Status == nil || events[0].Status.Status.State != a2a.TaskStateWorking {
+        t.Errorf("First event is not a working status: %#v", events[0])
+    }
+    if events[1].Artifact == nil {
+        t.Errorf("Second event is not an artifact update: %#v", events[1])
+    }
+    if events[2].Status == nil || !events[2].Status.Final {
+        t.Errorf("Last event is not final: %#v", events[2])
+    }
+}
+
+func TestStreamingErrors(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskResubscribe(func(ctx context.Context, params *a2a.TaskQueryParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            return a2a.TaskNotFoundError()
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+    client := a2a.NewClient()
+
+    // No handler registered: the error comes back before the stream starts
+    _, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    var rpcErr *a2a.JSONRPCError
+    if !errors.As(err, &rpcErr) || rpcErr.Code != a2a.ErrCodeUnsupportedOperation {
+        t.Errorf("Expected unsupported operation, got %v", err)
+    }
+
+    // Handler error: the error is the last event of the stream
+    stream, err := client.ResubscribeTask(context.Background(), &a2a.TaskQueryParams{ID: "task-1"}, server.URL)
+    if err != nil {
+        t.Fatalf("ResubscribeTask failed: %v", err)
+    }
+    defer stream.Close()
+    if _, err := stream.Recv(); err != nil {
+        t.Fatalf("Recv failed: %v", err)
+    }
+    _, err = stream.Recv()
+    if !errors.As(err, &rpcErr) || rpcErr.Code != a2a.ErrCodeTaskNotFound {
+        t.Errorf("Expected task not found, got %v", err)
+    }
+}
@@ -0,0 +1,461 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the task manager running tasks on a bounded pool of workers
+package a2a
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+)
+
+// Default limits of task managers
+const (
+    DefaultTaskWorkers   = 8
+    DefaultTaskQueueSize = 64
+)
+
+// SkillMetadataKey is the key of the task metadata naming the skill a task is sent to
+const SkillMetadataKey = "skillId"
+
+// Errors returned by task managers
+var (
+    // ErrTaskQueueFull is returned when a task is sent while every worker is busy and the queue is full
+    ErrTaskQueueFull = errors.New("a2a: task queue full")
+    // ErrTaskManagerClosed is returned when a task is sent after Shutdown
+    ErrTaskManagerClosed = errors.New("a2a: task manager shut down")
+)
+
+// Causes with which the context of a task is canceled
+var (
+    errTaskCanceled = errors.New("a2a: task canceled")
+    errTaskDeadline = errors.New("a2a: task deadline exceeded")
+)
+
+// errTaskUnchanged is returned by the change function of TaskManager.update to leave the task as it is
+var errTaskUnchanged = errors.New("a2a: task unchanged")
+
+// TaskProcessFunc processes a task on a worker of a TaskManager.
+// The context is canceled when the task is canceled, its deadline passes or the manager gives up
+// draining on shutdown. Returning nil completes the task unless the function moved it to another
+// state itself, such as input-required; returning an error fails it.
+type TaskProcessFunc func(ctx context.Context, run *TaskRun) error
+
+// TaskRun is the processing of one tasks/send request by a TaskManager
+type TaskRun struct {
+    // Params are the params of the request
+    Params *TaskSendParams
+    // Skill is the ID of the skill the task was sent to, if any
+    Skill string
+
+    manager *TaskManager
+    // ctx carries the values of the request context, canceled only when the manager aborts
+    ctx context.Context
+    // cancel cancels the context of the running task; canceled records a cancellation before it started
+    cancel   context.CancelCauseFunc
+    canceled bool
+}
+
+// Task returns the current state of the task
+func (r *TaskRun) Task(ctx context.Context) (*Task, error) {
+    task, _, err := r.manager.store.Get(ctx, r.Params.ID)
+    return task, err
+}
+
+// UpdateStatus moves the task to a new state, recording the message as its status message
+func (r *TaskRun) UpdateStatus(ctx context.Context, state TaskState, message *Message) error {
+    _, err := r.manager.update(ctx, r.Params.ID, true, func(task *Task) (*TaskEvent, error) {
+        if err := r.manager.card().TransitionTask(task, state, message); err != nil {
+            return nil, err
+        }
+        return statusEvent(task), nil
+    })
+    return err
+}
+
+// AddArtifact adds an artifact to the task
+func (r *TaskRun) AddArtifact(ctx context.Context, artifact Artifact) error {
+    _, err := r.manager.update(ctx, r.Params.ID, true, func(task *Task) (*TaskEvent, error) {
+        if task.Status.State.IsTerminal() {
+            return nil, fmt.Errorf("a2a: task is %s", task.Status.State)
+        }
+        task.Artifacts = append(task.Artifacts, artifact)
+        return &TaskEvent{Artifact: &TaskArtifactUpdateEvent{ID: task.ID, Artifact: artifact}}, nil
+    })
+    return err
+}
+
+// TaskManager accepts tasks/send requests, keeps their tasks in a store and processes them on a
+// bounded pool of workers. Each task runs with its own context, which tasks/cancel cancels while
+// moving the task to canceled. Register it with ProtocolHandler.WithTaskManager.
+type TaskManager struct {
+    process        TaskProcessFunc
+    store          TaskStore
+    workers        int
+    queueSize      int
+    defaultTimeout time.Duration
+    timeouts       map[string]time.Duration
+    handler        *ProtocolHandler
+
+    startOnce sync.Once
+    queue     chan *TaskRun
+    wg        sync.WaitGroup
+    // ctx is canceled when Shutdown stops waiting, canceling every task still running
+    ctx   context.Context
+    abort context.CancelCauseFunc
+
+    mu     sync.Mutex
+    closed bool
+    runs   map[string]*TaskRun
+}
+
+// NewTaskManager creates a task manager keeping its tasks in the store, or in memory when store is nil
+func NewTaskManager(store TaskStore, process TaskProcessFunc) *TaskManager {
+    if store == nil {
+        store = NewMemoryTaskStore()
+    }
+    ctx, abort := context.WithCancelCause(context.Background())
+    return &TaskManager{
+        process:   process,
+        store:     store,
+        workers:   DefaultTaskWorkers,
+        queueSize: DefaultTaskQueueSize,
+        timeouts:  make(map[string]time.Duration),
+        ctx:       ctx,
+        abort:     abort,
+        runs:      make(map[string]*TaskRun),
+    }
+}
+
+// WithWorkers sets how many tasks are processed at the same time
+func (m *TaskManager) WithWorkers(workers int) *TaskManager {
+    m.workers = workers
+    return m
+}
+
+// WithQueueSize sets how many tasks may wait for a worker before tasks/send is rejected
+func (m *TaskManager) WithQueueSize(size int) *TaskManager {
+    m.queueSize = size
+    return m
+}
+
+// WithTimeout sets how long tasks of skills without their own timeout may run before they fail.
+// Zero lets them run until they finish.
+func (m *TaskManager) WithTimeout(timeout time.Duration) *TaskManager {
+    m.defaultTimeout = timeout
+    return m
+}
+
+// WithSkillTimeout sets how long tasks sent to the skill may run before they fail.
+// The skill of a task is named by the SkillMetadataKey entry of the tasks/send metadata.
+func (m *TaskManager) WithSkillTimeout(skillID string, timeout time.Duration) *TaskManager {
+    m.timeouts[skillID] = timeout
+    return m
+}
+
+// WithTaskManager answers tasks/send, tasks/get and tasks/cancel with the task manager.
+// The status and artifact updates of its tasks are recorded in the event log of the handler,
+// if any, and sent as push notifications. The manager keeps its tasks itself, so its store must
+// not also be set with WithTaskStore, which would overwrite updates made by the workers.
+func (h *ProtocolHandler) WithTaskManager(manager *TaskManager) *ProtocolHandler {
+    manager.handler = h
+    h.sendTask = manager.SendTask
+    h.getTask = manager.GetTask
+    h.cancelTask = manager.CancelTask
+    return h
+}
+
+// SendTask implements the tasks/send method of TaskHandler.
+// It stores the task as submitted and queues it for a worker; a task waiting for input is
+// queued again with the message appended to its history.
+func (m *TaskManager) SendTask(ctx context.Context, params *TaskSendParams) (*Task, error) {
+    m.startOnce.Do(m.start)
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.closed {
+        return nil, ErrTaskManagerClosed
+    }
+    if _, ok := m.runs[params.ID]; ok {
+        return nil, InvalidRequestError().WithData("task is already being processed")
+    }
+    if len(m.runs) >= cap(m.queue) {
+        return nil, ErrTaskQueueFull
+    }
+
+    task, err := m.update(ctx, params.ID, false, func(task *Task) (*TaskEvent, error) {
+        if task.Status.State.IsTerminal() {
+            return nil, InvalidParamsError().WithData(fmt.Sprintf("task is %s", task.Status.State))
+        }
+        task.AddToHistory(params.Message)
+        return nil, nil
+    })
+    if errors.Is(err, ErrTaskNotFound) {
+        task = NewTask(params.ID, TaskStateSubmitted)
+        if params.SessionID != "" {
+            task.WithSessionID(params.SessionID)
+        }
+        task.Metadata = params.Metadata
+        task.AddToHistory(params.Message)
+        if _, err = m.store.Create(ctx, task); err == nil {
+            m.publish(task, statusEvent(task), false)
+        }
+    }
+    if err != nil {
+        return nil, err
+    }
+    if m.handler != nil && m.handler.events != nil {
+        // A client streaming the answer may resume it with Last-Event-ID, as for a tasks/sendSubscribe stream
+        m.handler.events.answering(params.ID, messageKey(&params.Message))
+    }
+
+    skill, _ := params.Metadata[SkillMetadataKey].(string)
+    run := &TaskRun{Params: params, Skill: skill, manager: m, ctx: context.WithoutCancel(ctx)}
+    m.runs[params.ID] = run
+    m.queue <- run
+    trimHistory(task, params.HistoryLength)
+    return task, nil
+}
+
+// GetTask implements the tasks/get method of TaskHandler
+func (m *TaskManager) GetTask(ctx context.Context, params *TaskQueryParams) (*Task, error) {
+    task, _, err := m.store.Get(ctx, params.ID)
+    if err != nil {
+        return nil, storeError(err)
+    }
+    trimHistory(task, params.HistoryLength)
+    return task, nil
+}
+
+// CancelTask implements the tasks/cancel method of TaskHandler.
+// The task moves to canceled and the context of its processing, if any, is canceled.
+func (m *TaskManager) CancelTask(ctx context.Context, params *TaskIdParams) (*Task, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    task, err := m.update(ctx, params.ID, false, func(task *Task) (*TaskEvent, error) {
+        if err := m.card().TransitionTask(task, TaskStateCanceled, nil); err != nil {
+            return nil, TaskNotCancelableError().WithData(err.Error())
+        }
+        return statusEvent(task), nil
+    })
+    if err != nil {
+        return nil, storeError(err)
+    }
+    if run, ok := m.runs[params.ID]; ok {
+        run.canceled = true
+        if run.cancel != nil {
+            run.cancel(errTaskCanceled)
+        }
+    }
+    return task, nil
+}
+
+// Shutdown stops accepting tasks and waits for the queued and running ones to finish.
+// When ctx is done first, the tasks left are canceled and failed, and ctx's error is returned.
+func (m *TaskManager) Shutdown(ctx context.Context) error {
+    m.startOnce.Do(m.start)
+    m.mu.Lock()
+    if !m.closed {
+        m.closed = true
+        close(m.queue)
+    }
+    m.mu.Unlock()
+
+    drained := make(chan struct{})
+    go func() {
+        m.wg.Wait()
+        close(drained)
+    }()
+    select {
+    case <-drained:
+        return nil
+    case <-ctx.Done():
+        m.abort(ErrTaskManagerClosed)
+        return ctx.Err()
+    }
+}
+
+// start creates the queue and the workers
+func (m *TaskManager) start() {
+    workers := m.workers
+    if workers <= 0 {
+        workers = DefaultTaskWorkers
+    }
+    queueSize := m.queueSize
+    if queueSize < 0 {
+        queueSize = 0
+    }
+    m.queue = make(chan *TaskRun, queueSize+workers)
+    m.wg.Add(workers)
+    for i := 0; i < workers; i++ {
+        go m.work()
+    }
+}
+
+// work processes queued tasks until the queue is closed
+func (m *TaskManager) work() {
+    defer m.wg.Done()
+    for run := range m.queue {
+        m.execute(run)
+    }
+}
+
+// execute processes one task and records how it ended
+func (m *TaskManager) execute(run *TaskRun) {
+    id := run.Params.ID
+    defer func() {
+        m.mu.Lock()
+        delete(m.runs, id)
+        m.mu.Unlock()
+    }()
+
+    m.mu.Lock()
+    if run.canceled {
+        m.mu.Unlock()
+        return
+    }
+    ctx, cancel := context.WithCancelCause(run.ctx)
+    defer cancel(nil)
+    if timeout := m.timeout(run.Skill); timeout > 0 {
+        var cancelTimeout context.CancelFunc
+        ctx, cancelTimeout = context.WithTimeoutCause(ctx, timeout, errTaskDeadline)
+        defer cancelTimeout()
+    }
+    run.cancel = cancel
+    m.mu.Unlock()
+    stop := context.AfterFunc(m.ctx, func() {
+        cancel(context.Cause(m.ctx))
+    })
+    defer stop()
+
+    if m.ctx.Err() != nil {
+        m.finish(id, TaskStateFailed, "the agent shut down before the task started")
+        return
+    }
+    if err := run.UpdateStatus(context.Background(), TaskStateWorking, nil); err != nil {
+        // Canceled while it was queued
+        return
+    }
+    // A task that overruns its deadline fails then, even if the function does not return
+    stopDeadline := context.AfterFunc(ctx, func() {
+        if context.Cause(ctx) == errTaskDeadline {
+            m.finish(id, TaskStateFailed, "the task did not finish in time")
+        }
+    })
+    defer stopDeadline()
+
+    err := m.run(ctx, run)
+    switch context.Cause(ctx) {
+    case errTaskCanceled, errTaskDeadline:
+        // Already recorded
+    case ErrTaskManagerClosed:
+        m.finish(id, TaskStateFailed, "the agent shut down before the task finished")
+    default:
+        if err != nil {
+            m.finish(id, TaskStateFailed, err.Error())
+        } else {
+            m.finish(id, TaskStateCompleted, "")
+        }
+    }
+}
+
+// run calls the process function, reporting a panic as an error
+func (m *TaskManager) run(ctx context.Context, run *TaskRun) (err error) {
+    defer func() {
+        if recovered := recover(); recovered != nil {
+            err = fmt.Errorf("a2a: task processing panicked: %v", recovered)
+        }
+    }()
+    return m.process(ctx, run)
+}
+
+// timeout returns the deadline of tasks sent to the skill
+func (m *TaskManager) timeout(skill string) time.Duration {
+    if timeout, ok := m.timeouts[skill]; ok {
+        return timeout
+    }
+    return m.defaultTimeout
+}
+
+// finish moves a task that is still working to a final state, with the text as its status message.
+// A task completed while the function left it in another state keeps that state.
+func (m *TaskManager) finish(id string, state TaskState, text string) {
+    m.update(context.Background(), id, true, func(task *Task) (*TaskEvent, error) {
+        current := task.Status.State
+        if current.IsTerminal() || (state == TaskStateCompleted && current != TaskStateWorking) {
+            return nil, errTaskUnchanged
+        }
+        var message *Message
+        if text != "" {
+            message = NewMessage(RoleAgent, []Part{NewTextPart(text)})
+        }
+        if err := m.card().TransitionTask(task, state, message); err != nil {
+            return nil, err
+        }
+        return statusEvent(task), nil
+    })
+}
+
+// update applies change to the stored task, retrying on version conflicts, and publishes the
+// event it returns, if any. The task is left as it is when change returns errTaskUnchanged.
+func (m *TaskManager) update(ctx context.Context, id string, notify bool, change func(task *Task) (*TaskEvent, error)) (*Task, error) {
+    for {
+        task, version, err := m.store.Get(ctx, id)
+        if err != nil {
+            return nil, err
+        }
+        event, err := change(task)
+        if err == errTaskUnchanged {
+            return task, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+        _, err = m.store.Update(ctx, task, version)
+        if errors.Is(err, ErrVersionConflict) {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        if event != nil {
+            m.publish(task, event, notify)
+        }
+        return task, nil
+    }
+}
+
+// publish records the event in the event log of the handler and, for updates made outside of a
+// request, sends the task as a push notification
+func (m *TaskManager) publish(task *Task, event *TaskEvent, notify bool) {
+    if m.handler == nil {
+        return
+    }
+    if m.handler.events != nil {
+        contextID := ""
+        if task.SessionID != nil {
+            contextID = *task.SessionID
+        }
+        m.handler.events.appendEvent(event, contextID, "")
+    }
+    if notify {
+        m.handler.notifyTask(task)
+    }
+}
+
+// card returns the agent card of the handler the manager is registered with
+func (m *TaskManager) card() *AgentCard {
+    if m.handler == nil {
+        return nil
+    }
+    return m.handler.card
+}
+
+// statusEvent returns the status update event announcing the current status of the task
+func statusEvent(task *Task) *TaskEvent {
+    return &TaskEvent{Status: &TaskStatusUpdateEvent{
+        ID:     task.ID,
+        Status: task.Status,
+        Final:  task.Status.State.IsTerminal(),
+    }}
+}
@@ -0,0 +1,170 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+// waitForState polls the task until it reaches the state
+func waitForState(t *testing.T, client *a2a.Client, url, id string, state a2a.TaskState) *a2a.Task {
+    t.Helper()
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        task, err := client.GetTask(context.Background(), &a2a.TaskQueryParams{ID: id}, url)
+        if err != nil {
+            t.Fatalf("GetTask failed: %v", err)
+        }
+        if task.Status.State == state {
+            return task
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("Task %s is %s, expected %s", id, task.Status.State, state)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+}
+
+// newManagedAgent returns a client calling a handler served by the task manager
+func newManagedAgent(manager *a2a.TaskManager) *a2a.Client {
+    handler := a2a.NewProtocolHandler(nil).WithTaskManager(manager)
+    return a2a.NewClient().WithTransport("local://agent", a2a.NewLoopbackTransport(handler))
+}
+
+// sendParams returns the params of a task sent to the skill
+func sendParams(id, skill string) *a2a.TaskSendParams {
+    params := &a2a.TaskSendParams{
+        ID:      id,
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }
+    if skill != "" {
+        params.Metadata = map[string]interface{}{a2a.SkillMetadataKey: skill}
+    }
+    return params
+}
+
+func TestTaskManagerProcessesTasks(t *testing.T) {
+    manager := a2a.NewTaskManager(nil, func(ctx context.Context, run *a2a.TaskRun) error {
+        if run.Params.ID == "task-fail" {
+            return errors.New("no such thing")
+        }
+        return run.AddArtifact(ctx, *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("done")}))
+    })
+    defer manager.Shutdown(context.Background())
+    client := newManagedAgent(manager)
+
+    task, err := client.SendTask(context.Background(), sendParams("task-1", ""), "local://agent")
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.Status.State.IsTerminal() {
+        t.Errorf("Expected the task to be returned before it is processed, got %s", task.Status.State)
+    }
+    task = waitForState(t, client, "local://agent", "task-1", a2a.TaskStateCompleted)
+    if len(task.Artifacts) != 1 {
+        t.Errorf("Expected 1 artifact, got %d", len(task.Artifacts))
+    }
+
+    if _, err := client.SendTask(context.Background(), sendParams("task-fail", ""), "local://agent"); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    task = waitForState(t, client, "local://agent", "task-fail", a2a.TaskStateFailed)
+    if task.Status.Message == nil {
+        t.Error("Expected the error as the status message")
+    }
+}
+
+func TestTaskManagerCancelPropagates(t *testing.T) {
+    started := make(chan struct{})
+    causes := make(chan error, 1)
+    manager := a2a.NewTaskManager(nil, func(ctx context.Context, run *a2a.TaskRun) error {
+        close(started)
+        <-ctx.Done()
+        causes <- ctx.Err()
+        return ctx.Err()
+    })
+    defer manager.Shutdown(context.Background())
+    client := newManagedAgent(manager)
+
+    if _, err := client.SendTask(context.Background(), sendParams("task-1", ""), "local://agent"); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    <-started
+    task, err := client.CancelTask(context.Background(), &a2a.TaskIdParams{ID: "task-1"}, "local://agent")
+    if err != nil {
+        t.Fatalf("CancelTask failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateCanceled {
+        t.Errorf("State mismatch: expected %s, got %s", a2a.TaskStateCanceled, task.Status.State)
+    }
+    if err := <-causes; !errors.Is(err, context.Canceled) {
+        t.Errorf("Expected the task context to be canceled, got %v", err)
+    }
+    waitForState(t, client, "local://agent", "task-1", a2a.TaskStateCanceled)
+
+    if _, err := client.CancelTask(context.Background(), &a2a.TaskIdParams{ID: "task-1"}, "local://agent"); !errors.Is(err, a2a.ErrTaskNotCancelable) {
+        t.Errorf("Expected task not cancelable, got %v", err)
+    }
+}
+
+func TestTaskManagerSkillTimeout(t *testing.T) {
+    release := make(chan struct{})
+    manager := a2a.NewTaskManager(nil, func(ctx context.Context, run *a2a.TaskRun) error {
+        // Ignores its context, so the deadline alone must fail the task
+        <-release
+        return nil
+    }).WithSkillTimeout("slow", 20*time.Millisecond)
+    client := newManagedAgent(manager)
+
+    if _, err := client.SendTask(context.Background(), sendParams("task-1", "slow"), "local://agent"); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    waitForState(t, client, "local://agent", "task-1", a2a.TaskStateFailed)
+    close(release)
+    if err := manager.Shutdown(context.Background()); err != nil {
+        t.Fatalf("Shutdown failed: %v", err)
+    }
+    waitForState(t, client, "local://agent", "task-1", a2a.TaskStateFailed)
+}
+
+func TestTaskManagerShutdownDrains(t *testing.T) {
+    release := make(chan struct{})
+    manager := a2a.NewTaskManager(nil, func(ctx context.Context, run *a2a.TaskRun) error {
+        <-release
+        return nil
+    }).WithWorkers(1).WithQueueSize(1)
+
+    ctx := context.Background()
+    for _, id := range []string{"task-1", "task-2"} {
+        if _, err := manager.SendTask(ctx, sendParams(id, "")); err != nil {
+            t.Fatalf("SendTask failed: %v", err)
+        }
+    }
+    if _, err := manager.SendTask(ctx, sendParams("task-3", "")); !errors.Is(err, a2a.ErrTaskQueueFull) {
+        t.Errorf("Expected a full queue, got %v", err)
+    }
+
+    shutdown := make(chan error, 1)
+    go func() {
+        shutdown <- manager.Shutdown(ctx)
+    }()
+    close(release)
+    if err := <-shutdown; err != nil {
+        t.Fatalf("Shutdown failed: %v", err)
+    }
+    for _, id := range []string{"task-1", "task-2"} {
+        task, err := manager.GetTask(ctx, &a2a.TaskQueryParams{ID: id})
+        if err != nil {
+            t.Fatalf("GetTask failed: %v", err)
+        }
+        if task.Status.State != a2a.TaskStateCompleted {
+            t.Errorf("Task %s is %s after draining", id, task.Status.State)
+        }
+    }
+    if _, err := manager.SendTask(ctx, sendParams("task-4", "")); !errors.Is(err, a2a.ErrTaskManagerClosed) {
+        t.Errorf("Expected the manager to be closed, got %v", err)
+    }
+}
@@ -0,0 +1,172 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the reconstruction of tasks from their status and artifact events
+package a2a
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+)
+
+// ErrEventTaskMismatch is returned when an event belongs to another task than the one it is applied to
+var ErrEventTaskMismatch = errors.New("a2a: event belongs to another task")
+
+// ApplyEvent folds a status or artifact update event into the task.
+// The event is a TaskStatusUpdateEvent, a TaskArtifactUpdateEvent, a pointer to either, or a *TaskEvent.
+//
+// A status update replaces the status of the task and appends its message to the history,
+// rebuilding the history a client saw; the new state must be reachable from the current one,
+// unless the task has no state yet or the state does not change. An artifact update adds the artifact, replaces the artifact
+// of the same index, or, with Append set, continues it as an ArtifactAssembler would.
+// An event that cannot be applied leaves the task unchanged.
+func ApplyEvent(task *Task, event interface{}) error {
+    return applyEvent(task, event, true)
+}
+
+// applyEvent folds the event into the task, appending status messages to the history if requested
+func applyEvent(task *Task, event interface{}, recordHistory bool) error {
+    switch e := event.(type) {
+    case TaskStatusUpdateEvent:
+        return applyStatusUpdate(task, &e, recordHistory)
+    case *TaskStatusUpdateEvent:
+        return applyStatusUpdate(task, e, recordHistory)
+    case TaskArtifactUpdateEvent:
+        return applyArtifactUpdate(task, &e)
+    case *TaskArtifactUpdateEvent:
+        return applyArtifactUpdate(task, e)
+    case *TaskEvent:
+        if e.Status != nil {
+            return applyStatusUpdate(task, e.Status, recordHistory)
+        }
+        if e.Artifact != nil {
+            return applyArtifactUpdate(task, e.Artifact)
+        }
+        return nil
+    }
+    return fmt.Errorf("a2a: cannot apply %T to a task", event)
+}
+
+// applyStatusUpdate folds a status update into the task
+func applyStatusUpdate(task *Task, event *TaskStatusUpdateEvent, recordHistory bool) error {
+    if err := checkEventTask(task, event.ID); err != nil {
+        return err
+    }
+    from, to := task.Status.State, event.Status.State
+    if from != "" && from != to && !CanTransition(from, to) {
+        return &TransitionError{From: from, To: to}
+    }
+    task.Status = event.Status
+    if recordHistory && event.Status.Message != nil {
+        task.AddToHistory(*event.Status.Message)
+    }
+    return nil
+}
+
+// applyArtifactUpdate folds an artifact chunk into the task
+func applyArtifactUpdate(task *Task, event *TaskArtifactUpdateEvent) error {
+    if err := checkEventTask(task, event.ID); err != nil {
+        return err
+    }
+    chunk := event.Artifact
+    position := -1
+    for i := range task.Artifacts {
+        if task.Artifacts[i].Index == chunk.Index {
+            position = i
+        }
+    }
+
+    if chunk.Append == nil || !*chunk.Append {
+        chunk.Append, chunk.LastChunk = nil, nil
+        if position >= 0 {
+            task.Artifacts[position] = chunk
+        } else {
+            task.AddArtifact(chunk)
+        }
+        return nil
+    }
+    if position < 0 {
+        return fmt.Errorf("%w: task %s artifact %d continues without a first chunk", ErrArtifactChunkMissing, event.ID, chunk.Index)
+    }
+
+    pending := &pendingArtifact{}
+    if _, err := pending.apply(&task.Artifacts[position]); err != nil {
+        return err
+    }
+    if _, err := pending.apply(&chunk); err != nil {
+        return err
+    }
+    task.Artifacts[position] = *pending.build()
+    return nil
+}
+
+// checkEventTask checks that an event belongs to the task, adopting its ID when the task has none
+func checkEventTask(task *Task, id string) error {
+    if task.ID == "" {
+        task.ID = id
+        return nil
+    }
+    if id != "" && id != task.ID {
+        return fmt.Errorf("%w: %s is not %s", ErrEventTaskMismatch, id, task.ID)
+    }
+    return nil
+}
+
+// TaskProjection rebuilds a task from its event log.
+// It keeps the events applied to it, so the task can be replayed to late subscribers.
+type TaskProjection struct {
+    mu     sync.Mutex
+    task   *Task
+    events []TaskEvent
+}
+
+// NewTaskProjection creates a projection starting from a copy of the task, or from an empty task when it is nil
+func NewTaskProjection(task *Task) *TaskProjection {
+    projection := &TaskProjection{task: &Task{}}
+    if task != nil {
+        if clone, err := cloneTask(task); err == nil {
+            projection.task = clone
+        }
+    }
+    return projection
+}
+
+// ProjectTask rebuilds the task from an event log
+func ProjectTask(taskID string, events []TaskEvent) (*Task, error) {
+    projection := NewTaskProjection(&Task{ID: taskID})
+    for i := range events {
+        if err := projection.Apply(&events[i]); err != nil {
+            return nil, err
+        }
+    }
+    return projection.Task(), nil
+}
+
+// Apply folds the event into the task and appends it to the log.
+// An event that cannot be applied leaves both unchanged.
+func (p *TaskProjection) Apply(event *TaskEvent) error {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if err := ApplyEvent(p.task, event); err != nil {
+        return err
+    }
+    p.events = append(p.events, *event)
+    return nil
+}
+
+// Task returns a copy of the current task
+func (p *TaskProjection) Task() *Task {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    clone, err := cloneTask(p.task)
+    if err != nil {
+        return nil
+    }
+    return clone
+}
+
+// Events returns the events applied so far
+func (p *TaskProjection) Events() []TaskEvent {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    return append([]TaskEvent(nil), p.events...)
+}
@@ -0,0 +1,147 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "io"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestProjectTask(t *testing.T) {
+    reply := a2a.NewMessage(a2a.RoleAgent, []a2a.Part{a2a.NewTextPart("need more")})
+    events := []a2a.TaskEvent{
+        {Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}},
+        {Artifact: &a2a.TaskArtifactUpdateEvent{ID: "task-1", Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("Hel")}).WithAppend(false)}},
+        {Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateInputRequired, Message: reply}}},
+        {Artifact: &a2a.TaskArtifactUpdateEvent{ID: "task-1", Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("lo")}).WithAppend(true).WithLastChunk(true)}},
+        {Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true}},
+    }
+    task, err := a2a.ProjectTask("task-1", events)
+    if err != nil {
+        t.Fatalf("ProjectTask failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateCompleted || len(task.History) != 1 || task.History[0].Role != a2a.RoleAgent {
+        t.Errorf("Status or history mismatch: %+v", task)
+    }
+    if len(task.Artifacts) != 1 || task.Artifacts[0].Parts[0].(a2a.TextPart).Text != "Hello" || task.Artifacts[0].Append != nil {
+        t.Errorf("Artifact mismatch: %+v", task.Artifacts)
+    }
+
+    projection := a2a.NewTaskProjection(task)
+    err = projection.Apply(&a2a.TaskEvent{Status: &a2a.TaskStatusUpdateEvent{ID: "task-1", Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}})
+    if !errors.Is(err, a2a.ErrInvalidTransition) || len(projection.Events()) != 0 {
+        t.Errorf("Expected a rejected transition, got %v", err)
+    }
+    err = projection.Apply(&a2a.TaskEvent{Status: &a2a.TaskStatusUpdateEvent{ID: "task-2", Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}}})
+    if !errors.Is(err, a2a.ErrEventTaskMismatch) {
+        t.Errorf("Expected a task mismatch, got %v", err)
+    }
+    err = a2a.ApplyEvent(task, a2a.TaskArtifactUpdateEvent{ID: "task-1", Artifact: *a2a.NewArtifact(nil).WithIndex(1).WithAppend(true)})
+    if !errors.Is(err, a2a.ErrArtifactChunkMissing) {
+        t.Errorf("Expected a missing chunk, got %v", err)
+    }
+}
+
+func TestStreamEventsUpdateStoredTask(t *testing.T) {
+    store := a2a.NewMemoryTaskStore()
+    handler := a2a.NewProtocolHandler(nil).
+        WithTaskStore(store).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            stream.WriteArtifactUpdate(a2a.TaskArtifactUpdateEvent{ID: params.ID, Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("out")})})
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+        })
+    client := a2a.NewClient().WithTransport("local://agent", a2a.NewLoopbackTransport(handler))
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")})}
+    stream, err := client.SendTaskSubscribe(context.Background(), params, "local://agent")
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    for {
+        if _, err := stream.Recv(); err == io.EOF {
+            break
+        } else if err != nil {
+            t.Fatalf("Recv failed: %v", err)
+        }
+    }
+    if err := stream.ProjectionError(); err != nil {
+        t.Errorf("Unexpected projection error: %v", err)
+    }
+
+    task, err := client.GetTask(context.Background(), &a2a.TaskQueryParams{ID: "task-1"}, "local://agent")
+    if err != nil {
+        t.Fatalf("GetTask failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateCompleted || len(task.Artifacts) != 1 {
+        t.Errorf("Stored task does not match the stream: %+v", task)
+    }
+}
+
+func TestStreamEventsRecordHistoryOnlyWhenAdvertised(t *testing.T) {
+    for _, advertised := range []bool{false, true} {
+        store := a2a.NewMemoryTaskStore()
+        card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{Streaming: true, StateTransitionHistory: advertised}, nil)
+        handler := a2a.NewProtocolHandler(card).
+            WithTaskStore(store).
+            HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+                reply := a2a.NewMessage(a2a.RoleAgent, []a2a.Part{a2a.NewTextPart("done")})
+                return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted, Message: reply}, Final: true})
+            })
+        client := a2a.NewClient().WithTransport("local://agent", a2a.NewLoopbackTransport(handler))
+        params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")})}
+        stream, err := client.SendTaskSubscribe(context.Background(), params, "local://agent")
+        if err != nil {
+            t.Fatalf("SendTaskSubscribe failed: %v", err)
+        }
+        for {
+            if _, err := stream.Recv(); err == io.EOF {
+                break
+            } else if err != nil {
+                t.Fatalf("Recv failed: %v", err)
+            }
+        }
+
+        task, _, err := store.Get(context.Background(), "task-1")
+        if err != nil {
+            t.Fatalf("Get failed: %v", err)
+        }
+        want := 0
+        if advertised {
+            want = 1
+        }
+        if len(task.History) != want || task.Status.Message == nil {
+            t.Errorf("StateTransitionHistory %v: expected %d history entries and a status message, got %+v", advertised, want, task)
+        }
+    }
+}
+
+func TestStreamProjectionError(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}})
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}, Final: true})
+        })
+    client := a2a.NewClient().WithTransport("local://agent", a2a.NewLoopbackTransport(handler))
+    params := &a2a.TaskSendParams{ID: "task-1", Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")})}
+    stream, err := client.SendTaskSubscribe(context.Background(), params, "local://agent")
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    events := 0
+    for {
+        if _, err := stream.Recv(); err == io.EOF {
+            break
+        } else if err != nil {
+            t.Fatalf("Recv failed: %v", err)
+        }
+        events++
+    }
+    if events != 2 {
+        t.Errorf("Expected both events to be returned, got %d", events)
+    }
+    if err := stream.ProjectionError(); !errors.Is(err, a2a.ErrInvalidTransition) {
+        t.Errorf("Expected the invalid transition to be reported, got %v", err)
+    }
+}
@@ -0,0 +1,114 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the task state machine
+package a2a
+
+import (
+    "errors"
+    "fmt"
+    "time"
+)
+
+// ErrInvalidTransition is returned for rejected task state transitions.
+// Rejected cancellations also match the protocol error ErrTaskNotCancelable.
+var ErrInvalidTransition = errors.New("a2a: invalid task state transition")
+
+// taskTransitions lists the states each non-terminal state may move to
+var taskTransitions = map[TaskState][]TaskState{
+    TaskStateSubmitted: {
+        TaskStateWorking,
+        TaskStateInputRequired,
+        TaskStateCompleted,
+        TaskStateFailed,
+        TaskStateCanceled,
+    },
+    TaskStateWorking: {
+        TaskStateWorking,
+        TaskStateInputRequired,
+        TaskStateCompleted,
+        TaskStateFailed,
+        TaskStateCanceled,
+    },
+    TaskStateInputRequired: {
+        TaskStateWorking,
+        TaskStateCompleted,
+        TaskStateFailed,
+        TaskStateCanceled,
+    },
+    TaskStateUnknown: {
+        TaskStateSubmitted,
+        TaskStateWorking,
+        TaskStateInputRequired,
+        TaskStateCompleted,
+        TaskStateFailed,
+        TaskStateCanceled,
+    },
+}
+
+// IsTerminal reports whether no further transitions are possible from the state
+func (s TaskState) IsTerminal() bool {
+    return s == TaskStateCompleted || s == TaskStateCanceled || s == TaskStateFailed
+}
+
+// IsValid reports whether the state is one of the states defined by the A2A schema
+func (s TaskState) IsValid() bool {
+    _, ok := taskTransitions[s]
+    return ok || s.IsTerminal()
+}
+
+// CanTransition reports whether a task may move from one state to another
+func CanTransition(from, to TaskState) bool {
+    for _, next := range taskTransitions[from] {
+        if next == to {
+            return true
+        }
+    }
+    return false
+}
+
+// TransitionError describes a rejected task state transition.
+// It matches ErrInvalidTransition, and ErrTaskNotCancelable when the target state is canceled.
+type TransitionError struct {
+    From TaskState
+    To   TaskState
+}
+
+// Error implements the error interface
+func (e *TransitionError) Error() string {
+    return fmt.Sprintf("a2a: invalid task state transition from %s to %s", e.From, e.To)
+}
+
+// Is reports whether the error matches target
+func (e *TransitionError) Is(target error) bool {
+    return target == ErrInvalidTransition || (e.To == TaskStateCanceled && ErrTaskNotCancelable.Is(target))
+}
+
+// Transition moves the task to newState and stamps the status timestamp.
+// The message becomes the status message; it is not appended to the task history, since a task
+// alone does not know whether its agent advertises StateTransitionHistory. Use AgentCard.TransitionTask
+// to record it when the agent does.
+func (t *Task) Transition(newState TaskState, message *Message) error {
+    return t.transition(newState, message, false)
+}
+
+// transition moves the task to newState, appending the message to the history if requested
+func (t *Task) transition(newState TaskState, message *Message, recordHistory bool) error {
+    if !CanTransition(t.Status.State, newState) {
+        return &TransitionError{From: t.Status.State, To: newState}
+    }
+    t.Status = TaskStatus{
+        State:     newState,
+        Message:   message,
+        Timestamp: time.Now(),
+    }
+    if recordHistory && message != nil {
+        t.AddToHistory(*message)
+    }
+    return nil
+}
+
+// TransitionTask moves the task to newState on behalf of this agent.
+// The status message is appended to the task history only when the agent
+// advertises the StateTransitionHistory capability.
+func (a *AgentCard) TransitionTask(task *Task, newState TaskState, message *Message) error {
+    return task.transition(newState, message, a != nil && a.Capabilities.StateTransitionHistory)
+}
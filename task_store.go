@@ -0,0 +1,245 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements task storage with optimistic versioning
+package a2a
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "sort"
+    "sync"
+)
+
+// Errors returned by task stores besides ErrTaskNotFound
+var (
+    ErrTaskExists      = errors.New("a2a: task already exists")
+    ErrVersionConflict = errors.New("a2a: task version conflict")
+)
+
+// TaskStore keeps tasks between requests.
+// Every stored task has a version that starts at 1 and is incremented by each update;
+// Update only succeeds when the caller holds the current version.
+type TaskStore interface {
+    // Get returns a copy of the task and its current version
+    Get(ctx context.Context, id string) (*Task, int64, error)
+    // Create stores a new task and returns its version
+    Create(ctx context.Context, task *Task) (int64, error)
+    // Update replaces the task if version is current and returns the new version
+    Update(ctx context.Context, task *Task, version int64) (int64, error)
+    // Delete removes the task
+    Delete(ctx context.Context, id string) error
+    // List returns copies of all tasks ordered by ID
+    List(ctx context.Context) ([]*Task, error)
+}
+
+// storedTask is a task together with its version
+type storedTask struct {
+    Task    *Task `json:"task"`
+    Version int64 `json:"version"`
+}
+
+// MemoryTaskStore is a concurrency-safe TaskStore kept in memory
+type MemoryTaskStore struct {
+    mu    sync.RWMutex
+    tasks map[string]storedTask
+}
+
+// NewMemoryTaskStore creates a new in-memory task store
+func NewMemoryTaskStore() *MemoryTaskStore {
+    return &MemoryTaskStore{
+        tasks: make(map[string]storedTask),
+    }
+}
+
+// Get implements TaskStore
+func (s *MemoryTaskStore) Get(ctx context.Context, id string) (*Task, int64, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    stored, ok := s.tasks[id]
+    if !ok {
+        return nil, 0, ErrTaskNotFound
+    }
+    task, err := cloneTask(stored.Task)
+    if err != nil {
+        return nil, 0, err
+    }
+    return task, stored.Version, nil
+}
+
+// Create implements TaskStore
+func (s *MemoryTaskStore) Create(ctx context.Context, task *Task) (int64, error) {
+    stored, err := cloneTask(task)
+    if err != nil {
+        return 0, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.tasks[task.ID]; ok {
+        return 0, ErrTaskExists
+    }
+    s.tasks[task.ID] = storedTask{Task: stored, Version: 1}
+    return 1, nil
+}
+
+// Update implements TaskStore
+func (s *MemoryTaskStore) Update(ctx context.Context, task *Task, version int64) (int64, error) {
+    stored, err := cloneTask(task)
+    if err != nil {
+        return 0, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    current, ok := s.tasks[task.ID]
+    if !ok {
+        return 0, ErrTaskNotFound
+    }
+    if current.Version != version {
+        return 0, ErrVersionConflict
+    }
+    s.tasks[task.ID] = storedTask{Task: stored, Version: version + 1}
+    return version + 1, nil
+}
+
+// Delete implements TaskStore
+func (s *MemoryTaskStore) Delete(ctx context.Context, id string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.tasks[id]; !ok {
+        return ErrTaskNotFound
+    }
+    delete(s.tasks, id)
+    return nil
+}
+
+// List implements TaskStore
+func (s *MemoryTaskStore) List(ctx context.Context) ([]*Task, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    tasks := make([]*Task, 0, len(s.tasks))
+    for _, stored := range s.tasks {
+        task, err := cloneTask(stored.Task)
+        if err != nil {
+            return nil, err
+        }
+        tasks = append(tasks, task)
+    }
+    sort.Slice(tasks, func(i, j int) bool {
+        return tasks[i].ID < tasks[j].ID
+    })
+    return tasks, nil
+}
+
+// version returns the current version of a task, or 0 if it does not exist
+func (s *MemoryTaskStore) version(id string) int64 {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.tasks[id].Version
+}
+
+// put stores a task at the given version without any checks
+func (s *MemoryTaskStore) put(task *Task, version int64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.tasks[task.ID] = storedTask{Task: task, Version: version}
+}
+
+// remove deletes a task without any checks
+func (s *MemoryTaskStore) remove(id string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.tasks, id)
+}
+
+// snapshot returns the stored tasks keyed by ID
+func (s *MemoryTaskStore) snapshot() map[string]storedTask {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    tasks := make(map[string]storedTask, len(s.tasks))
+    for id, stored := range s.tasks {
+        tasks[id] = stored
+    }
+    return tasks
+}
+
+// cloneTask returns a deep copy of the task
+func cloneTask(task *Task) (*Task, error) {
+    data, err := json.Marshal(task)
+    if err != nil {
+        return nil, err
+    }
+    var clone Task
+    if err := json.Unmarshal(data, &clone); err != nil {
+        return nil, err
+    }
+    return &clone, nil
+}
+
+// saveTask creates the task or overwrites the stored version of it.
+// A task whose state cannot follow the stored state is rejected with a TransitionError.
+func saveTask(ctx context.Context, store TaskStore, task *Task) error {
+    for {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        stored, version, err := store.Get(ctx, task.ID)
+        if errors.Is(err, ErrTaskNotFound) {
+            _, err = store.Create(ctx, task)
+            if errors.Is(err, ErrTaskExists) {
+                continue
+            }
+            return err
+        }
+        if err != nil {
+            return err
+        }
+        if from, to := stored.Status.State, task.Status.State; from != to && !CanTransition(from, to) {
+            return &TransitionError{From: from, To: to}
+        }
+        _, err = store.Update(ctx, task, version)
+        if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrTaskNotFound) {
+            continue
+        }
+        return err
+    }
+}
+
+// applyStoredEvent folds a stream event into the stored task, creating the task if it is not stored yet.
+// Status messages are appended to the history only when recordHistory is set, as by AgentCard.TransitionTask.
+func applyStoredEvent(ctx context.Context, store TaskStore, taskID string, event interface{}, recordHistory bool) error {
+    if taskID == "" {
+        return nil
+    }
+    for {
+        task, version, err := store.Get(ctx, taskID)
+        if errors.Is(err, ErrTaskNotFound) {
+            task = NewTask(taskID, TaskStateSubmitted)
+            if err := applyEvent(task, event, recordHistory); err != nil {
+                return err
+            }
+            _, err = store.Create(ctx, task)
+            if errors.Is(err, ErrTaskExists) {
+                continue
+            }
+            return err
+        }
+        if err != nil {
+            return err
+        }
+        if err := applyEvent(task, event, recordHistory); err != nil {
+            return err
+        }
+        _, err = store.Update(ctx, task, version)
+        if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrTaskNotFound) {
+            continue
+        }
+        return err
+    }
+}
+
+// trimHistory keeps only the last historyLength messages of the task history
+func trimHistory(task *Task, historyLength *int) {
+    if historyLength == nil || *historyLength < 0 || len(task.History) <= *historyLength {
+        return
+    }
+    task.History = task.History[len(task.History)-*historyLength:]
+}
@@ -0,0 +1,223 @@
+package a2a_test
+
+import (
+    "context"
+    "errors"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func testTaskStore(t *testing.T, store a2a.TaskStore) {
+    ctx := context.Background()
+    task := a2a.NewTask("task-1", a2a.TaskStateSubmitted).
+        AddToHistory(*a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hi")}))
+
+    version, err := store.Create(ctx, task)
+    if err != nil || version != 1 {
+        t.Fatalf("Create failed: %d %v", version, err)
+    }
+    if _, err := store.Create(ctx, task); !errors.Is(err, a2a.ErrTaskExists) {
+        t.Errorf("Expected ErrTaskExists, got %v", err)
+    }
+
+    // Mutating the original must not affect the stored copy
+    task.Status.State = a2a.TaskStateFailed
+    stored, version, err := store.Get(ctx, "task-1")
+    if err != nil {
+        t.Fatalf("Get failed: %v", err)
+    }
+    if stored.Status.State != a2a.TaskStateSubmitted {
+        t.Errorf("Stored task was modified: %s", stored.Status.State)
+    }
+    if _, ok := stored.History[0].Parts[0].(a2a.TextPart); !ok {
+        t.Errorf("History part is not a TextPart")
+    }
+
+    stored.Status.State = a2a.TaskStateWorking
+    newVersion, err := store.Update(ctx, stored, version)
+    if err != nil || newVersion != version+1 {
+        t.Fatalf("Update failed: %d %v", newVersion, err)
+    }
+    if _, err := store.Update(ctx, stored, version); !errors.Is(err, a2a.ErrVersionConflict) {
+        t.Errorf("Expected ErrVersionConflict, got %v", err)
+    }
+
+    store.Create(ctx, a2a.NewTask("task-0", a2a.TaskStateSubmitted))
+    tasks, err := store.List(ctx)
+    if err != nil || len(tasks) != 2 || tasks[0].ID != "task-0" {
+        t.Fatalf("List mismatch: %v %v", tasks, err)
+    }
+
+    if err := store.Delete(ctx, "task-0"); err != nil {
+        t.Fatalf("Delete failed: %v", err)
+    }
+    if _, _, err := store.Get(ctx, "task-0"); !errors.Is(err, a2a.ErrTaskNotFound) {
+        t.Errorf("Expected ErrTaskNotFound, got %v", err)
+    }
+}
+
+func TestMemoryTaskStore(t *testing.T) {
+    testTaskStore(t, a2a.NewMemoryTaskStore())
+}
+
+func TestFileTaskStore(t *testing.T) {
+    dir := t.TempDir()
+    store, err := a2a.NewFileTaskStore(dir)
+    if err != nil {
+        t.Fatalf("NewFileTaskStore failed: %v", err)
+    }
+    store.WithCompactEvery(3)
+    testTaskStore(t, store)
+    store.Create(context.Background(), a2a.NewTask("task-2", a2a.TaskStateCompleted))
+    store.Close()
+
+    // Simulate a crash in the middle of a write
+    log, err := os.OpenFile(filepath.Join(dir, "tasks.log"), os.O_WRONLY|os.O_APPEND, 0)
+    if err != nil {
+        t.Fatalf("Failed to open log: %v", err)
+    }
+    log.WriteString(`{"op":"put","id":"torn"`)
+    log.Close()
+
+    reopened, err := a2a.NewFileTaskStore(dir)
+    if err != nil {
+        t.Fatalf("Reopen failed: %v", err)
+    }
+    defer reopened.Close()
+
+    task, version, err := reopened.Get(context.Background(), "task-1")
+    if err != nil || task.Status.State != a2a.TaskStateWorking || version != 2 {
+        t.Errorf("task-1 not restored: %v %d %v", task, version, err)
+    }
+    if _, _, err := reopened.Get(context.Background(), "task-2"); err != nil {
+        t.Errorf("task-2 not restored: %v", err)
+    }
+    if _, _, err := reopened.Get(context.Background(), "task-0"); !errors.Is(err, a2a.ErrTaskNotFound) {
+        t.Errorf("Deleted task was restored: %v", err)
+    }
+    if _, err := reopened.Create(context.Background(), a2a.NewTask("task-3", a2a.TaskStateSubmitted)); err != nil {
+        t.Errorf("Create after torn write failed: %v", err)
+    }
+}
+
+func TestFileTaskStoreCompactionFailure(t *testing.T) {
+    dir := t.TempDir()
+    var compactErrs []error
+    store, err := a2a.NewFileTaskStore(dir)
+    if err != nil {
+        t.Fatalf("NewFileTaskStore failed: %v", err)
+    }
+    store.WithCompactEvery(1).OnCompactError(func(err error) {
+        compactErrs = append(compactErrs, err)
+    })
+
+    // A non-empty directory in place of the snapshot makes compaction fail
+    snapshot := filepath.Join(dir, "tasks.snapshot")
+    if err := os.MkdirAll(filepath.Join(snapshot, "blocker"), 0o755); err != nil {
+        t.Fatalf("Failed to block snapshot: %v", err)
+    }
+
+    ctx := context.Background()
+    version, err := store.Create(ctx, a2a.NewTask("task-1", a2a.TaskStateSubmitted))
+    if err != nil || version != 1 {
+        t.Fatalf("Create failed: %d %v", version, err)
+    }
+    version, err = store.Update(ctx, a2a.NewTask("task-1", a2a.TaskStateWorking), version)
+    if err != nil || version != 2 {
+        t.Fatalf("Update failed: %d %v", version, err)
+    }
+    if len(compactErrs) == 0 {
+        t.Error("Expected compaction failure to be reported")
+    }
+    store.Close()
+
+    os.RemoveAll(snapshot)
+    reopened, err := a2a.NewFileTaskStore(dir)
+    if err != nil {
+        t.Fatalf("Reopen failed: %v", err)
+    }
+    defer reopened.Close()
+    task, version, err := reopened.Get(ctx, "task-1")
+    if err != nil || task.Status.State != a2a.TaskStateWorking || version != 2 {
+        t.Errorf("task-1 not restored: %v %d %v", task, version, err)
+    }
+}
+
+func TestProtocolHandlerWithTaskStore(t *testing.T) {
+    store := a2a.NewMemoryTaskStore()
+    handler := a2a.NewProtocolHandler(nil).
+        WithTaskStore(store).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateWorking).AddToHistory(params.Message), nil
+        })
+
+    postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":1,"method":"tasks/send","params":{"id":"task-1","message":{"role":"user","parts":[{"type":"text","text":"hi"}]}}}`)
+
+    response := postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":2,"method":"tasks/get","params":{"id":"task-1","historyLength":0}}`)
+    if response.IsError() {
+        t.Fatalf("tasks/get failed: %v", response.Error.Message)
+    }
+    if history, ok := response.Result.(map[string]interface{})["history"]; ok {
+        t.Errorf("History was not trimmed: %v", history)
+    }
+
+    response = postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":3,"method":"tasks/cancel","params":{"id":"task-1"}}`)
+    if response.IsError() {
+        t.Fatalf("tasks/cancel failed: %v", response.Error.Message)
+    }
+    task, _, _ := store.Get(context.Background(), "task-1")
+    if task.Status.State != a2a.TaskStateCanceled {
+        t.Errorf("Task was not canceled: %s", task.Status.State)
+    }
+
+    response = postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":4,"method":"tasks/cancel","params":{"id":"task-1"}}`)
+    if !response.IsError() || response.Error.Code != a2a.ErrCodeTaskNotCancelable {
+        t.Errorf("Expected task not cancelable, got %+v", response.Error)
+    }
+
+    response = postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":5,"method":"tasks/get","params":{"id":"missing"}}`)
+    if !response.IsError() || response.Error.Code != a2a.ErrCodeTaskNotFound {
+        t.Errorf("Expected task not found, got %+v", response.Error)
+    }
+}
+
+func TestProtocolHandlerEnforcesStoredState(t *testing.T) {
+    store := a2a.NewMemoryTaskStore()
+    canceled := 0
+    handler := a2a.NewProtocolHandler(nil).
+        WithTaskStore(store).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            state := a2a.TaskStateCompleted
+            if _, _, err := store.Get(ctx, params.ID); err == nil {
+                state = a2a.TaskStateWorking
+            }
+            return a2a.NewTask(params.ID, state), nil
+        }).
+        HandleTaskCancel(func(ctx context.Context, params *a2a.TaskIdParams) (*a2a.Task, error) {
+            canceled++
+            return a2a.NewTask(params.ID, a2a.TaskStateCanceled), nil
+        })
+
+    send := `{"jsonrpc":"2.0","id":1,"method":"tasks/send","params":{"id":"task-1","message":{"role":"user","parts":[{"type":"text","text":"hi"}]}}}`
+    if response := postJSONRPC(t, handler, send); response.IsError() {
+        t.Fatalf("tasks/send failed: %v", response.Error.Message)
+    }
+
+    // A completed task cannot be reopened by a later send, nor canceled by the registered handler
+    if response := postJSONRPC(t, handler, send); !response.IsError() || response.Error.Code != a2a.ErrCodeInvalidRequest {
+        t.Errorf("Expected the reopening send to be rejected, got %+v", response.Error)
+    }
+    response := postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":2,"method":"tasks/cancel","params":{"id":"task-1"}}`)
+    if !response.IsError() || response.Error.Code != a2a.ErrCodeTaskNotCancelable {
+        t.Errorf("Expected task not cancelable, got %+v", response.Error)
+    }
+    if canceled != 0 {
+        t.Errorf("Cancel handler called for a completed task")
+    }
+    if task, _, _ := store.Get(context.Background(), "task-1"); task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("Stored task changed: %s", task.Status.State)
+    }
+}
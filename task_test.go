@@ -0,0 +1,132 @@
+package a2a_test
+
+import (
+    "encoding/json"
+    "errors"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestTaskSerialization(t *testing.T) {
+    message := a2a.NewMessage(a2a.RoleAgent, []a2a.Part{
+        a2a.NewTextPart("done"),
+        a2a.NewFilePart(a2a.NewFileContentWithURI("report.pdf", "application/pdf", "https://example.com/report.pdf")),
+        a2a.NewDataPart(map[string]interface{}{"score": 0.9}),
+    })
+    task := a2a.NewTask("task-1", a2a.TaskStateCompleted).
+        WithMessage(message).
+        AddToHistory(*message).
+        AddArtifact(*a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("result")}).WithName("output"))
+
+    data, err := task.ToJSON()
+    if err != nil {
+        t.Fatalf("Failed to serialize task: %v", err)
+    }
+
+    parsedTask, err := a2a.TaskFromJSON(data)
+    if err != nil {
+        t.Fatalf("Failed to deserialize task: %v", err)
+    }
+
+    if len(parsedTask.Status.Message.Parts) != 3 {
+        t.Fatalf("Status parts count mismatch: expected %d, got %d", 3, len(parsedTask.Status.Message.Parts))
+    }
+    if _, ok := parsedTask.Status.Message.Parts[1].(a2a.FilePart); !ok {
+        t.Errorf("Second status part is not a FilePart")
+    }
+    if _, ok := parsedTask.History[0].Parts[2].(a2a.DataPart); !ok {
+        t.Errorf("Third history part is not a DataPart")
+    }
+    textPart, ok := parsedTask.Artifacts[0].Parts[0].(a2a.TextPart)
+    if !ok || textPart.Text != "result" {
+        t.Errorf("Artifact part mismatch: %#v", parsedTask.Artifacts[0].Parts[0])
+    }
+}
+
+func TestParseTaskArtifactUpdate(t *testing.T) {
+    event := a2a.TaskArtifactUpdateEvent{
+        ID:       "task-1",
+        Artifact: *a2a.NewArtifact([]a2a.Part{a2a.NewTextPart("chunk")}).WithLastChunk(true),
+    }
+    data, err := json.Marshal(a2a.SendTaskStreamingResponse{JSONRPC: a2a.JSONRPCVersion, ID: 1, Result: event})
+    if err != nil {
+        t.Fatalf("Failed to serialize response: %v", err)
+    }
+
+    protocol := a2a.NewProtocol()
+    response, err := protocol.ParseStreamingResponse(data)
+    if err != nil {
+        t.Fatalf("Failed to parse response: %v", err)
+    }
+    parsedEvent, err := protocol.ParseTaskArtifactUpdate(response)
+    if err != nil {
+        t.Fatalf("Failed to parse event: %v", err)
+    }
+    if parsedEvent.Artifact.LastChunk == nil || !*parsedEvent.Artifact.LastChunk {
+        t.Errorf("LastChunk flag lost")
+    }
+    if _, ok := parsedEvent.Artifact.Parts[0].(a2a.TextPart); !ok {
+        t.Errorf("Artifact part is not a TextPart")
+    }
+}
+
+func TestTaskTransition(t *testing.T) {
+    task := a2a.NewTask("task-1", a2a.TaskStateSubmitted)
+    before := task.Status.Timestamp
+
+    message := a2a.NewMessage(a2a.RoleAgent, []a2a.Part{a2a.NewTextPart("need more input")})
+    if err := task.Transition(a2a.TaskStateInputRequired, message); err != nil {
+        t.Fatalf("Transition failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateInputRequired || task.Status.Message != message {
+        t.Errorf("Status not updated: %+v", task.Status)
+    }
+    if task.Status.Timestamp.Before(before) {
+        t.Errorf("Timestamp not stamped")
+    }
+    if len(task.History) != 0 {
+        t.Errorf("History recorded without StateTransitionHistory: got %d messages", len(task.History))
+    }
+
+    if err := task.Transition(a2a.TaskStateCompleted, nil); err != nil {
+        t.Fatalf("Transition failed: %v", err)
+    }
+    if !task.Status.State.IsTerminal() {
+        t.Errorf("Completed state is not terminal")
+    }
+
+    err := task.Transition(a2a.TaskStateWorking, nil)
+    if !errors.Is(err, a2a.ErrInvalidTransition) || errors.Is(err, a2a.ErrTaskNotCancelable) {
+        t.Errorf("Expected invalid transition, got %v", err)
+    }
+    err = task.Transition(a2a.TaskStateCanceled, nil)
+    if !errors.Is(err, a2a.ErrTaskNotCancelable) {
+        t.Errorf("Expected not cancelable, got %v", err)
+    }
+
+    if a2a.CanTransition(a2a.TaskStateFailed, a2a.TaskStateCanceled) {
+        t.Errorf("Failed task can be canceled")
+    }
+    if !a2a.CanTransition(a2a.TaskStateWorking, a2a.TaskStateWorking) {
+        t.Errorf("Working task cannot report progress")
+    }
+}
+
+func TestAgentCardTransitionTask(t *testing.T) {
+    message := a2a.NewMessage(a2a.RoleAgent, []a2a.Part{a2a.NewTextPart("working")})
+
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{}, nil)
+    task := a2a.NewTask("task-1", a2a.TaskStateSubmitted)
+    card.TransitionTask(task, a2a.TaskStateWorking, message)
+    if len(task.History) != 0 {
+        t.Errorf("History recorded without StateTransitionHistory")
+    }
+
+    card.Capabilities.StateTransitionHistory = true
+    task = a2a.NewTask("task-2", a2a.TaskStateSubmitted)
+    card.TransitionTask(task, a2a.TaskStateWorking, message)
+    if len(task.History) != 1 {
+        t.Errorf("History not recorded with StateTransitionHistory")
+    }
+}
@@ -0,0 +1,135 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements a local OAuth2 token endpoint issuing JWTs with the client credentials grant
+package a2a
+
+import (
+    "crypto/subtle"
+    "encoding/json"
+    "net/http"
+    "net/url"
+    "sync"
+    "time"
+)
+
+// TokenEndpoint is a minimal OAuth2 token endpoint for the client credentials grant.
+// It stands in for an authorization server in development and tests: registered clients
+// receive JWTs that a JWTAuthenticator holding the matching key accepts.
+type TokenEndpoint struct {
+    key      interface{}
+    issuer   string
+    audience string
+    ttl      time.Duration
+
+    mu      sync.RWMutex
+    clients map[string]string
+}
+
+// NewTokenEndpoint creates a token endpoint signing tokens with the key.
+// The key is a []byte secret, an *rsa.PrivateKey or an *ecdsa.PrivateKey.
+func NewTokenEndpoint(key interface{}) *TokenEndpoint {
+    return &TokenEndpoint{
+        key:     key,
+        ttl:     time.Hour,
+        clients: make(map[string]string),
+    }
+}
+
+// WithClient registers a client allowed to request tokens
+func (e *TokenEndpoint) WithClient(clientID, clientSecret string) *TokenEndpoint {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    e.clients[clientID] = clientSecret
+    return e
+}
+
+// WithIssuer sets the iss claim of issued tokens
+func (e *TokenEndpoint) WithIssuer(issuer string) *TokenEndpoint {
+    e.issuer = issuer
+    return e
+}
+
+// WithAudience sets the aud claim of issued tokens
+func (e *TokenEndpoint) WithAudience(audience string) *TokenEndpoint {
+    e.audience = audience
+    return e
+}
+
+// WithTTL sets how long issued tokens are valid
+func (e *TokenEndpoint) WithTTL(ttl time.Duration) *TokenEndpoint {
+    e.ttl = ttl
+    return e
+}
+
+// ServeHTTP implements http.Handler
+func (e *TokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        w.Header().Set("Allow", http.MethodPost)
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    if err := r.ParseForm(); err != nil {
+        writeTokenError(w, http.StatusBadRequest, "invalid_request")
+        return
+    }
+    if r.PostForm.Get("grant_type") != "client_credentials" {
+        writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+        return
+    }
+
+    clientID, clientSecret, ok := r.BasicAuth()
+    if ok {
+        // Basic credentials of OAuth2 clients are form-encoded before they are joined
+        clientID, _ = url.QueryUnescape(clientID)
+        clientSecret, _ = url.QueryUnescape(clientSecret)
+    } else {
+        clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+    }
+    e.mu.RLock()
+    expected, registered := e.clients[clientID]
+    e.mu.RUnlock()
+    if !registered || subtle.ConstantTimeCompare([]byte(expected), []byte(clientSecret)) != 1 {
+        w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+        writeTokenError(w, http.StatusUnauthorized, "invalid_client")
+        return
+    }
+
+    now := time.Now()
+    claims := map[string]interface{}{
+        "sub": clientID,
+        "iat": now.Unix(),
+        "exp": now.Add(e.ttl).Unix(),
+        "jti": randomID(),
+    }
+    if e.issuer != "" {
+        claims["iss"] = e.issuer
+    }
+    if e.audience != "" {
+        claims["aud"] = e.audience
+    }
+    scope := r.PostForm.Get("scope")
+    if scope != "" {
+        claims["scope"] = scope
+    }
+    token, err := signJWT(claims, e.key)
+    if err != nil {
+        writeTokenError(w, http.StatusInternalServerError, "server_error")
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    json.NewEncoder(w).Encode(tokenResponse{
+        AccessToken: token,
+        TokenType:   "Bearer",
+        ExpiresIn:   int64(e.ttl / time.Second),
+        Scope:       scope,
+    })
+}
+
+// writeTokenError writes an OAuth2 error response
+func writeTokenError(w http.ResponseWriter, status int, code string) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
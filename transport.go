@@ -0,0 +1,780 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the message transports that carry JSON-RPC over connections other than HTTP
+package a2a
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Methods of the notifications exchanged over message transports
+const (
+    // MethodStreamEvent carries one event of a streaming request from the agent to the client
+    MethodStreamEvent = "tasks/streamEvent"
+    // MethodCancelStream asks the agent to stop a streaming request the client no longer reads
+    MethodCancelStream = "tasks/cancelStream"
+    // MethodStreamCredit allows the agent to send more events of a streaming request on transports with flow control
+    MethodStreamCredit = "tasks/streamCredit"
+)
+
+// DefaultStreamWindow is the number of events an agent may send per stream before the client grants more
+// on transports with flow control
+const DefaultStreamWindow = 64
+
+// ErrTransportClosed is returned for requests on a transport whose connection has ended
+var ErrTransportClosed = errors.New("a2a: transport closed")
+
+// Transport carries the JSON-RPC payloads of a client to an agent over a connection other than HTTP.
+// Register one for an agent URL with Client.WithTransport.
+type Transport interface {
+    // Call sends a request or batch and returns the raw response; notifications return nil
+    Call(ctx context.Context, request []byte) ([]byte, error)
+    // Stream sends a streaming request and returns the events answering it
+    Stream(ctx context.Context, request []byte) (EventReader, error)
+    // Close ends the connection
+    Close() error
+}
+
+// EventReader delivers the events answering a streaming request
+type EventReader interface {
+    // Next returns the ID and the streaming response of the next event, or io.EOF at the end of the stream
+    Next() (id string, data []byte, err error)
+    // Close stops reading the stream
+    Close() error
+}
+
+// StreamEventParams are the params of a MethodStreamEvent notification.
+// The ID of the response is the ID of the streaming request the event answers.
+type StreamEventParams struct {
+    EventID  uint64                     `json:"eventId,omitempty"`
+    Response *SendTaskStreamingResponse `json:"response"`
+}
+
+// streamCreditParams are the params of MethodCancelStream and MethodStreamCredit notifications
+type streamCreditParams struct {
+    ID     json.RawMessage `json:"id"`
+    Credit int             `json:"credit,omitempty"`
+}
+
+// streamEnd is the result of the response that ends a streaming request on a message transport
+type streamEnd struct {
+    Done bool `json:"done"`
+}
+
+// WithTransport sends the requests for the agent at url over the transport instead of HTTP
+func (c *Client) WithTransport(url string, transport Transport) *Client {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.transports == nil {
+        c.transports = make(map[string]Transport)
+    }
+    c.transports[url] = transport
+    return c
+}
+
+// webSocketDial is a WebSocket connection being opened to an agent, shared by the requests waiting for it
+type webSocketDial struct {
+    done      chan struct{}
+    transport *WebSocketTransport
+    // failed and retryAt are set when the dial failed, until when the agent is reached over HTTP
+    failed  bool
+    retryAt time.Time
+}
+
+// transportFor returns the transport for the agent at url, or nil for HTTP.
+// When no transport is registered and the known card of the agent advertises a WebSocket
+// interface, a connection is opened and kept for later requests; concurrent requests wait for
+// the same connection. If it cannot be opened, the agent is reached over HTTP until the redial
+// backoff has passed.
+func (c *Client) transportFor(ctx context.Context, url string) Transport {
+    c.mu.Lock()
+    transport, registered := c.transports[url]
+    if ws, ok := transport.(*WebSocketTransport); ok && ws.dialed && ws.Closed() {
+        delete(c.transports, url)
+        transport, registered = nil, false
+    }
+    card := c.agents[strings.TrimSuffix(url, "/")]
+    if registered || card == nil {
+        c.mu.Unlock()
+        return transport
+    }
+    wsURL := card.InterfaceURL(TransportWebSocket)
+    if wsURL == "" {
+        c.mu.Unlock()
+        return nil
+    }
+
+    dial := c.dials[url]
+    if dial != nil && dial.failed && !time.Now().Before(dial.retryAt) {
+        dial = nil
+    }
+    if dial == nil {
+        dial = &webSocketDial{done: make(chan struct{})}
+        if c.dials == nil {
+            c.dials = make(map[string]*webSocketDial)
+        }
+        c.dials[url] = dial
+        // A request giving up does not fail the connection the others wait for
+        go c.dial(context.WithoutCancel(ctx), dial, url, wsURL)
+    }
+    c.mu.Unlock()
+
+    select {
+    case <-dial.done:
+    case <-ctx.Done():
+        return nil
+    }
+    if dial.transport == nil {
+        return nil
+    }
+    return dial.transport
+}
+
+// dial opens the WebSocket connection of a webSocketDial and registers it for the agent at url
+func (c *Client) dial(ctx context.Context, dial *webSocketDial, url, wsURL string) {
+    ws, err := c.dialWebSocket(ctx, url, wsURL)
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    defer close(dial.done)
+    if err != nil {
+        // Remembered, so the handshake is not attempted on every request
+        dial.failed = true
+        dial.retryAt = time.Now().Add(c.wsRedialBackoff)
+        return
+    }
+    ws.dialed = true
+    dial.transport = ws
+    if c.transports == nil {
+        c.transports = make(map[string]Transport)
+    }
+    c.transports[url] = ws
+    delete(c.dials, url)
+}
+
+// sseEventReader reads the events of a Server-Sent Events response body
+type sseEventReader struct {
+    body   io.ReadCloser
+    reader *sseReader
+}
+
+// newSSEEventReader creates an event reader of a response body
+func newSSEEventReader(body io.ReadCloser) *sseEventReader {
+    return &sseEventReader{body: body, reader: newSSEReader(body)}
+}
+
+// Next implements EventReader
+func (r *sseEventReader) Next() (string, []byte, error) {
+    event, err := r.reader.next()
+    if err != nil {
+        return "", nil, err
+    }
+    return event.ID, event.Data, nil
+}
+
+// Close implements EventReader
+func (r *sseEventReader) Close() error {
+    return r.body.Close()
+}
+
+// frameConn reads and writes whole JSON-RPC messages over a connection
+type frameConn interface {
+    readFrame() ([]byte, error)
+    writeFrame(data []byte) error
+}
+
+// serveFrames answers the messages read from the connection until it ends.
+// Requests are processed concurrently; the events of streaming requests are sent as
+// MethodStreamEvent notifications, followed by a response to the request once the stream ends.
+// Requests are answered in the requested protocol version where the method allows it.
+// With a window, each stream sends that many events and then waits for MethodStreamCredit.
+func (h *ProtocolHandler) serveFrames(ctx context.Context, conn frameConn, requested ProtocolVersion, window int) error {
+    ctx, cancel := context.WithCancel(ctx)
+    server := &frameServer{
+        handler:   h,
+        conn:      conn,
+        requested: requested,
+        window:    window,
+        streams:   make(map[string]*frameStream),
+    }
+    // Requests still running when the connection ends are canceled, as with a dropped HTTP connection
+    defer server.wg.Wait()
+    defer cancel()
+    for {
+        frame, err := conn.readFrame()
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        server.wg.Add(1)
+        go func() {
+            defer server.wg.Done()
+            server.serveFrame(ctx, frame)
+        }()
+    }
+}
+
+// frameServer holds the state of a connection served by serveFrames
+type frameServer struct {
+    handler   *ProtocolHandler
+    conn      frameConn
+    requested ProtocolVersion
+    window    int
+    writeMu   sync.Mutex
+    wg        sync.WaitGroup
+
+    mu      sync.Mutex
+    streams map[string]*frameStream
+}
+
+// frameStream is a streaming request in progress on a connection
+type frameStream struct {
+    cancel context.CancelFunc
+    flow   *streamFlow
+}
+
+// write sends one message, serializing the writes of concurrent requests
+func (s *frameServer) write(v interface{}) error {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return err
+    }
+    s.writeMu.Lock()
+    defer s.writeMu.Unlock()
+    return s.conn.writeFrame(data)
+}
+
+// serveFrame answers one message
+func (s *frameServer) serveFrame(ctx context.Context, frame []byte) {
+    h := s.handler
+    if isBatch(frame) {
+        if response := h.processBatch(ctx, frame, s.requested); response != nil {
+            s.write(response)
+        }
+        return
+    }
+
+    var request rpcRequest
+    if err := json.Unmarshal(frame, &request); err != nil {
+        s.write(NewJSONRPCErrorResponse(nil, JSONParseError()))
+        return
+    }
+    request.version = h.requestVersion(request.Method, s.requested)
+    switch {
+    case request.Method == MethodCancelStream:
+        if stream := s.stream(request.Params); stream != nil {
+            stream.cancel()
+        }
+    case request.Method == MethodStreamCredit:
+        if stream := s.stream(request.Params); stream != nil && stream.flow != nil {
+            stream.flow.grant(request.Params)
+        }
+    case isStreamingMethod(request.Method):
+        s.serveStream(ctx, &request)
+    default:
+        response := h.handleRequest(ctx, &request)
+        if !request.isNotification() {
+            s.write(response)
+        }
+    }
+}
+
+// serveStream runs a streaming request, sending its events as notifications
+func (s *frameServer) serveStream(ctx context.Context, request *rpcRequest) {
+    if request.JSONRPC != JSONRPCVersion {
+        s.write(NewJSONRPCErrorResponse(request.responseID(), InvalidRequestError()))
+        return
+    }
+    run, rpcErr := s.handler.prepareStream(request)
+    if rpcErr != nil {
+        s.write(NewJSONRPCErrorResponse(request.responseID(), rpcErr))
+        return
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    state := &frameStream{cancel: cancel}
+    if s.window > 0 {
+        state.flow = newStreamFlow(s.window)
+    }
+    key := idKey(request.ID)
+    s.mu.Lock()
+    s.streams[key] = state
+    s.mu.Unlock()
+    defer func() {
+        s.mu.Lock()
+        delete(s.streams, key)
+        s.mu.Unlock()
+    }()
+
+    stream := s.handler.newStream(request, &frameSink{server: s, ctx: ctx, flow: state.flow})
+    runStream(ctx, run, stream)
+    s.write(NewJSONRPCResponse(request.responseID(), streamEnd{Done: true}))
+}
+
+// stream returns the streaming request named by the params of a notification about it
+func (s *frameServer) stream(params json.RawMessage) *frameStream {
+    var target idField
+    if json.Unmarshal(params, &target) != nil {
+        return nil
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.streams[idKey(target.ID)]
+}
+
+// streamFlow counts the events a stream may still send before the client grants more
+type streamFlow struct {
+    mu     sync.Mutex
+    credit int
+    wake   chan struct{}
+}
+
+// newStreamFlow creates the flow control of a stream with the initial credit
+func newStreamFlow(credit int) *streamFlow {
+    return &streamFlow{credit: credit, wake: make(chan struct{}, 1)}
+}
+
+// acquire waits until the stream may send one more event
+func (f *streamFlow) acquire(ctx context.Context) error {
+    for {
+        f.mu.Lock()
+        if f.credit > 0 {
+            f.credit--
+            f.mu.Unlock()
+            return nil
+        }
+        f.mu.Unlock()
+        select {
+        case <-f.wake:
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+}
+
+// grant adds the credit of a MethodStreamCredit notification
+func (f *streamFlow) grant(params json.RawMessage) {
+    var credit streamCreditParams
+    if json.Unmarshal(params, &credit) != nil || credit.Credit <= 0 {
+        return
+    }
+    f.mu.Lock()
+    f.credit += credit.Credit
+    f.mu.Unlock()
+    select {
+    case f.wake <- struct{}{}:
+    default:
+    }
+}
+
+// frameSink sends stream responses as MethodStreamEvent notifications, waiting for credit when the stream has flow control
+type frameSink struct {
+    server *frameServer
+    ctx    context.Context
+    flow   *streamFlow
+}
+
+// writeEvent implements eventSink
+func (s *frameSink) writeEvent(response *SendTaskStreamingResponse, eventID uint64) error {
+    if s.flow != nil {
+        if err := s.flow.acquire(s.ctx); err != nil {
+            return err
+        }
+    }
+    return s.server.write(NewJSONRPCRequest(nil, MethodStreamEvent, StreamEventParams{EventID: eventID, Response: response}))
+}
+
+// rpcMux matches the messages read from a connection to the requests a client sent over it
+type rpcMux struct {
+    write func(data []byte) error
+    // window is the credit granted per stream on transports with flow control, zero without
+    window int
+
+    mu      sync.Mutex
+    calls   map[string]*muxCall
+    streams map[string]*muxStream
+    err     error
+    done    chan struct{}
+}
+
+// muxCall waits for the response to a request or batch
+type muxCall struct {
+    keys     []string
+    batch    bool
+    response chan []byte
+}
+
+// muxItem is an event of a stream, or its end when data is nil; err is set when the request was rejected
+type muxItem struct {
+    id   string
+    data []byte
+    err  error
+}
+
+// newRPCMux creates a mux sending messages with write
+func newRPCMux(write func(data []byte) error) *rpcMux {
+    return &rpcMux{
+        write:   write,
+        calls:   make(map[string]*muxCall),
+        streams: make(map[string]*muxStream),
+        done:    make(chan struct{}),
+    }
+}
+
+// idField decodes the ID of a JSON-RPC message
+type idField struct {
+    ID json.RawMessage `json:"id"`
+}
+
+// requestKeys returns the keys of the IDs of a request or the requests of a batch
+func requestKeys(request []byte) ([]string, bool, error) {
+    var ids []idField
+    batch := isBatch(request)
+    if batch {
+        if err := json.Unmarshal(request, &ids); err != nil {
+            return nil, true, err
+        }
+    } else {
+        ids = make([]idField, 1)
+        if err := json.Unmarshal(request, &ids[0]); err != nil {
+            return nil, false, err
+        }
+    }
+    var keys []string
+    for _, id := range ids {
+        if key := idKey(id.ID); key != "" {
+            keys = append(keys, key)
+        }
+    }
+    return keys, batch, nil
+}
+
+// idKey returns the key of a raw request ID, or "" for a missing or null ID
+func idKey(id json.RawMessage) string {
+    var compact bytes.Buffer
+    if len(id) == 0 || json.Compact(&compact, id) != nil || compact.String() == "null" {
+        return ""
+    }
+    return compact.String()
+}
+
+// call sends a request or batch and waits for its response
+func (m *rpcMux) call(ctx context.Context, request []byte) ([]byte, error) {
+    keys, batch, err := requestKeys(request)
+    if err != nil {
+        return nil, err
+    }
+    if len(keys) == 0 {
+        // Notifications are not answered
+        return nil, m.send(request)
+    }
+
+    call := &muxCall{keys: keys, batch: batch, response: make(chan []byte, 1)}
+    m.mu.Lock()
+    if m.err != nil {
+        m.mu.Unlock()
+        return nil, m.err
+    }
+    for _, key := range keys {
+        m.calls[key] = call
+    }
+    m.mu.Unlock()
+    defer m.forget(call)
+
+    if err := m.send(request); err != nil {
+        return nil, err
+    }
+    select {
+    case response := <-call.response:
+        return response, nil
+    case <-m.done:
+        return nil, m.closedErr()
+    case <-ctx.Done():
+        return nil, ctx.Err()
+    }
+}
+
+// stream sends a streaming request and waits until the stream starts or is rejected
+func (m *rpcMux) stream(ctx context.Context, request []byte) (EventReader, error) {
+    keys, _, err := requestKeys(request)
+    if err != nil {
+        return nil, err
+    }
+    if len(keys) != 1 {
+        return nil, errors.New("a2a: streaming request needs an id")
+    }
+
+    buffer := muxStreamBuffer
+    if m.window > 0 {
+        // The agent sends at most a window of events ahead, plus the response ending the stream
+        buffer = m.window + 1
+    }
+    stream := &muxStream{
+        mux:   m,
+        ctx:   ctx,
+        key:   keys[0],
+        items: make(chan muxItem, buffer),
+        done:  make(chan struct{}),
+    }
+    m.mu.Lock()
+    if m.err != nil {
+        m.mu.Unlock()
+        return nil, m.err
+    }
+    m.streams[stream.key] = stream
+    m.mu.Unlock()
+
+    if err := m.send(request); err != nil {
+        m.forgetStream(stream)
+        return nil, err
+    }
+    // Wait for the first message so that a rejected request fails here, as it does over HTTP
+    select {
+    case item := <-stream.items:
+        if item.data == nil && item.err != nil {
+            return nil, item.err
+        }
+        stream.first = &item
+        return stream, nil
+    case <-m.done:
+        m.forgetStream(stream)
+        return nil, m.closedErr()
+    case <-ctx.Done():
+        stream.Close()
+        return nil, ctx.Err()
+    }
+}
+
+// send writes one message
+func (m *rpcMux) send(data []byte) error {
+    select {
+    case <-m.done:
+        return m.closedErr()
+    default:
+    }
+    return m.write(data)
+}
+
+// forget drops a call that was answered or abandoned
+func (m *rpcMux) forget(call *muxCall) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, key := range call.keys {
+        if m.calls[key] == call {
+            delete(m.calls, key)
+        }
+    }
+}
+
+// forgetStream drops a stream that ended or was closed
+func (m *rpcMux) forgetStream(stream *muxStream) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.streams[stream.key] == stream {
+        delete(m.streams, stream.key)
+    }
+}
+
+// dispatch routes a message read from the connection to the call or stream waiting for it
+func (m *rpcMux) dispatch(frame []byte) {
+    if isBatch(frame) {
+        var responses []idField
+        if json.Unmarshal(frame, &responses) != nil {
+            return
+        }
+        for _, response := range responses {
+            if m.answer(idKey(response.ID), frame) {
+                return
+            }
+        }
+        return
+    }
+
+    var message struct {
+        ID     json.RawMessage `json:"id"`
+        Method string          `json:"method"`
+        Params json.RawMessage `json:"params"`
+        Error  *JSONRPCError   `json:"error"`
+    }
+    if json.Unmarshal(frame, &message) != nil {
+        return
+    }
+    if message.Method == MethodStreamEvent {
+        var params struct {
+            EventID  uint64          `json:"eventId"`
+            Response json.RawMessage `json:"response"`
+        }
+        var response idField
+        if json.Unmarshal(message.Params, &params) != nil || json.Unmarshal(params.Response, &response) != nil {
+            return
+        }
+        id := ""
+        if params.EventID != 0 {
+            id = strconv.FormatUint(params.EventID, 10)
+        }
+        m.deliver(idKey(response.ID), muxItem{id: id, data: params.Response})
+        return
+    }
+    if message.Method != "" {
+        return
+    }
+
+    key := idKey(message.ID)
+    if m.answer(key, frame) {
+        return
+    }
+    // The response to a streaming request ends the stream, or rejects it before it starts
+    item := muxItem{}
+    if message.Error != nil {
+        item.err = message.Error
+    }
+    m.deliver(key, item)
+}
+
+// answer hands a response to the call waiting for it.
+// A response without ID answers a pending batch, which is how a batch rejected as a whole is answered.
+func (m *rpcMux) answer(key string, frame []byte) bool {
+    m.mu.Lock()
+    call := m.calls[key]
+    if key == "" {
+        for _, pending := range m.calls {
+            if pending.batch {
+                call = pending
+                break
+            }
+        }
+    }
+    m.mu.Unlock()
+    if call == nil {
+        return false
+    }
+    select {
+    case call.response <- frame:
+    default:
+    }
+    return true
+}
+
+// deliver queues an item on the stream of the request, blocking while the stream is full
+func (m *rpcMux) deliver(key string, item muxItem) {
+    m.mu.Lock()
+    stream := m.streams[key]
+    m.mu.Unlock()
+    if stream == nil {
+        return
+    }
+    select {
+    case stream.items <- item:
+    case <-stream.done:
+    }
+}
+
+// fail ends the mux after the connection ended with err
+func (m *rpcMux) fail(err error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.err != nil {
+        return
+    }
+    if err == nil || err == io.EOF {
+        err = ErrTransportClosed
+    }
+    m.err = err
+    close(m.done)
+}
+
+// closedErr returns the error the mux ended with
+func (m *rpcMux) closedErr() error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.err
+}
+
+// muxStreamBuffer is the number of events queued per stream before reading the connection blocks
+const muxStreamBuffer = 64
+
+// muxStream is the EventReader of a streaming request sent over a mux
+type muxStream struct {
+    mux   *rpcMux
+    ctx   context.Context
+    key   string
+    items chan muxItem
+    first *muxItem
+
+    // consumed counts the events read since credit was last granted
+    consumed int
+
+    // done is closed by Close; ended records that the agent ended the stream
+    closeOnce sync.Once
+    done      chan struct{}
+    endedMu   sync.Mutex
+    ended     bool
+}
+
+// Next implements EventReader
+func (s *muxStream) Next() (string, []byte, error) {
+    var item muxItem
+    if s.first != nil {
+        item, s.first = *s.first, nil
+    } else {
+        select {
+        case item = <-s.items:
+        case <-s.done:
+            return "", nil, io.EOF
+        case <-s.mux.done:
+            return "", nil, io.ErrUnexpectedEOF
+        case <-s.ctx.Done():
+            return "", nil, s.ctx.Err()
+        }
+    }
+    if item.data == nil {
+        s.endedMu.Lock()
+        s.ended = true
+        s.endedMu.Unlock()
+        s.mux.forgetStream(s)
+        if item.err != nil {
+            return "", nil, item.err
+        }
+        return "", nil, io.EOF
+    }
+    if window := s.mux.window; window > 0 {
+        s.consumed++
+        if s.consumed >= (window+1)/2 {
+            s.notify(MethodStreamCredit, s.consumed)
+            s.consumed = 0
+        }
+    }
+    return item.id, item.data, nil
+}
+
+// notify sends a notification about the stream to the agent
+func (s *muxStream) notify(method string, credit int) {
+    data, err := json.Marshal(NewJSONRPCRequest(nil, method, streamCreditParams{ID: json.RawMessage(s.key), Credit: credit}))
+    if err == nil {
+        s.mux.send(data)
+    }
+}
+
+// Close implements EventReader, asking the agent to stop the stream if it has not ended
+func (s *muxStream) Close() error {
+    s.closeOnce.Do(func() {
+        close(s.done)
+        s.mux.forgetStream(s)
+        s.endedMu.Lock()
+        ended := s.ended
+        s.endedMu.Unlock()
+        if !ended {
+            s.notify(MethodCancelStream, 0)
+        }
+    })
+    return nil
+}
@@ -0,0 +1,130 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements serving and calling agents over Unix domain sockets
+package a2a
+
+import (
+    "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "os/user"
+    "strconv"
+)
+
+// UnixScheme is the URL scheme of agents reached over a Unix domain socket, as in unix:///run/a2a/agent.sock.
+// The path of the URL is the socket; requests are sent to the root path of the agent served on it.
+const UnixScheme = "unix"
+
+// AuthSchemePeerCredentials is the scheme of principals authenticated by the peer credentials of a Unix domain socket
+const AuthSchemePeerCredentials = "PeerCredentials"
+
+// ErrPeerCredentialsUnsupported is returned where the platform does not report the credentials of socket peers
+var ErrPeerCredentialsUnsupported = errors.New("a2a: peer credentials not supported on this platform")
+
+// PeerCredentials identify the process at the other end of a Unix domain socket, as reported by the kernel
+type PeerCredentials struct {
+    UID int
+    GID int
+    PID int
+}
+
+// PeerCredentialsContext returns a copy of ctx carrying the principal identified by the peer credentials
+// of conn, when it is a Unix domain socket. It is installed as the ConnContext of the servers created by
+// NewServer; set it on other http.Servers to identify their Unix socket callers the same way.
+// The principal only stands in for the card's schemes where AuthMiddleware.WithPeerCredentials allows it.
+func PeerCredentialsContext(ctx context.Context, conn net.Conn) context.Context {
+    if tlsConn, ok := conn.(*tls.Conn); ok {
+        conn = tlsConn.NetConn()
+    }
+    unixConn, ok := conn.(*net.UnixConn)
+    if !ok {
+        return ctx
+    }
+    creds, err := readPeerCredentials(unixConn)
+    if err != nil {
+        return ctx
+    }
+    return ContextWithPrincipal(ctx, creds.principal())
+}
+
+// principal returns the principal identified by the credentials, named after the user when it is known
+func (p *PeerCredentials) principal() *Principal {
+    subject := strconv.Itoa(p.UID)
+    if account, err := user.LookupId(subject); err == nil {
+        subject = account.Username
+    }
+    return &Principal{Scheme: AuthSchemePeerCredentials, Subject: subject, Peer: p}
+}
+
+// ListenUnix listens on a Unix domain socket at path. A socket file left by a server that is no
+// longer running is removed first; the file is removed again when the listener is closed.
+// Access to the agent is governed by the permissions of the socket file.
+func ListenUnix(path string) (net.Listener, error) {
+    if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+        if conn, err := net.Dial("unix", path); err == nil {
+            conn.Close()
+            return nil, fmt.Errorf("a2a: socket %s is in use", path)
+        }
+        if err := os.Remove(path); err != nil {
+            return nil, err
+        }
+    }
+    return net.Listen("unix", path)
+}
+
+// ListenAndServeUnix starts an A2A server for this handler on a Unix domain socket at path
+func (h *ProtocolHandler) ListenAndServeUnix(path string) error {
+    return NewServer(nil, h).ListenAndServeUnix(path)
+}
+
+// ListenAndServeUnix listens on a Unix domain socket at path and serves requests.
+// Callers are authenticated by their peer credentials, which the auth middleware accepts
+// in place of the schemes of the agent card, so same-host agents need neither TLS nor tokens.
+func (s *Server) ListenAndServeUnix(path string) error {
+    listener, err := ListenUnix(path)
+    if err != nil {
+        return err
+    }
+    return s.Serve(listener)
+}
+
+// unixSocketURL splits a unix:// agent URL into the path of the socket and the HTTP URL requested over it
+func unixSocketURL(agentURL string) (socket, httpURL string, ok bool) {
+    u, err := url.Parse(agentURL)
+    if err != nil || u.Scheme != UnixScheme || u.Path == "" {
+        return "", "", false
+    }
+    return u.Path, "http://localhost/", true
+}
+
+// httpClientFor returns the HTTP client sending requests to the agent at agentURL.
+// Agents at unix:// URLs are reached through a client dialing their socket, created once per socket.
+func (c *Client) httpClientFor(agentURL string) *http.Client {
+    socket, _, ok := unixSocketURL(agentURL)
+    if !ok {
+        return c.httpClient
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if client, ok := c.unixClients[socket]; ok {
+        return client
+    }
+    dialer := &net.Dialer{}
+    client := &http.Client{
+        Transport: &http.Transport{
+            DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+                return dialer.DialContext(ctx, "unix", socket)
+            },
+        },
+        Timeout: c.httpClient.Timeout,
+    }
+    if c.unixClients == nil {
+        c.unixClients = make(map[string]*http.Client)
+    }
+    c.unixClients[socket] = client
+    return client
+}
@@ -0,0 +1,28 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements reading the peer credentials of Unix domain sockets on Linux
+package a2a
+
+import (
+    "net"
+    "syscall"
+)
+
+// readPeerCredentials returns the credentials of the process connected to conn, using SO_PEERCRED
+func readPeerCredentials(conn *net.UnixConn) (*PeerCredentials, error) {
+    raw, err := conn.SyscallConn()
+    if err != nil {
+        return nil, err
+    }
+    var ucred *syscall.Ucred
+    var credErr error
+    err = raw.Control(func(fd uintptr) {
+        ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+    })
+    if err != nil {
+        return nil, err
+    }
+    if credErr != nil {
+        return nil, credErr
+    }
+    return &PeerCredentials{UID: int(ucred.Uid), GID: int(ucred.Gid), PID: int(ucred.Pid)}, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the fallback for platforms without peer credentials of Unix domain sockets
+package a2a
+
+import "net"
+
+// readPeerCredentials reports that peer credentials are not available
+func readPeerCredentials(conn *net.UnixConn) (*PeerCredentials, error) {
+    return nil, ErrPeerCredentialsUnsupported
+}
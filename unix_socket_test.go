@@ -0,0 +1,89 @@
+package a2a_test
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "runtime"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestUnixSocketServer(t *testing.T) {
+    // Socket paths are limited to about a hundred bytes, so the directory is kept short
+    dir, err := os.MkdirTemp("", "a2a")
+    if err != nil {
+        t.Fatalf("MkdirTemp failed: %v", err)
+    }
+    defer os.RemoveAll(dir)
+    socket := filepath.Join(dir, "agent.sock")
+
+    card := a2a.NewAgentCard("Local Agent", "unix://"+socket, "1.0.0", a2a.AgentCapabilities{}, nil)
+    card.Authentication = &a2a.AgentAuthentication{Schemes: []string{a2a.AuthSchemeBearer}}
+    principals := make(chan *a2a.Principal, 1)
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            principal, _ := a2a.PrincipalFromContext(ctx)
+            principals <- principal
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        })
+    auth, err := a2a.NewAuthMiddleware(card, a2a.NewBearerAuthenticator(func(ctx context.Context, token string) (*a2a.Principal, error) {
+        return nil, a2a.ErrInvalidCredentials
+    }))
+    if err != nil {
+        t.Fatalf("NewAuthMiddleware failed: %v", err)
+    }
+    config := a2a.DefaultServerConfig()
+    config.Auth = auth
+    server := a2a.NewServer(config, handler)
+    served := make(chan error, 1)
+    go func() {
+        served <- server.ListenAndServeUnix(socket)
+    }()
+    defer func() {
+        server.Shutdown(context.Background())
+        if err := <-served; err != nil {
+            t.Errorf("ListenAndServeUnix failed: %v", err)
+        }
+    }()
+    for i := 0; i < 100; i++ {
+        if _, err := os.Stat(socket); err == nil {
+            break
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+
+    params := &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("hello")}),
+    }
+    client := a2a.NewClient()
+    if _, err := client.SendTask(context.Background(), params, card.URL); err == nil {
+        t.Error("Expected peer credentials to be refused until the middleware allows them")
+    }
+
+    auth.WithPeerUIDs(os.Getuid())
+    task, err := client.SendTask(context.Background(), params, card.URL)
+    if runtime.GOOS != "linux" {
+        // Without peer credentials the advertised bearer scheme still applies
+        if err == nil {
+            t.Error("Expected the request without a token to be rejected")
+        }
+        return
+    }
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("State mismatch: expected %s, got %s", a2a.TaskStateCompleted, task.Status.State)
+    }
+    principal := <-principals
+    if principal == nil || principal.Scheme != a2a.AuthSchemePeerCredentials || principal.Peer == nil {
+        t.Fatalf("Expected a peer credentials principal, got %#v", principal)
+    }
+    if principal.Peer.UID != os.Getuid() || principal.Peer.PID != os.Getpid() {
+        t.Errorf("Peer mismatch: got %#v", principal.Peer)
+    }
+}
@@ -0,0 +1,267 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements validation of the protocol types with detailed errors
+package a2a
+
+import (
+    "encoding/base64"
+    "fmt"
+    "net/url"
+    "strings"
+)
+
+// ValidationError describes a single invalid field.
+// Path is a JSON pointer to the field within the validated value.
+type ValidationError struct {
+    Path   string `json:"path"`
+    Reason string `json:"reason"`
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+    if e.Path == "" {
+        return e.Reason
+    }
+    return e.Path + ": " + e.Reason
+}
+
+// ValidationErrors lists every invalid field of a validated value
+type ValidationErrors []ValidationError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+    reasons := make([]string, len(e))
+    for i, err := range e {
+        reasons[i] = err.Error()
+    }
+    return "a2a: validation failed: " + strings.Join(reasons, "; ")
+}
+
+// ToJSONRPCError converts the validation errors into an invalid params error carrying them as data
+func (e ValidationErrors) ToJSONRPCError() *JSONRPCError {
+    return InvalidParamsError().WithData(e)
+}
+
+// validator accumulates validation errors
+type validator struct {
+    errs ValidationErrors
+}
+
+// add records an invalid field
+func (v *validator) add(path, reason string) {
+    v.errs = append(v.errs, ValidationError{Path: path, Reason: reason})
+}
+
+// required records an error if the value is empty
+func (v *validator) required(path, value string) {
+    if value == "" {
+        v.add(path, "is required")
+    }
+}
+
+// absoluteURL records an error if the value is not an absolute URL
+func (v *validator) absoluteURL(path, value string) {
+    if value == "" {
+        v.add(path, "is required")
+        return
+    }
+    parsed, err := url.Parse(value)
+    if err != nil || parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "" && parsed.Path == "") {
+        v.add(path, "must be an absolute URL")
+    }
+}
+
+// err returns the accumulated errors, or nil if there are none
+func (v *validator) err() error {
+    if len(v.errs) == 0 {
+        return nil
+    }
+    return v.errs
+}
+
+// Validate checks the agent card against the A2A schema
+func (a *AgentCard) Validate() error {
+    v := &validator{}
+    v.required("/name", a.Name)
+    v.absoluteURL("/url", a.URL)
+    v.required("/version", a.Version)
+    if a.Provider != nil {
+        v.required("/provider/organization", a.Provider.Organization)
+    }
+    if a.Authentication != nil && len(a.Authentication.Schemes) == 0 {
+        v.add("/authentication/schemes", "must list at least one scheme")
+    }
+
+    if len(a.Skills) == 0 {
+        v.add("/skills", "must list at least one skill")
+    }
+    seen := make(map[string]bool, len(a.Skills))
+    for i, skill := range a.Skills {
+        path := fmt.Sprintf("/skills/%d", i)
+        v.required(path+"/id", skill.ID)
+        v.required(path+"/name", skill.Name)
+        if skill.ID != "" && seen[skill.ID] {
+            v.add(path+"/id", "duplicates skill "+skill.ID)
+        }
+        seen[skill.ID] = true
+    }
+    return v.err()
+}
+
+// ValidateFileContent validates that the FileContent follows the constraints
+// (either 'bytes' or 'uri' must be provided, but not both)
+func ValidateFileContent(file FileContent) error {
+    v := &validator{}
+    validateFileContent(v, "", file)
+    return v.err()
+}
+
+// validateFileContent checks a file content at path
+func validateFileContent(v *validator, path string, file FileContent) {
+    switch {
+    case file.Bytes != "" && file.URI != "":
+        v.add(path, "must set either bytes or uri, not both")
+    case file.Bytes == "" && file.URI == "":
+        v.add(path, "must set either bytes or uri")
+    case file.Bytes != "":
+        if _, err := base64.StdEncoding.DecodeString(file.Bytes); err != nil {
+            v.add(path+"/bytes", "must be base64 encoded")
+        }
+    default:
+        v.absoluteURL(path+"/uri", file.URI)
+    }
+}
+
+// ValidatePart checks a message or artifact part
+func ValidatePart(part Part) error {
+    v := &validator{}
+    validatePart(v, "", part)
+    return v.err()
+}
+
+// validatePart checks a part at path
+func validatePart(v *validator, path string, part Part) {
+    if part == nil {
+        v.add(path, "is required")
+        return
+    }
+    switch p := part.(type) {
+    case TextPart:
+        validatePartType(v, path, p.Type, "text")
+    case FilePart:
+        validatePartType(v, path, p.Type, "file")
+        validateFileContent(v, path+"/file", p.File)
+    case DataPart:
+        validatePartType(v, path, p.Type, "data")
+        if p.Data == nil {
+            v.add(path+"/data", "is required")
+        }
+    default:
+        v.required(path+"/type", part.GetType())
+    }
+}
+
+// validatePartType checks the type discriminator of a built-in part
+func validatePartType(v *validator, path, got, want string) {
+    if got != want {
+        v.add(path+"/type", fmt.Sprintf("must be %q", want))
+    }
+}
+
+// validateParts checks a list of parts at path
+func validateParts(v *validator, path string, parts []Part) {
+    if len(parts) == 0 {
+        v.add(path, "must contain at least one part")
+    }
+    for i, part := range parts {
+        validatePart(v, fmt.Sprintf("%s/%d", path, i), part)
+    }
+}
+
+// Validate checks the message against the A2A schema
+func (m *Message) Validate() error {
+    v := &validator{}
+    validateMessage(v, "", m)
+    return v.err()
+}
+
+// validateMessage checks a message at path
+func validateMessage(v *validator, path string, m *Message) {
+    if m.Role != RoleUser && m.Role != RoleAgent {
+        v.add(path+"/role", fmt.Sprintf("must be %q or %q", RoleUser, RoleAgent))
+    }
+    validateParts(v, path+"/parts", m.Parts)
+}
+
+// Validate checks the artifact against the A2A schema
+func (a *Artifact) Validate() error {
+    v := &validator{}
+    validateArtifact(v, "", a)
+    return v.err()
+}
+
+// validateArtifact checks an artifact at path
+func validateArtifact(v *validator, path string, a *Artifact) {
+    if a.Index < 0 {
+        v.add(path+"/index", "must not be negative")
+    }
+    validateParts(v, path+"/parts", a.Parts)
+}
+
+// Validate checks the task against the A2A schema
+func (t *Task) Validate() error {
+    v := &validator{}
+    v.required("/id", t.ID)
+    if !t.Status.State.IsValid() {
+        v.add("/status/state", fmt.Sprintf("unknown state %q", t.Status.State))
+    }
+    if t.Status.Message != nil {
+        validateMessage(v, "/status/message", t.Status.Message)
+    }
+    for i := range t.History {
+        validateMessage(v, fmt.Sprintf("/history/%d", i), &t.History[i])
+    }
+    for i := range t.Artifacts {
+        validateArtifact(v, fmt.Sprintf("/artifacts/%d", i), &t.Artifacts[i])
+    }
+    return v.err()
+}
+
+// Validate checks the tasks/send params against the A2A schema
+func (p *TaskSendParams) Validate() error {
+    v := &validator{}
+    v.required("/id", p.ID)
+    validateMessage(v, "/message", &p.Message)
+    if p.PushNotification != nil {
+        validatePushNotificationConfig(v, "/pushNotification", p.PushNotification)
+    }
+    if p.HistoryLength != nil && *p.HistoryLength < 0 {
+        v.add("/historyLength", "must not be negative")
+    }
+    return v.err()
+}
+
+// Validate checks the push notification configuration against the A2A schema
+func (p *PushNotificationConfig) Validate() error {
+    v := &validator{}
+    validatePushNotificationConfig(v, "", p)
+    return v.err()
+}
+
+// validatePushNotificationConfig checks a push notification configuration at path
+func validatePushNotificationConfig(v *validator, path string, p *PushNotificationConfig) {
+    v.absoluteURL(path+"/url", p.URL)
+    if parsed, err := url.Parse(p.URL); err == nil && parsed.Scheme != "" && parsed.Scheme != "http" && parsed.Scheme != "https" {
+        v.add(path+"/url", "must use http or https")
+    }
+    if p.Authentication != nil && len(p.Authentication.Schemes) == 0 {
+        v.add(path+"/authentication/schemes", "must list at least one scheme")
+    }
+}
+
+// validationError converts an error returned by Validate into a JSON-RPC error
+func validationError(err error) *JSONRPCError {
+    if errs, ok := err.(ValidationErrors); ok {
+        return errs.ToJSONRPCError()
+    }
+    return InvalidParamsError().WithData(err.Error())
+}
@@ -0,0 +1,89 @@
+package a2a_test
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func validationPaths(t *testing.T, err error) map[string]bool {
+    t.Helper()
+    var errs a2a.ValidationErrors
+    if !errors.As(err, &errs) {
+        t.Fatalf("Expected ValidationErrors, got %v", err)
+    }
+    paths := make(map[string]bool, len(errs))
+    for _, e := range errs {
+        paths[e.Path] = true
+    }
+    return paths
+}
+
+func TestAgentCardValidate(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "http://localhost", "1.0", a2a.AgentCapabilities{},
+        []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    if err := card.Validate(); err != nil {
+        t.Fatalf("Valid card rejected: %v", err)
+    }
+
+    card.URL = "not a url"
+    card.Skills = append(card.Skills, a2a.AgentSkill{ID: "echo"})
+    paths := validationPaths(t, card.Validate())
+    for _, path := range []string{"/url", "/skills/1/id", "/skills/1/name"} {
+        if !paths[path] {
+            t.Errorf("Missing error for %s: %v", path, paths)
+        }
+    }
+}
+
+func TestTaskSendParamsValidate(t *testing.T) {
+    params := a2a.TaskSendParams{
+        Message: *a2a.NewMessage("system", []a2a.Part{
+            a2a.NewTextPart("hi"),
+            a2a.NewFilePart(a2a.FileContent{Bytes: "aGk=", URI: "https://example.com/f"}),
+            a2a.TextPart{Text: "untyped"},
+        }),
+        PushNotification: a2a.NewPushNotificationConfig("ftp://example.com"),
+    }
+
+    err := params.Validate()
+    paths := validationPaths(t, err)
+    for _, path := range []string{"/id", "/message/role", "/message/parts/1/file", "/message/parts/2/type", "/pushNotification/url"} {
+        if !paths[path] {
+            t.Errorf("Missing error for %s: %v", path, paths)
+        }
+    }
+    if paths["/message/parts/0"] || paths["/message/parts/0/type"] {
+        t.Errorf("Valid part rejected: %v", paths)
+    }
+
+    rpcErr := err.(a2a.ValidationErrors).ToJSONRPCError()
+    if rpcErr.Code != a2a.ErrCodeInvalidParams || rpcErr.Data == nil {
+        t.Errorf("Unexpected JSON-RPC error: %+v", rpcErr)
+    }
+}
+
+func TestValidateFileContent(t *testing.T) {
+    if err := a2a.ValidateFileContent(a2a.NewFileContentWithBytes("f", "text/plain", "aGk=")); err != nil {
+        t.Errorf("Valid file rejected: %v", err)
+    }
+    if err := a2a.ValidateFileContent(a2a.FileContent{}); err == nil {
+        t.Errorf("Empty file accepted")
+    }
+    if err := a2a.ValidateFileContent(a2a.NewFileContentWithBytes("f", "text/plain", "%%%")); err == nil {
+        t.Errorf("Invalid base64 accepted")
+    }
+}
+
+func TestProtocolHandlerRejectsInvalidParams(t *testing.T) {
+    handler := a2a.NewProtocolHandler(nil)
+    response := postJSONRPC(t, handler, `{"jsonrpc":"2.0","id":1,"method":"tasks/send","params":{"id":"t","message":{"role":"user","parts":[]}}}`)
+    if !response.IsError() || response.Error.Code != a2a.ErrCodeInvalidParams {
+        t.Fatalf("Expected invalid params, got %+v", response.Error)
+    }
+    data, ok := response.Error.Data.([]interface{})
+    if !ok || len(data) != 1 || data[0].(map[string]interface{})["path"] != "/message/parts" {
+        t.Errorf("Unexpected error data: %v", response.Error.Data)
+    }
+}
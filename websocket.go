@@ -0,0 +1,493 @@
+// Package a2a implements the A2A protocol operations and data structures
+// This file implements the WebSocket transport multiplexing requests and streams over one connection
+package a2a
+
+import (
+    "bufio"
+    "context"
+    "crypto/rand"
+    "crypto/sha1"
+    "encoding/base64"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// WebSocketSubprotocol is the subprotocol negotiated for A2A JSON-RPC over WebSocket
+const WebSocketSubprotocol = "a2a.jsonrpc"
+
+// Default limits of WebSocket connections
+const (
+    DefaultWebSocketPingInterval   = 30 * time.Second
+    DefaultWebSocketMaxMessageSize = 16 << 20
+    DefaultWebSocketRedialBackoff  = 30 * time.Second
+)
+
+// ErrWebSocketMessageTooLarge is returned when a peer sends a message above the size limit
+var ErrWebSocketMessageTooLarge = errors.New("a2a: websocket message too large")
+
+// websocketGUID is appended to the handshake key to derive the accept value (RFC 6455)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes
+const (
+    wsContinuation = 0x0
+    wsText         = 0x1
+    wsBinary       = 0x2
+    wsClose        = 0x8
+    wsPing         = 0x9
+    wsPong         = 0xA
+)
+
+// WebSocket close codes
+const (
+    wsCloseNormal        = 1000
+    wsCloseProtocolError = 1002
+    wsCloseTooLarge      = 1009
+)
+
+// WithWebSocketKeepalive sets how often WebSocket connections are pinged; a connection
+// silent for two intervals is closed. Zero disables keepalive.
+func (h *ProtocolHandler) WithWebSocketKeepalive(interval time.Duration) *ProtocolHandler {
+    h.wsPingInterval = &interval
+    return h
+}
+
+// isWebSocketUpgrade reports whether the request opens a WebSocket connection
+func isWebSocketUpgrade(r *http.Request) bool {
+    return r.Method == http.MethodGet &&
+        headerHasToken(r.Header, "Connection", "upgrade") &&
+        strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// headerHasToken reports whether a comma-separated header holds the token
+func headerHasToken(header http.Header, name, token string) bool {
+    for _, value := range header.Values(name) {
+        for _, field := range strings.Split(value, ",") {
+            if strings.EqualFold(strings.TrimSpace(field), token) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// websocketAccept derives the Sec-WebSocket-Accept value of a handshake key
+func websocketAccept(key string) string {
+    sum := sha1.Sum([]byte(key + websocketGUID))
+    return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// serveWebSocket upgrades the request and serves JSON-RPC over the WebSocket connection.
+// Streams use flow control with DefaultStreamWindow.
+func (h *ProtocolHandler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+    if r.Header.Get("Sec-WebSocket-Version") != "13" {
+        w.Header().Set("Sec-WebSocket-Version", "13")
+        http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+        return
+    }
+    key := r.Header.Get("Sec-WebSocket-Key")
+    if key == "" {
+        http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+        return
+    }
+    hijacker, ok := w.(http.Hijacker)
+    if !ok {
+        http.Error(w, "websocket is not supported by the connection", http.StatusInternalServerError)
+        return
+    }
+    requested := ProtocolVersion(r.Header.Get(ProtocolVersionHeader))
+    version := h.requestVersion("", requested)
+
+    conn, rw, err := hijacker.Hijack()
+    if err != nil {
+        return
+    }
+    // The deadlines of the HTTP server do not apply to the long-lived connection
+    conn.SetDeadline(time.Time{})
+
+    fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n", websocketAccept(key))
+    if headerHasToken(r.Header, "Sec-WebSocket-Protocol", WebSocketSubprotocol) {
+        fmt.Fprintf(rw, "Sec-WebSocket-Protocol: %s\r\n", WebSocketSubprotocol)
+    }
+    fmt.Fprintf(rw, "%s: %s\r\n\r\n", ProtocolVersionHeader, version)
+    if err := rw.Flush(); err != nil {
+        conn.Close()
+        return
+    }
+
+    ws := newWebSocketConn(conn, rw.Reader, false)
+    interval := DefaultWebSocketPingInterval
+    if h.wsPingInterval != nil {
+        interval = *h.wsPingInterval
+    }
+    go ws.keepalive(interval)
+    // Requests keep the values of the upgrade request, such as the principal, but not its cancellation
+    h.serveFrames(context.WithoutCancel(r.Context()), ws, requested, DefaultStreamWindow)
+    ws.close(wsCloseNormal)
+}
+
+// webSocketConn reads and writes WebSocket messages
+type webSocketConn struct {
+    conn   io.ReadWriteCloser
+    reader *bufio.Reader
+    // client connections mask the frames they write and expect unmasked frames
+    client         bool
+    maxMessageSize int64
+
+    writeMu   sync.Mutex
+    lastSeen  int64
+    closeOnce sync.Once
+    done      chan struct{}
+}
+
+// newWebSocketConn wraps an upgraded connection
+func newWebSocketConn(conn io.ReadWriteCloser, reader *bufio.Reader, client bool) *webSocketConn {
+    c := &webSocketConn{
+        conn:           conn,
+        reader:         reader,
+        client:         client,
+        maxMessageSize: DefaultWebSocketMaxMessageSize,
+        done:           make(chan struct{}),
+    }
+    c.touch()
+    return c
+}
+
+// touch records that the peer was heard from
+func (c *webSocketConn) touch() {
+    atomic.StoreInt64(&c.lastSeen, time.Now().UnixNano())
+}
+
+// readFrame implements frameConn, returning the next data message.
+// Control frames are answered as they arrive; a close frame ends the connection with io.EOF.
+func (c *webSocketConn) readFrame() ([]byte, error) {
+    var message []byte
+    fragmented := false
+    for {
+        fin, opcode, payload, err := c.readWireFrame()
+        if err != nil {
+            return nil, err
+        }
+        c.touch()
+
+        switch opcode {
+        case wsPing:
+            c.writeWireFrame(wsPong, payload)
+            continue
+        case wsPong:
+            continue
+        case wsClose:
+            code := wsCloseNormal
+            if len(payload) >= 2 {
+                code = int(binary.BigEndian.Uint16(payload))
+            }
+            c.close(code)
+            return nil, io.EOF
+        case wsText, wsBinary:
+            if fragmented {
+                return nil, c.fail(wsCloseProtocolError, "a2a: websocket message started before the previous one ended")
+            }
+            message = payload
+        case wsContinuation:
+            if !fragmented {
+                return nil, c.fail(wsCloseProtocolError, "a2a: websocket continuation without a message")
+            }
+            message = append(message, payload...)
+        default:
+            return nil, c.fail(wsCloseProtocolError, fmt.Sprintf("a2a: unknown websocket opcode %d", opcode))
+        }
+
+        if c.maxMessageSize > 0 && int64(len(message)) > c.maxMessageSize {
+            c.close(wsCloseTooLarge)
+            return nil, ErrWebSocketMessageTooLarge
+        }
+        if fin {
+            return message, nil
+        }
+        fragmented = true
+    }
+}
+
+// readWireFrame reads one frame and unmasks its payload
+func (c *webSocketConn) readWireFrame() (bool, byte, []byte, error) {
+    var header [2]byte
+    if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+        if errors.Is(err, net.ErrClosed) {
+            return false, 0, nil, io.EOF
+        }
+        return false, 0, nil, err
+    }
+    fin := header[0]&0x80 != 0
+    opcode := header[0] & 0x0F
+    masked := header[1]&0x80 != 0
+    if header[0]&0x70 != 0 {
+        return false, 0, nil, c.fail(wsCloseProtocolError, "a2a: websocket frame uses reserved bits")
+    }
+    if masked == c.client {
+        return false, 0, nil, c.fail(wsCloseProtocolError, "a2a: websocket frame masking is wrong for its direction")
+    }
+
+    length := uint64(header[1] & 0x7F)
+    switch length {
+    case 126:
+        var extended [2]byte
+        if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+            return false, 0, nil, err
+        }
+        length = uint64(binary.BigEndian.Uint16(extended[:]))
+    case 127:
+        var extended [8]byte
+        if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+            return false, 0, nil, err
+        }
+        length = binary.BigEndian.Uint64(extended[:])
+    }
+    if opcode >= wsClose && (length > 125 || !fin) {
+        return false, 0, nil, c.fail(wsCloseProtocolError, "a2a: invalid websocket control frame")
+    }
+    if c.maxMessageSize > 0 && length > uint64(c.maxMessageSize) {
+        c.close(wsCloseTooLarge)
+        return false, 0, nil, ErrWebSocketMessageTooLarge
+    }
+
+    var mask [4]byte
+    if masked {
+        if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+            return false, 0, nil, err
+        }
+    }
+    payload := make([]byte, length)
+    if _, err := io.ReadFull(c.reader, payload); err != nil {
+        return false, 0, nil, err
+    }
+    if masked {
+        for i := range payload {
+            payload[i] ^= mask[i%4]
+        }
+    }
+    return fin, opcode, payload, nil
+}
+
+// writeFrame implements frameConn, sending the message as one text frame
+func (c *webSocketConn) writeFrame(data []byte) error {
+    return c.writeWireFrame(wsText, data)
+}
+
+// writeWireFrame writes one unfragmented frame, masking it on client connections
+func (c *webSocketConn) writeWireFrame(opcode byte, payload []byte) error {
+    frame := make([]byte, 0, len(payload)+14)
+    frame = append(frame, 0x80|opcode)
+    maskBit := byte(0)
+    if c.client {
+        maskBit = 0x80
+    }
+    switch {
+    case len(payload) < 126:
+        frame = append(frame, maskBit|byte(len(payload)))
+    case len(payload) <= 0xFFFF:
+        frame = append(frame, maskBit|126)
+        frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+    default:
+        frame = append(frame, maskBit|127)
+        frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+    }
+    if c.client {
+        var mask [4]byte
+        if _, err := rand.Read(mask[:]); err != nil {
+            return err
+        }
+        frame = append(frame, mask[:]...)
+        start := len(frame)
+        frame = append(frame, payload...)
+        for i := range frame[start:] {
+            frame[start+i] ^= mask[i%4]
+        }
+    } else {
+        frame = append(frame, payload...)
+    }
+
+    c.writeMu.Lock()
+    defer c.writeMu.Unlock()
+    _, err := c.conn.Write(frame)
+    return err
+}
+
+// keepalive pings the peer every interval and closes the connection once it has been silent for two
+func (c *webSocketConn) keepalive(interval time.Duration) {
+    if interval <= 0 {
+        return
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ticker.C:
+            if time.Since(time.Unix(0, atomic.LoadInt64(&c.lastSeen))) > 2*interval {
+                c.close(wsCloseNormal)
+                return
+            }
+            c.writeWireFrame(wsPing, nil)
+        case <-c.done:
+            return
+        }
+    }
+}
+
+// fail closes the connection after a protocol violation and returns the error describing it
+func (c *webSocketConn) fail(code int, message string) error {
+    c.close(code)
+    return errors.New(message)
+}
+
+// close sends a close frame with the code and closes the connection
+func (c *webSocketConn) close(code int) {
+    c.closeOnce.Do(func() {
+        close(c.done)
+        c.writeWireFrame(wsClose, binary.BigEndian.AppendUint16(nil, uint16(code)))
+        c.conn.Close()
+    })
+}
+
+// WebSocketTransport is a Transport multiplexing the requests and streams of a client over one
+// WebSocket connection. Streams use flow control: the agent sends DefaultStreamWindow events
+// ahead of the client and then waits until the client has read them, so a slow reader of one
+// stream holds back only that stream.
+type WebSocketTransport struct {
+    conn *webSocketConn
+    mux  *rpcMux
+    // dialed is set on connections the client opened itself from the agent card
+    dialed bool
+}
+
+// DialWebSocket opens a WebSocket connection to the agent at url, given as a ws, wss, http or https URL.
+// The handshake carries the headers and the credentials of the client, and uses the transport of its HTTP client.
+func (c *Client) DialWebSocket(ctx context.Context, url string) (*WebSocketTransport, error) {
+    return c.dialWebSocket(ctx, url, url)
+}
+
+// dialWebSocket opens a WebSocket connection to wsURL with the credentials for the agent at agentURL
+func (c *Client) dialWebSocket(ctx context.Context, agentURL, wsURL string) (*WebSocketTransport, error) {
+    switch {
+    case strings.HasPrefix(wsURL, "ws://"):
+        wsURL = "http://" + strings.TrimPrefix(wsURL, "ws://")
+    case strings.HasPrefix(wsURL, "wss://"):
+        wsURL = "https://" + strings.TrimPrefix(wsURL, "wss://")
+    }
+    // The connection outlives the context of the request that opened it
+    req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, wsURL, nil)
+    if err != nil {
+        return nil, err
+    }
+    for key, values := range c.headers {
+        req.Header[key] = append([]string(nil), values...)
+    }
+    nonce := make([]byte, 16)
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+    key := base64.StdEncoding.EncodeToString(nonce)
+    req.Header.Set("Connection", "Upgrade")
+    req.Header.Set("Upgrade", "websocket")
+    req.Header.Set("Sec-WebSocket-Version", "13")
+    req.Header.Set("Sec-WebSocket-Key", key)
+    req.Header.Set("Sec-WebSocket-Protocol", WebSocketSubprotocol)
+    req.Header.Set(ProtocolVersionHeader, string(c.versionFor(agentURL)))
+    provider, err := c.credentialsFor(ctx, agentURL)
+    if err != nil {
+        return nil, err
+    }
+    if provider != nil {
+        if err := provider.Apply(ctx, req); err != nil {
+            return nil, fmt.Errorf("a2a: applying %s credentials: %w", provider.Scheme(), err)
+        }
+    }
+
+    // The round tripper is used directly, since a client timeout would also cut the connection
+    roundTripper := c.httpClient.Transport
+    if roundTripper == nil {
+        roundTripper = http.DefaultTransport
+    }
+    resp, err := roundTripper.RoundTrip(req)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode != http.StatusSwitchingProtocols {
+        defer resp.Body.Close()
+        data, _ := io.ReadAll(resp.Body)
+        return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}
+    }
+    body, ok := resp.Body.(io.ReadWriteCloser)
+    if !ok || resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+        resp.Body.Close()
+        return nil, errors.New("a2a: invalid websocket handshake response")
+    }
+
+    t := &WebSocketTransport{conn: newWebSocketConn(body, bufio.NewReader(body), true)}
+    t.mux = newRPCMux(t.conn.writeFrame)
+    t.mux.window = DefaultStreamWindow
+    go t.readLoop()
+    go t.conn.keepalive(c.wsPingInterval)
+    return t, nil
+}
+
+// Call implements Transport
+func (t *WebSocketTransport) Call(ctx context.Context, request []byte) ([]byte, error) {
+    return t.mux.call(ctx, request)
+}
+
+// Stream implements Transport
+func (t *WebSocketTransport) Stream(ctx context.Context, request []byte) (EventReader, error) {
+    return t.mux.stream(ctx, request)
+}
+
+// Close implements Transport
+func (t *WebSocketTransport) Close() error {
+    t.mux.fail(ErrTransportClosed)
+    t.conn.close(wsCloseNormal)
+    return nil
+}
+
+// Closed reports whether the connection has ended
+func (t *WebSocketTransport) Closed() bool {
+    select {
+    case <-t.mux.done:
+        return true
+    default:
+        return false
+    }
+}
+
+// readLoop dispatches the messages of the agent until the connection ends
+func (t *WebSocketTransport) readLoop() {
+    for {
+        frame, err := t.conn.readFrame()
+        if err != nil {
+            t.mux.fail(err)
+            t.conn.close(wsCloseNormal)
+            return
+        }
+        t.mux.dispatch(frame)
+    }
+}
+
+// WithWebSocketKeepalive sets how often the WebSocket connections of the client are pinged;
+// a connection silent for two intervals is closed. Zero disables keepalive.
+func (c *Client) WithWebSocketKeepalive(interval time.Duration) *Client {
+    c.wsPingInterval = interval
+    return c
+}
+
+// WithWebSocketRedialBackoff sets how long an agent is reached over HTTP after the WebSocket
+// connection advertised by its card failed to open, before the connection is dialed again
+func (c *Client) WithWebSocketRedialBackoff(backoff time.Duration) *Client {
+    c.wsRedialBackoff = backoff
+    return c
+}
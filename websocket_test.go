@@ -0,0 +1,256 @@
+package a2a_test
+
+import (
+    "context"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/A2AGateway/a2a-protocol"
+)
+
+func TestWebSocketTransport(t *testing.T) {
+    server := httptest.NewServer(newTransportAgent().WithWebSocketKeepalive(20 * time.Millisecond))
+    defer server.Close()
+
+    // The client does not ping, but answers the pings of the agent
+    client := a2a.NewClient().WithWebSocketKeepalive(0)
+    transport, err := client.DialWebSocket(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+    if err != nil {
+        t.Fatalf("DialWebSocket failed: %v", err)
+    }
+    defer transport.Close()
+    exerciseTransport(t, transport)
+
+    time.Sleep(100 * time.Millisecond)
+    if transport.Closed() {
+        t.Fatal("Connection closed although pings were answered")
+    }
+    exerciseTransport(t, transport)
+}
+
+func TestWebSocketChosenFromAgentCard(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "", "1.0", a2a.AgentCapabilities{Streaming: true}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        })
+    var posts int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method == http.MethodPost {
+            atomic.AddInt32(&posts, 1)
+        }
+        handler.ServeHTTP(w, r)
+    }))
+    defer server.Close()
+    card.URL = server.URL
+    card.WithInterface("ws"+strings.TrimPrefix(server.URL, "http"), a2a.TransportWebSocket)
+
+    client := a2a.NewClient()
+    if _, err := client.NegotiateVersion(context.Background(), server.URL); err != nil {
+        t.Fatalf("NegotiateVersion failed: %v", err)
+    }
+    task, err := client.SendTask(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+    if task.Status.State != a2a.TaskStateCompleted {
+        t.Errorf("State mismatch: expected %s, got %s", a2a.TaskStateCompleted, task.Status.State)
+    }
+    if n := atomic.LoadInt32(&posts); n != 0 {
+        t.Errorf("Expected the request over WebSocket, got %d HTTP posts", n)
+    }
+}
+
+func TestWebSocketStreamBackpressure(t *testing.T) {
+    const events = 3 * a2a.DefaultStreamWindow
+    var written [2]int32
+    handler := a2a.NewProtocolHandler(nil).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            counter := &written[0]
+            if params.ID == "fast" {
+                counter = &written[1]
+            }
+            for i := 0; i < events; i++ {
+                if err := stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}}); err != nil {
+                    return err
+                }
+                atomic.AddInt32(counter, 1)
+            }
+            return stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true})
+        })
+    server := httptest.NewServer(handler)
+    defer server.Close()
+
+    url := "ws" + strings.TrimPrefix(server.URL, "http")
+    client := a2a.NewClient()
+    transport, err := client.DialWebSocket(context.Background(), url)
+    if err != nil {
+        t.Fatalf("DialWebSocket failed: %v", err)
+    }
+    defer transport.Close()
+    client.WithTransport(url, transport)
+
+    subscribe := func(id string) *a2a.TaskEventStream {
+        stream, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+            ID:      id,
+            Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+        }, url)
+        if err != nil {
+            t.Fatalf("SendTaskSubscribe failed: %v", err)
+        }
+        return stream
+    }
+    slow := subscribe("slow")
+    defer slow.Close()
+    fast := subscribe("fast")
+    defer fast.Close()
+
+    // The unread stream holds back only its own handler
+    if n := len(collectEvents(t, fast)); n != events+1 {
+        t.Errorf("Fast stream event count mismatch: expected %d, got %d", events+1, n)
+    }
+    if n := atomic.LoadInt32(&written[0]); n > a2a.DefaultStreamWindow {
+        t.Errorf("Slow stream sent %d events ahead of its reader, window is %d", n, a2a.DefaultStreamWindow)
+    }
+    if n := len(collectEvents(t, slow)); n != events+1 {
+        t.Errorf("Slow stream event count mismatch: expected %d, got %d", events+1, n)
+    }
+}
+
+// connListener records the connections it accepts so a test can drop them
+type connListener struct {
+    net.Listener
+    mu    sync.Mutex
+    conns []net.Conn
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+    conn, err := l.Listener.Accept()
+    if err == nil {
+        l.mu.Lock()
+        l.conns = append(l.conns, conn)
+        l.mu.Unlock()
+    }
+    return conn, err
+}
+
+func (l *connListener) drop() {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    for _, conn := range l.conns {
+        conn.Close()
+    }
+    l.conns = nil
+}
+
+// newWebSocketAgent serves the handler with the card advertising a WebSocket interface,
+// counting the upgrades; the first failUpgrades of them are refused
+func newWebSocketAgent(card *a2a.AgentCard, handler http.Handler, failUpgrades int32) (*httptest.Server, *connListener, *int32) {
+    var upgrades int32
+    server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("Upgrade") == "websocket" && atomic.AddInt32(&upgrades, 1) <= failUpgrades {
+            http.Error(w, "unavailable", http.StatusServiceUnavailable)
+            return
+        }
+        handler.ServeHTTP(w, r)
+    }))
+    listener := &connListener{Listener: server.Listener}
+    server.Listener = listener
+    server.Start()
+    card.URL = server.URL
+    card.WithInterface("ws"+strings.TrimPrefix(server.URL, "http"), a2a.TransportWebSocket)
+    return server, listener, &upgrades
+}
+
+func TestWebSocketDialedOnceAndRetried(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "", "1.0", a2a.AgentCapabilities{}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    handler := a2a.NewProtocolHandler(card).
+        HandleTaskSend(func(ctx context.Context, params *a2a.TaskSendParams) (*a2a.Task, error) {
+            return a2a.NewTask(params.ID, a2a.TaskStateCompleted), nil
+        })
+    server, _, upgrades := newWebSocketAgent(card, handler, 1)
+    defer server.Close()
+
+    client := a2a.NewClient().WithWebSocketRedialBackoff(0)
+    if _, err := client.NegotiateVersion(context.Background(), server.URL); err != nil {
+        t.Fatalf("NegotiateVersion failed: %v", err)
+    }
+    send := func(id string) error {
+        _, err := client.SendTask(context.Background(), &a2a.TaskSendParams{
+            ID:      id,
+            Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+        }, server.URL)
+        return err
+    }
+    // The refused handshake falls back to HTTP
+    if err := send("task-0"); err != nil {
+        t.Fatalf("SendTask failed: %v", err)
+    }
+
+    // Once the backoff has passed, concurrent requests share one new connection
+    var wg sync.WaitGroup
+    for i := 1; i <= 8; i++ {
+        wg.Add(1)
+        go func(id string) {
+            defer wg.Done()
+            if err := send(id); err != nil {
+                t.Errorf("SendTask failed: %v", err)
+            }
+        }("task-" + strconv.Itoa(i))
+    }
+    wg.Wait()
+    if n := atomic.LoadInt32(upgrades); n != 2 {
+        t.Errorf("Expected one refused and one successful handshake, got %d", n)
+    }
+}
+
+func TestWebSocketStreamResumesAfterRedial(t *testing.T) {
+    card := a2a.NewAgentCard("Test", "", "1.0", a2a.AgentCapabilities{Streaming: true}, []a2a.AgentSkill{{ID: "echo", Name: "Echo"}})
+    log := a2a.NewEventLog()
+    handler := a2a.NewProtocolHandler(card).
+        WithEventLog(log).
+        HandleTaskSendSubscribe(func(ctx context.Context, params *a2a.TaskSendParams, stream *a2a.StreamWriter) error {
+            stream.WriteStatusUpdate(a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateWorking}})
+            // The task completes while the connection is lost
+            log.Append(&a2a.TaskEvent{Status: &a2a.TaskStatusUpdateEvent{ID: params.ID, Status: a2a.TaskStatus{State: a2a.TaskStateCompleted}, Final: true}})
+            <-ctx.Done()
+            return ctx.Err()
+        })
+    server, listener, upgrades := newWebSocketAgent(card, handler, 0)
+    defer server.Close()
+
+    client := a2a.NewClient().WithStreamReconnect(3, time.Millisecond)
+    if _, err := client.NegotiateVersion(context.Background(), server.URL); err != nil {
+        t.Fatalf("NegotiateVersion failed: %v", err)
+    }
+    stream, err := client.SendTaskSubscribe(context.Background(), &a2a.TaskSendParams{
+        ID:      "task-1",
+        Message: *a2a.NewMessage(a2a.RoleUser, []a2a.Part{a2a.NewTextPart("go")}),
+    }, server.URL)
+    if err != nil {
+        t.Fatalf("SendTaskSubscribe failed: %v", err)
+    }
+    defer stream.Close()
+    if _, err := stream.Recv(); err != nil {
+        t.Fatalf("Recv failed: %v", err)
+    }
+
+    listener.drop()
+    events := collectEvents(t, stream)
+    if len(events) != 1 || events[0].EventID != "2" || !events[0].Status.Final {
+        t.Fatalf("Expected the final event after resuming, got %+v", events)
+    }
+    if n := atomic.LoadInt32(upgrades); n != 2 {
+        t.Errorf("Expected the stream resumed over a new connection, got %d handshakes", n)
+    }
+}